@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/legostin/reap/internal/config"
+	"github.com/legostin/reap/internal/exporter"
+	"github.com/spf13/cobra"
+)
+
+var exporterListen string
+
+var exporterCmd = &cobra.Command{
+	Use:   "exporter",
+	Short: "Serve listening ports as Prometheus metrics",
+	Long: `Serve listening ports as Prometheus metrics on /metrics.
+
+Every scrape runs a scan. Metrics:
+  reap_port_listening                 1 per port, protocol, process, user and container
+  reap_process_resident_memory_bytes  RSS of each process owning a port
+  reap_user_ports                     listening ports per user
+  reap_scan_success                   1 if this scrape's scan succeeded, 0 if not
+  reap_scans_total                    scans performed
+  reap_scan_errors_total              scans that failed
+  reap_scan_duration_seconds          time spent scanning (summary)
+
+The metrics name every process, user and command holding a port, so the
+exporter listens on 127.0.0.1 only. To let a Prometheus server on another host
+scrape it, listen on all interfaces deliberately with --listen :9717 (or a
+specific address) and restrict access with a firewall.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		scanner, err := newScanner(config.Load())
+		if err != nil {
+			return err
+		}
+
+		mux := http.NewServeMux()
+		mux.Handle("GET /metrics", exporter.New(scanner))
+		mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintln(w, `<html><body><h1>reap exporter</h1><p><a href="/metrics">Metrics</a></p></body></html>`)
+		})
+		srv := &http.Server{Addr: exporterListen, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		go func() {
+			<-ctx.Done()
+			shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			srv.Shutdown(shutdown)
+		}()
+
+		fmt.Fprintf(os.Stderr, "serving metrics on %s/metrics\n", exporterListen)
+		if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	},
+}
+
+func init() {
+	exporterCmd.Flags().StringVar(&exporterListen, "listen", "127.0.0.1:9717", "address to serve metrics on (:9717 for all interfaces)")
+}
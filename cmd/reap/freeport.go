@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/legostin/reap/internal/config"
+	"github.com/legostin/reap/internal/ports"
+	"github.com/spf13/cobra"
+)
+
+var (
+	freeRange      string
+	freeCount      int
+	freeContiguous bool
+	freeUDP        bool
+	freeJSON       bool
+	freeAll        bool
+	freeReserve    time.Duration
+)
+
+var freePortCmd = &cobra.Command{
+	Use:   "free-port",
+	Short: "Print unused ports",
+	Long: `Print the first unused ports in a range, one per line.
+
+A port is free when the scanner sees no socket on it and it can really be
+bound. Ports marked as well-known services by the color map or port labels are
+skipped unless --all is given.
+
+--reserve keeps the ports bound after printing them and is meant to run in
+the background, with stdout redirected:
+
+  reap free-port --reserve 30s > port.txt &`,
+	Args:          cobra.NoArgs,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		from, to, err := parsePortRange(freeRange)
+		if err != nil {
+			return err
+		}
+		cmd.SilenceUsage = true
+
+		cfg := config.Load()
+		scanner, err := newScanner(cfg)
+		if err != nil {
+			return err
+		}
+		results, err := scanner.Scan()
+		if err != nil {
+			return fmt.Errorf("scan failed: %w", err)
+		}
+
+		q := ports.FreePortQuery{
+			From: from, To: to,
+			Count:      freeCount,
+			Contiguous: freeContiguous,
+			Protocol:   "tcp",
+		}
+		if freeUDP {
+			q.Protocol = "udp"
+		}
+		if !freeAll {
+			q.Skip = cfg.WellKnown
+		}
+		found, held, err := ports.FindFreePorts(q, results)
+		if err != nil {
+			return err
+		}
+		defer closeAll(held)
+
+		if freeJSON {
+			json.NewEncoder(os.Stdout).Encode(found)
+		} else {
+			for _, p := range found {
+				fmt.Println(p)
+			}
+		}
+
+		if freeReserve > 0 {
+			// Keep the ports bound so nothing else grabs them before the
+			// caller is ready. Closing stdout lets a reader waiting for EOF
+			// go on while the ports are held.
+			os.Stdout.Close()
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			select {
+			case <-ctx.Done():
+			case <-time.After(freeReserve):
+			}
+		}
+		return nil
+	},
+}
+
+// parsePortRange parses "3000-3999" or a single port.
+func parsePortRange(s string) (from, to int, err error) {
+	lo, hi, isRange := strings.Cut(s, "-")
+	if from, err = strconv.Atoi(lo); err != nil {
+		return 0, 0, fmt.Errorf("invalid range %q", s)
+	}
+	to = from
+	if isRange {
+		if to, err = strconv.Atoi(hi); err != nil {
+			return 0, 0, fmt.Errorf("invalid range %q", s)
+		}
+	}
+	return from, to, nil
+}
+
+func closeAll(closers []io.Closer) {
+	for _, c := range closers {
+		c.Close()
+	}
+}
+
+func init() {
+	freePortCmd.Flags().StringVarP(&freeRange, "range", "r", "3000-32767", "ports to search, e.g. 8000-8999")
+	freePortCmd.Flags().IntVarP(&freeCount, "count", "c", 1, "number of ports to find")
+	freePortCmd.Flags().BoolVar(&freeContiguous, "contiguous", false, "the ports must be consecutive")
+	freePortCmd.Flags().BoolVar(&freeUDP, "udp", false, "find UDP ports instead of TCP")
+	freePortCmd.Flags().BoolVar(&freeJSON, "json", false, "output a JSON array")
+	freePortCmd.Flags().BoolVar(&freeAll, "all", false, "do not skip well-known service ports")
+	freePortCmd.Flags().DurationVar(&freeReserve, "reserve", 0, "keep the ports bound for this long after printing them; run in the background")
+}
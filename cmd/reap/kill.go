@@ -4,23 +4,71 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"syscall"
+	"time"
 
+	"github.com/legostin/reap/internal/config"
 	"github.com/legostin/reap/internal/ports"
 	"github.com/spf13/cobra"
 )
 
 var (
-	killForce bool
-	killYes   bool
+	killForce    bool
+	killYes      bool
+	killEscalate bool
+	killGrace    time.Duration
+	killTree     bool
+	killGroup    bool
+	killSession  bool
+
+	killContainerAction string
+	killTimeout         time.Duration
 )
 
 var killCmd = &cobra.Command{
-	Use:   "kill <port>...",
+	Use:   "kill <port>[/tcp|/udp]...",
 	Short: "Kill processes on specified ports",
 	Args:  cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		scanner := ports.NewScanner()
+		cfg := config.Load()
+		scanner, err := newScanner(cfg)
+		if err != nil {
+			return err
+		}
+		if killEscalate && killForce {
+			return fmt.Errorf("--escalate and --force cannot be combined")
+		}
+		grace := time.Duration(cfg.KillGrace) * time.Second
+		if cmd.Flags().Changed("grace") {
+			grace = killGrace
+		}
+		killer := ports.NewKiller(scanner, grace)
+
+		action, err := ports.ParseContainerAction(killContainerAction)
+		if err != nil {
+			return err
+		}
+		if flag := signalFlag(); flag != "" && cmd.Flags().Changed("container-action") {
+			return fmt.Errorf("%s cannot be combined with --container-action", flag)
+		}
+		timeout := time.Duration(cfg.ContainerTimeout) * time.Second
+		if cmd.Flags().Changed("timeout") {
+			timeout = killTimeout
+		}
+		handled := make(map[string]bool) // container IDs already acted on
+		roots := make(map[int]bool)      // tree roots already offered
+
+		scope, tree := treeScope()
+		if tree && killEscalate {
+			return fmt.Errorf("--escalate cannot be combined with --tree")
+		}
+		var procTable []ports.Process
+		if tree {
+			if procTable, err = ports.ListProcesses(); err != nil {
+				return fmt.Errorf("process table: %w", err)
+			}
+		}
 		results, err := scanner.Scan()
 		if err != nil {
 			return fmt.Errorf("scan failed: %w", err)
@@ -32,21 +80,42 @@ var killCmd = &cobra.Command{
 		}
 
 		for _, arg := range args {
-			port, err := strconv.Atoi(arg)
+			port, protocol, err := parsePortArg(arg)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "invalid port: %s\n", arg)
 				continue
 			}
 
-			procs, ok := portMap[port]
-			if !ok {
-				fmt.Fprintf(os.Stderr, "no process found on port %d\n", port)
+			procs := matchProtocol(portMap[port], protocol)
+			if len(procs) == 0 {
+				fmt.Fprintf(os.Stderr, "no process found on port %s\n", arg)
 				continue
 			}
 
 			for _, p := range procs {
+				// The host PID of a container port is usually a runtime proxy;
+				// signalling it would break the runtime, not stop the service.
+				if ct := p.ContainerInfo; ct != nil {
+					if !handled[ct.ID] {
+						handled[ct.ID] = true
+						if flag := signalFlag(); flag != "" {
+							fmt.Fprintf(os.Stderr, "port %d/%s belongs to container %s, %s does not apply\n", p.Port, p.Protocol, p.Container, flag)
+						}
+						containerAction(p, action, timeout)
+					}
+					continue
+				}
+				if tree {
+					// a PID on several ports, or on tcp and udp, or on IPv4
+					// and IPv6, is one tree
+					if !roots[p.PID] {
+						roots[p.PID] = true
+						killProcessTree(p, ports.CollectTree(procTable, p.PID, scope))
+					}
+					continue
+				}
 				if !killYes {
-					fmt.Printf("kill %s (PID %d) on port %d? [y/N] ", p.Process, p.PID, p.Port)
+					fmt.Printf("kill %s (PID %d) on port %d/%s? [y/N] ", p.Process, p.PID, p.Port, p.Protocol)
 					var answer string
 					fmt.Scanln(&answer)
 					if answer != "y" && answer != "Y" {
@@ -55,12 +124,17 @@ var killCmd = &cobra.Command{
 					}
 				}
 
+				if killEscalate {
+					escalate(killer, p)
+					continue
+				}
+
 				sig := syscall.SIGTERM
 				if killForce {
 					sig = syscall.SIGKILL
 				}
 
-				if err := syscall.Kill(p.PID, sig); err != nil {
+				if err := ports.SendSignal(p.PID, sig); err != nil {
 					fmt.Fprintf(os.Stderr, "failed to kill PID %d: %s\n", p.PID, err)
 				} else {
 					sigName := "SIGTERM"
@@ -75,7 +149,153 @@ var killCmd = &cobra.Command{
 	},
 }
 
+// containerAction asks for confirmation and applies action to the
+// container owning p through its runtime.
+func containerAction(p ports.PortInfo, action ports.ContainerAction, timeout time.Duration) {
+	if !killYes {
+		fmt.Printf("%s container %s (%s) on port %d/%s? [y/N] ", action, p.Container, p.ContainerInfo.Image, p.Port, p.Protocol)
+		var answer string
+		fmt.Scanln(&answer)
+		if answer != "y" && answer != "Y" {
+			fmt.Println("skipped")
+			return
+		}
+	}
+	if err := ports.ApplyContainerAction(p.ContainerInfo, action, timeout); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to %s container %s: %s\n", action, p.Container, err)
+		return
+	}
+	fmt.Printf("%s container %s\n", action.Done(), p.Container)
+}
+
+// signalFlag returns the first of --force, --escalate and the tree flags
+// that is set, or "". Those only apply to host processes.
+func signalFlag() string {
+	switch {
+	case killForce:
+		return "--force"
+	case killEscalate:
+		return "--escalate"
+	case killSession:
+		return "--session"
+	case killGroup:
+		return "--group"
+	case killTree:
+		return "--tree"
+	}
+	return ""
+}
+
+// treeScope returns the scope selected by --tree, --group or --session and
+// whether a tree kill was requested at all.
+func treeScope() (ports.TreeScope, bool) {
+	switch {
+	case killSession:
+		return ports.ScopeSession, true
+	case killGroup:
+		return ports.ScopeGroup, true
+	default:
+		return ports.ScopeDescendants, killTree
+	}
+}
+
+// killProcessTree lists targets, asks for confirmation and signals them
+// leaves-first.
+func killProcessTree(p ports.PortInfo, targets []ports.Process) {
+	if len(targets) == 0 {
+		fmt.Fprintf(os.Stderr, "refusing to kill the tree of %s (PID %d)\n", p.Process, p.PID)
+		return
+	}
+	fmt.Printf("process tree of %s (PID %d) on port %d/%s:\n", p.Process, p.PID, p.Port, p.Protocol)
+	for _, t := range targets {
+		fmt.Printf("  %7d  %s\n", t.PID, t.Name)
+	}
+	if !killYes {
+		fmt.Printf("kill %d processes? [y/N] ", len(targets))
+		var answer string
+		fmt.Scanln(&answer)
+		if answer != "y" && answer != "Y" {
+			fmt.Println("skipped")
+			return
+		}
+	}
+
+	sig, sigName := syscall.SIGTERM, "SIGTERM"
+	if killForce {
+		sig, sigName = syscall.SIGKILL, "SIGKILL"
+	}
+	sent, err := ports.SignalProcesses(targets, sig)
+	fmt.Printf("sent %s to %d processes\n", sigName, sent)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to kill some processes:\n%s\n", err)
+	}
+}
+
+// escalate sends SIGTERM to p, escalating to SIGKILL after the grace
+// period, and reports each stage and the final outcome.
+func escalate(killer *ports.Killer, p ports.PortInfo) {
+	// Progress is only reported once SIGTERM was sent, so a failed signal
+	// is never announced as sent.
+	started := false
+	var last ports.KillStage
+	res := killer.Escalate(p, func(progress ports.KillProgress) {
+		if !started {
+			started = true
+			fmt.Printf("sent SIGTERM to %s (PID %d), waiting up to %s\n", p.Process, p.PID, killer.Grace())
+		} else if progress.Stage == last {
+			return
+		}
+		last = progress.Stage
+		switch progress.Stage {
+		case ports.StageKill:
+			fmt.Printf("PID %d ignored SIGTERM, sent SIGKILL\n", p.PID)
+		case ports.StageRelease:
+			fmt.Printf("PID %d exited, checking port %d\n", p.PID, p.Port)
+		}
+	})
+	if res.Err != nil {
+		fmt.Fprintf(os.Stderr, "failed to kill %s\n", res.Describe())
+		return
+	}
+	fmt.Println(res.Describe())
+}
+
+// parsePortArg parses "3000" or "53/udp". An empty protocol matches any.
+func parsePortArg(arg string) (port int, protocol string, err error) {
+	portStr, protocol, _ := strings.Cut(arg, "/")
+	protocol = strings.ToLower(protocol)
+	if protocol != "" && protocol != "tcp" && protocol != "udp" {
+		return 0, "", fmt.Errorf("unknown protocol %q", protocol)
+	}
+	port, err = strconv.Atoi(portStr)
+	if err != nil {
+		return 0, "", err
+	}
+	return port, protocol, nil
+}
+
+// matchProtocol keeps entries using protocol, or all of them if it is empty.
+func matchProtocol(procs []ports.PortInfo, protocol string) []ports.PortInfo {
+	if protocol == "" {
+		return procs
+	}
+	var matched []ports.PortInfo
+	for _, p := range procs {
+		if p.Protocol == protocol {
+			matched = append(matched, p)
+		}
+	}
+	return matched
+}
+
 func init() {
 	killCmd.Flags().BoolVarP(&killForce, "force", "f", false, "send SIGKILL instead of SIGTERM")
 	killCmd.Flags().BoolVarP(&killYes, "yes", "y", false, "skip confirmation")
+	killCmd.Flags().BoolVarP(&killEscalate, "escalate", "e", false, "send SIGTERM, then SIGKILL if the process outlives the grace period")
+	killCmd.Flags().BoolVarP(&killTree, "tree", "t", false, "kill the process and all its descendants, leaves first")
+	killCmd.Flags().BoolVar(&killGroup, "group", false, "like --tree, plus every process in the same process group")
+	killCmd.Flags().BoolVar(&killSession, "session", false, "like --tree, plus every process in the same session")
+	killCmd.Flags().StringVar(&killContainerAction, "container-action", "stop", "action for ports owned by a container: stop, restart, pause or unpause")
+	killCmd.Flags().DurationVar(&killTimeout, "timeout", 10*time.Second, "how long a container gets to stop before it is killed (overrides container_timeout)")
+	killCmd.Flags().DurationVar(&killGrace, "grace", 5*time.Second, "grace period before SIGKILL with --escalate (overrides kill_grace)")
 }
@@ -0,0 +1,108 @@
+package main
+
+import "github.com/legostin/reap/internal/ports"
+
+// The legacy types freeze the JSON printed by `reap list --json` before the
+// versioned schema: a bare array with Go field names. They are printed with
+// --legacy-json and must not change.
+
+type legacyPortInfo struct {
+	Port      int
+	PID       int
+	PPID      int
+	Process   string
+	User      string
+	Command   string
+	Protocol  string
+	Address   string
+	Uptime    string
+	Memory    string
+	RSS       int64
+	Container string
+	CWD       string
+
+	ContainerInfo *legacyContainer
+	PortForward   *legacyPortForward
+
+	Connections []legacyConnection
+}
+
+type legacyContainer struct {
+	ID          string
+	Name        string
+	Image       string
+	State       string
+	Runtime     string
+	Project     string
+	Service     string
+	PIDs        []int
+	HostNetwork bool
+	IPs         []string
+	Ports       []legacyContainerPort
+}
+
+type legacyContainerPort struct {
+	HostIP        string
+	HostPort      int
+	ContainerPort int
+	Protocol      string
+}
+
+type legacyPortForward struct {
+	Namespace  string
+	Kind       string
+	Name       string
+	Context    string
+	RemotePort int
+}
+
+type legacyConnection struct {
+	RemoteAddr   string
+	RemotePort   int
+	State        string
+	LocalPID     int
+	LocalProcess string
+}
+
+func toLegacy(items []ports.PortInfo) []legacyPortInfo {
+	if items == nil {
+		return nil
+	}
+	out := make([]legacyPortInfo, len(items))
+	for i, p := range items {
+		out[i] = legacyPortInfo{
+			Port:      p.Port,
+			PID:       p.PID,
+			PPID:      p.PPID,
+			Process:   p.Process,
+			User:      p.User,
+			Command:   p.Command,
+			Protocol:  p.Protocol,
+			Address:   p.Address,
+			Uptime:    p.Uptime,
+			Memory:    p.Memory,
+			RSS:       p.RSS,
+			Container: p.Container,
+			CWD:       p.CWD,
+		}
+		if ct := p.ContainerInfo; ct != nil {
+			lc := &legacyContainer{
+				ID: ct.ID, Name: ct.Name, Image: ct.Image, State: ct.State,
+				Runtime: ct.Runtime, Project: ct.Project, Service: ct.Service,
+				PIDs: ct.PIDs, HostNetwork: ct.HostNetwork, IPs: ct.IPs,
+			}
+			for _, cp := range ct.Ports {
+				lc.Ports = append(lc.Ports, legacyContainerPort(cp))
+			}
+			out[i].ContainerInfo = lc
+		}
+		if f := p.PortForward; f != nil {
+			lf := legacyPortForward(*f)
+			out[i].PortForward = &lf
+		}
+		for _, c := range p.Connections {
+			out[i].Connections = append(out[i].Connections, legacyConnection(c))
+		}
+	}
+	return out
+}
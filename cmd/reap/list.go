@@ -4,83 +4,175 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"strconv"
+	"slices"
 	"strings"
-	"text/tabwriter"
+	"time"
 
+	"github.com/legostin/reap/internal/config"
 	"github.com/legostin/reap/internal/ports"
 	"github.com/spf13/cobra"
 )
 
 var (
-	listPort   int
-	listName   string
-	listJSON   bool
+	listFilters   filterFlags
+	listJSON      bool
+	listOutput    string
+	listTemplate  string
+	listColumns   string
+	listNoHeaders bool
+	listLegacy    bool
+	listSort      string
+	listReverse   bool
 )
 
+// filterFlags are the port filters shared by list, watch and wait.
+type filterFlags struct {
+	port      int
+	name      string
+	container string
+	tcp       bool
+	udp       bool
+	query     string
+}
+
+func (f *filterFlags) register(cmd *cobra.Command) {
+	cmd.Flags().IntVarP(&f.port, "port", "p", 0, "filter by port number")
+	cmd.Flags().StringVarP(&f.name, "name", "n", "", "filter by process name")
+	cmd.Flags().StringVar(&f.container, "container", "", "filter by container name")
+	cmd.Flags().BoolVar(&f.tcp, "tcp", false, "show only TCP listeners")
+	cmd.Flags().BoolVar(&f.udp, "udp", false, "show only UDP sockets")
+	cmd.Flags().StringVar(&f.query, "query", "", "filter with a query, e.g. 'port:3000-3999 user:dev'")
+}
+
+// filter builds the filter selected by --port, --name, --container, --tcp,
+// --udp and --query.
+func (f *filterFlags) filter() (ports.Filter, error) {
+	// --tcp and --udp together are the same as neither
+	protocol := ""
+	if f.tcp != f.udp {
+		protocol = "tcp"
+		if f.udp {
+			protocol = "udp"
+		}
+	}
+	filter := ports.Filter{Port: f.port, Name: f.name, Protocol: protocol, Container: f.container}
+	if f.query != "" {
+		query, err := ports.ParseQuery(f.query)
+		if err != nil {
+			return ports.Filter{}, fmt.Errorf("invalid --query: %w", err)
+		}
+		filter.Query = query
+	}
+	return filter, nil
+}
+
 var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List listening ports (non-interactive)",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		scanner := ports.NewScanner()
+		format, err := outputFormat(cmd)
+		if err != nil {
+			return err
+		}
+		cols, err := selectColumns(listColumns, format)
+		if err != nil {
+			return err
+		}
+		filter, err := listFilters.filter()
+		if err != nil {
+			return err
+		}
+		sortKey, err := ports.ParseSortKey(listSort)
+		if err != nil {
+			return err
+		}
+		cmd.SilenceUsage = true
+
+		scanner, err := newScanner(config.Load())
+		if err != nil {
+			return err
+		}
+		scannedAt := time.Now()
 		results, err := scanner.Scan()
 		if err != nil {
 			return fmt.Errorf("scan failed: %w", err)
 		}
 
-		filtered := filterResults(results)
+		filtered := filter.Apply(results)
+		ports.Sort(filtered, sortKey, listReverse)
 
-		if listJSON {
-			return printJSON(filtered)
+		headers := !listNoHeaders
+		switch format {
+		case "json":
+			if listLegacy {
+				return printJSON(toLegacy(filtered))
+			}
+			return printJSON(ports.NewReport(filtered, scannedAt))
+		case "ndjson":
+			return writeNDJSON(os.Stdout, filtered, cols)
+		case "csv":
+			return writeCSV(os.Stdout, filtered, cols, headers)
+		case "tsv":
+			return writeTSV(os.Stdout, filtered, cols, headers)
+		case "yaml":
+			return writeYAML(os.Stdout, filtered, cols)
+		case "template":
+			return writeTemplate(os.Stdout, filtered, listTemplate)
+		default:
+			return writeTable(os.Stdout, filtered, cols, headers)
 		}
-		printTable(filtered)
-		return nil
 	},
 }
 
 func init() {
-	listCmd.Flags().IntVarP(&listPort, "port", "p", 0, "filter by port number")
-	listCmd.Flags().StringVarP(&listName, "name", "n", "", "filter by process name")
-	listCmd.Flags().BoolVar(&listJSON, "json", false, "output as JSON")
+	listFilters.register(listCmd)
+	registerOutputFlags(listCmd)
+	listCmd.Flags().StringVar(&listSort, "sort", "port", "sort by port, pid, process, user, memory, uptime or cpu")
+	listCmd.Flags().BoolVar(&listReverse, "reverse", false, "reverse the sort order")
 }
 
-func filterResults(results []ports.PortInfo) []ports.PortInfo {
-	if listPort == 0 && listName == "" {
-		return results
-	}
-	var filtered []ports.PortInfo
-	for _, p := range results {
-		if listPort != 0 && p.Port != listPort {
-			continue
-		}
-		if listName != "" && !strings.Contains(strings.ToLower(p.Process), strings.ToLower(listName)) {
-			continue
-		}
-		filtered = append(filtered, p)
-	}
-	return filtered
+// registerOutputFlags adds the flags read by outputFormat and the writers.
+func registerOutputFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&listJSON, "json", false, "output as JSON (same as --output json)")
+	cmd.Flags().StringVarP(&listOutput, "output", "o", "table", "output format: "+strings.Join(outputFormats, ", "))
+	cmd.Flags().StringVar(&listTemplate, "template", "", "Go template executed per entry, e.g. '{{.Port}} {{.PID}}'")
+	cmd.Flags().StringVar(&listColumns, "columns", "", "comma-separated columns to print, in order ("+columnNames()+")")
+	cmd.Flags().BoolVar(&listNoHeaders, "no-headers", false, "omit the header row")
+	cmd.Flags().BoolVar(&listLegacy, "legacy-json", false, "print JSON in the old unversioned format (a bare array with Go field names)")
 }
 
-func printTable(items []ports.PortInfo) {
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(w, "PORT\tPID\tPROCESS\tUSER\tMEMORY\tUPTIME\tCONTAINER\tDIR\tADDRESS")
-	for _, p := range items {
-		container := p.Container
-		if container == "" {
-			container = "-"
-		}
-		cwd := p.CWD
-		if cwd == "" {
-			cwd = "-"
+// outputFormat resolves --output, --json and --template. --template alone
+// implies --output template.
+func outputFormat(cmd *cobra.Command) (string, error) {
+	format := strings.ToLower(listOutput)
+	if !slices.Contains(outputFormats, format) {
+		return "", fmt.Errorf("unknown output format %q (available: %s)", listOutput, strings.Join(outputFormats, ", "))
+	}
+	outputSet := cmd.Flags().Changed("output")
+	if listJSON {
+		if outputSet && format != "json" {
+			return "", fmt.Errorf("--json conflicts with --output %s", format)
 		}
-		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s:%d\n",
-			strconv.Itoa(p.Port), p.PID, p.Process, p.User, p.Memory, p.Uptime, container, cwd, p.Address, p.Port)
+		format = "json"
+	}
+	if listTemplate != "" && !outputSet {
+		format = "template"
+	}
+	switch {
+	case format == "template" && listTemplate == "":
+		return "", fmt.Errorf("--output template requires --template")
+	case format != "template" && listTemplate != "":
+		return "", fmt.Errorf("--template requires --output template")
+	case (format == "json" || format == "template") && listColumns != "":
+		return "", fmt.Errorf("--columns cannot be used with --output %s", format)
+	case listLegacy && format != "json":
+		return "", fmt.Errorf("--legacy-json requires --json")
 	}
-	w.Flush()
+	return format, nil
 }
 
-func printJSON(items []ports.PortInfo) error {
+func printJSON(v any) error {
 	enc := json.NewEncoder(os.Stdout)
 	enc.SetIndent("", "  ")
-	return enc.Encode(items)
+	return enc.Encode(v)
 }
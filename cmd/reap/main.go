@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
@@ -11,26 +12,64 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var backendFlag string
+
 var rootCmd = &cobra.Command{
 	Use:   "reap",
 	Short: "Interactive TUI for viewing and killing processes on ports",
 	Long:  "reap — like htop meets lsof. View listening ports, filter, sort, and kill processes.",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		cfg := config.Load()
-		scanner := ports.NewScanner()
+		scanner, err := newScanner(cfg)
+		if err != nil {
+			return err
+		}
 		model := tui.New(scanner, cfg)
 		p := tea.NewProgram(model, tea.WithAltScreen())
-		_, err := p.Run()
+		_, err = p.Run()
 		return err
 	},
 }
 
+// newScanner builds a scanner from the --backend flag, falling back to the
+// backend set in the config file. Ports are labelled from the config and
+// the service catalog.
+func newScanner(cfg config.Config) (ports.Scanner, error) {
+	backend := cfg.Backend
+	if backendFlag != "" {
+		backend = backendFlag
+	}
+	s, err := ports.NewScannerWithBackend(backend)
+	if err != nil {
+		return nil, err
+	}
+	return ports.WithLabels(s, cfg.Label), nil
+}
+
+// exitError makes the process exit with code instead of 1.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+
 func main() {
+	rootCmd.PersistentFlags().StringVar(&backendFlag, "backend", "", "socket scanner backend (linux: netlink, procfs)")
 	rootCmd.AddCommand(listCmd)
 	rootCmd.AddCommand(killCmd)
+	rootCmd.AddCommand(watchCmd)
+	rootCmd.AddCommand(waitCmd)
+	rootCmd.AddCommand(freePortCmd)
+	rootCmd.AddCommand(serveCmd)
+	rootCmd.AddCommand(exporterCmd)
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintln(os.Stderr, err)
+		var exit *exitError
+		if errors.As(err, &exit) {
+			os.Exit(exit.code)
+		}
 		os.Exit(1)
 	}
 }
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"time"
+
+	"github.com/legostin/reap/internal/ports"
+)
+
+// column is a field that list can print. value returns a number, a string,
+// or nil when the field does not apply to the entry or is unknown.
+type column struct {
+	name   string
+	header string
+	value  func(p ports.PortInfo) any
+}
+
+// columns are all printable fields in wide order.
+var columns = []column{
+	{"port", "PORT", func(p ports.PortInfo) any { return p.Port }},
+	{"proto", "PROTO", func(p ports.PortInfo) any { return p.Protocol }},
+	{"pid", "PID", func(p ports.PortInfo) any { return p.PID }},
+	{"ppid", "PPID", func(p ports.PortInfo) any { return p.PPID }},
+	{"process", "PROCESS", func(p ports.PortInfo) any { return p.Process }},
+	{"user", "USER", func(p ports.PortInfo) any { return p.User }},
+	{"memory", "MEMORY", func(p ports.PortInfo) any { return p.Memory }},
+	{"rss", "RSS", func(p ports.PortInfo) any { return p.RSS }},
+	{"uptime", "UPTIME", func(p ports.PortInfo) any { return p.Uptime }},
+	{"uptime_seconds", "UPTIME_S", func(p ports.PortInfo) any {
+		if p.StartTime.IsZero() {
+			return nil
+		}
+		return p.UptimeSeconds
+	}},
+	{"start_time", "STARTED", func(p ports.PortInfo) any {
+		if p.StartTime.IsZero() {
+			return nil
+		}
+		return p.StartTime.Format(time.RFC3339)
+	}},
+	{"cpu", "CPU%", func(p ports.PortInfo) any { return math.Round(p.CPUPercent*10) / 10 }},
+	{"threads", "THREADS", func(p ports.PortInfo) any { return knownCount(p.Threads) }},
+	{"fds", "FDS", func(p ports.PortInfo) any { return knownCount(p.FDs) }},
+	{"read", "READ", func(p ports.PortInfo) any { return p.ReadBytes }},
+	{"write", "WRITE", func(p ports.PortInfo) any { return p.WriteBytes }},
+	{"conns", "CONNS", func(p ports.PortInfo) any {
+		if p.Protocol == "udp" {
+			return nil
+		}
+		return len(p.Connections)
+	}},
+	{"label", "LABEL", func(p ports.PortInfo) any { return p.Label }},
+	{"container", "CONTAINER", func(p ports.PortInfo) any { return p.Container }},
+	{"dir", "DIR", func(p ports.PortInfo) any { return p.CWD }},
+	{"address", "ADDRESS", func(p ports.PortInfo) any { return p.Address + ":" + strconv.Itoa(p.Port) }},
+	{"command", "COMMAND", func(p ports.PortInfo) any { return p.Command }},
+}
+
+// defaultColumns are the columns of the plain table.
+var defaultColumns = []string{"port", "proto", "pid", "process", "label", "user", "memory", "uptime", "conns", "container", "dir", "address"}
+
+// Output formats accepted by --output.
+var outputFormats = []string{"table", "wide", "json", "ndjson", "csv", "tsv", "yaml", "template"}
+
+// selectColumns resolves a comma-separated --columns value. An empty value
+// selects the defaults: the plain table columns for table output and every
+// column otherwise.
+func selectColumns(spec, format string) ([]column, error) {
+	if spec == "" {
+		if format != "table" {
+			return columns, nil
+		}
+		spec = strings.Join(defaultColumns, ",")
+	}
+	var selected []column
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		c, ok := findColumn(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown column %q (available: %s)", name, columnNames())
+		}
+		selected = append(selected, c)
+	}
+	return selected, nil
+}
+
+// knownCount returns nil for a thread or FD count of zero, which only
+// happens when the count could not be read.
+func knownCount(n int) any {
+	if n == 0 {
+		return nil
+	}
+	return n
+}
+
+func findColumn(name string) (column, bool) {
+	for _, c := range columns {
+		if c.name == name {
+			return c, true
+		}
+	}
+	return column{}, false
+}
+
+func columnNames() string {
+	names := make([]string, len(columns))
+	for i, c := range columns {
+		names[i] = c.name
+	}
+	return strings.Join(names, ", ")
+}
+
+// cellText formats a column value for text formats. Missing values are
+// rendered as placeholder.
+func cellText(v any, placeholder string) string {
+	switch v := v.(type) {
+	case nil:
+		return placeholder
+	case string:
+		if v == "" {
+			return placeholder
+		}
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func writeTable(w io.Writer, items []ports.PortInfo, cols []column, headers bool) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if headers {
+		row := make([]string, len(cols))
+		for i, c := range cols {
+			row[i] = c.header
+		}
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	for _, p := range items {
+		row := make([]string, len(cols))
+		for i, c := range cols {
+			row[i] = cellText(c.value(p), "-")
+		}
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+func writeCSV(w io.Writer, items []ports.PortInfo, cols []column, headers bool) error {
+	cw := csv.NewWriter(w)
+	if headers {
+		row := make([]string, len(cols))
+		for i, c := range cols {
+			row[i] = c.name
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	for _, p := range items {
+		row := make([]string, len(cols))
+		for i, c := range cols {
+			row[i] = cellText(c.value(p), "")
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// tsvEscaper keeps every entry on one line with a fixed number of fields.
+var tsvEscaper = strings.NewReplacer("\t", " ", "\n", " ", "\r", " ")
+
+func writeTSV(w io.Writer, items []ports.PortInfo, cols []column, headers bool) error {
+	if headers {
+		row := make([]string, len(cols))
+		for i, c := range cols {
+			row[i] = c.name
+		}
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	for _, p := range items {
+		row := make([]string, len(cols))
+		for i, c := range cols {
+			row[i] = tsvEscaper.Replace(cellText(c.value(p), ""))
+		}
+		if _, err := fmt.Fprintln(w, strings.Join(row, "\t")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeNDJSON prints one object per entry with the columns as keys, in
+// column order.
+func writeNDJSON(w io.Writer, items []ports.PortInfo, cols []column) error {
+	for _, p := range items {
+		var b strings.Builder
+		b.WriteByte('{')
+		for i, c := range cols {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			key, _ := json.Marshal(c.name)
+			val, _ := json.Marshal(c.value(p))
+			b.Write(key)
+			b.WriteByte(':')
+			b.Write(val)
+		}
+		b.WriteString("}\n")
+		if _, err := io.WriteString(w, b.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeYAML prints a sequence of mappings. Strings are written as JSON
+// strings, which are valid double-quoted YAML scalars.
+func writeYAML(w io.Writer, items []ports.PortInfo, cols []column) error {
+	if len(items) == 0 {
+		_, err := fmt.Fprintln(w, "[]")
+		return err
+	}
+	var b strings.Builder
+	for _, p := range items {
+		for i, c := range cols {
+			if i == 0 {
+				b.WriteString("- ")
+			} else {
+				b.WriteString("  ")
+			}
+			val, _ := json.Marshal(c.value(p))
+			fmt.Fprintf(&b, "%s: %s\n", c.name, val)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeTemplate executes tmpl once per entry, each followed by a newline.
+func writeTemplate(w io.Writer, items []ports.PortInfo, tmpl string) error {
+	t, err := template.New("output").Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return fmt.Errorf("invalid template: %w", err)
+	}
+	for _, p := range items {
+		if err := t.Execute(w, p); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintln(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
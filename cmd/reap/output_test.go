@@ -0,0 +1,275 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/legostin/reap/internal/ports"
+	"github.com/spf13/cobra"
+)
+
+func outputItems() []ports.PortInfo {
+	return []ports.PortInfo{
+		{Port: 3000, Protocol: "tcp", PID: 1 << 30, Process: "node", Command: `node "server.js", --port=3000`},
+		{Port: 53, Protocol: "udp", PID: 1<<30 + 1, Process: "dns\tmasq", Command: "dnsmasq\n--keep-in-foreground\r"},
+	}
+}
+
+func mustColumns(t *testing.T, spec string) []column {
+	t.Helper()
+	cols, err := selectColumns(spec, "csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cols
+}
+
+func TestWriteCSV(t *testing.T) {
+	cols := mustColumns(t, "port,process,command")
+	tests := []struct {
+		headers bool
+		want    string
+	}{
+		{true, "port,process,command\n" +
+			"3000,node,\"node \"\"server.js\"\", --port=3000\"\n" +
+			"53,dns\tmasq,\"dnsmasq\n--keep-in-foreground\r\"\n"},
+		{false, "3000,node,\"node \"\"server.js\"\", --port=3000\"\n" +
+			"53,dns\tmasq,\"dnsmasq\n--keep-in-foreground\r\"\n"},
+	}
+	for _, tt := range tests {
+		var b bytes.Buffer
+		if err := writeCSV(&b, outputItems(), cols, tt.headers); err != nil {
+			t.Fatal(err)
+		}
+		if b.String() != tt.want {
+			t.Errorf("headers %v:\ngot  %q\nwant %q", tt.headers, b.String(), tt.want)
+		}
+	}
+}
+
+func TestWriteTSV(t *testing.T) {
+	cols := mustColumns(t, "port,process,command,container")
+	tests := []struct {
+		headers bool
+		want    string
+	}{
+		{true, "port\tprocess\tcommand\tcontainer\n" +
+			"3000\tnode\tnode \"server.js\", --port=3000\t\n" +
+			"53\tdns masq\tdnsmasq --keep-in-foreground \t\n"},
+		{false, "3000\tnode\tnode \"server.js\", --port=3000\t\n" +
+			"53\tdns masq\tdnsmasq --keep-in-foreground \t\n"},
+	}
+	for _, tt := range tests {
+		var b bytes.Buffer
+		if err := writeTSV(&b, outputItems(), cols, tt.headers); err != nil {
+			t.Fatal(err)
+		}
+		if b.String() != tt.want {
+			t.Errorf("headers %v:\ngot  %q\nwant %q", tt.headers, b.String(), tt.want)
+		}
+		for _, line := range strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n") {
+			if n := strings.Count(line, "\t"); n != len(cols)-1 {
+				t.Errorf("line %q has %d tabs, want %d", line, n, len(cols)-1)
+			}
+		}
+	}
+}
+
+func TestWriteTable(t *testing.T) {
+	cols := mustColumns(t, "port,process,container")
+	tests := []struct {
+		headers bool
+		want    string
+	}{
+		{true, "PORT  PROCESS  CONTAINER\n3000  node     -\n"},
+		{false, "3000  node  -\n"},
+	}
+	for _, tt := range tests {
+		var b bytes.Buffer
+		if err := writeTable(&b, outputItems()[:1], cols, tt.headers); err != nil {
+			t.Fatal(err)
+		}
+		if b.String() != tt.want {
+			t.Errorf("headers %v:\ngot  %q\nwant %q", tt.headers, b.String(), tt.want)
+		}
+	}
+}
+
+func TestWriteYAML(t *testing.T) {
+	cols := mustColumns(t, "port,process,command,uptime_seconds")
+	tests := []struct {
+		name  string
+		items []ports.PortInfo
+		want  string
+	}{
+		{"empty", nil, "[]\n"},
+		{"special strings", []ports.PortInfo{
+			{Port: 3000, Process: "yes", Command: `say "hi": #1`},
+			{Port: 53, Process: "null", Command: "a\nb"},
+		}, "- port: 3000\n" +
+			"  process: \"yes\"\n" +
+			"  command: \"say \\\"hi\\\": #1\"\n" +
+			"  uptime_seconds: null\n" +
+			"- port: 53\n" +
+			"  process: \"null\"\n" +
+			"  command: \"a\\nb\"\n" +
+			"  uptime_seconds: null\n"},
+	}
+	for _, tt := range tests {
+		var b bytes.Buffer
+		if err := writeYAML(&b, tt.items, cols); err != nil {
+			t.Fatal(err)
+		}
+		if b.String() != tt.want {
+			t.Errorf("%s:\ngot  %q\nwant %q", tt.name, b.String(), tt.want)
+		}
+	}
+}
+
+func TestWriteNDJSON(t *testing.T) {
+	var b bytes.Buffer
+	if err := writeNDJSON(&b, outputItems(), mustColumns(t, "port,process,conns")); err != nil {
+		t.Fatal(err)
+	}
+	want := "{\"port\":3000,\"process\":\"node\",\"conns\":0}\n" +
+		"{\"port\":53,\"process\":\"dns\\tmasq\",\"conns\":null}\n"
+	if b.String() != want {
+		t.Errorf("got %q, want %q", b.String(), want)
+	}
+}
+
+func TestWriteTemplate(t *testing.T) {
+	tests := []struct {
+		tmpl    string
+		want    string
+		wantErr string
+	}{
+		{"{{.Port}}/{{.Protocol}} {{.Process}}", "3000/tcp node\n53/udp dns\tmasq\n", ""},
+		{"{{.Port", "", "invalid template"},
+		{"{{.NoSuchField}}", "", "NoSuchField"},
+		{"{{index .Connections 5}}", "", "index out of range"},
+	}
+	for _, tt := range tests {
+		var b bytes.Buffer
+		err := writeTemplate(&b, outputItems(), tt.tmpl)
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("%q: unexpected error %v", tt.tmpl, err)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("%q: got error %v, want one containing %q", tt.tmpl, err, tt.wantErr)
+		case tt.wantErr == "" && b.String() != tt.want:
+			t.Errorf("%q: got %q, want %q", tt.tmpl, b.String(), tt.want)
+		}
+	}
+}
+
+func TestSelectColumns(t *testing.T) {
+	tests := []struct {
+		spec    string
+		format  string
+		want    []string
+		wantErr string
+	}{
+		{"", "table", defaultColumns, ""},
+		{"", "csv", nil, ""}, // every column
+		{"PID, Port ,process", "csv", []string{"pid", "port", "process"}, ""},
+		{"port,nope", "tsv", nil, `unknown column "nope"`},
+		{"port,", "table", nil, `unknown column ""`},
+	}
+	for _, tt := range tests {
+		cols, err := selectColumns(tt.spec, tt.format)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%q: got error %v, want one containing %q", tt.spec, err, tt.wantErr)
+			} else if !strings.Contains(err.Error(), "available: port, proto") {
+				t.Errorf("%q: error should list the columns: %v", tt.spec, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error %v", tt.spec, err)
+			continue
+		}
+		want := tt.want
+		if want == nil {
+			want = strings.Split(columnNames(), ", ")
+		}
+		var got []string
+		for _, c := range cols {
+			got = append(got, c.name)
+		}
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Errorf("%q %s: got %v, want %v", tt.spec, tt.format, got, want)
+		}
+	}
+}
+
+func TestOutputFormat(t *testing.T) {
+	tests := []struct {
+		args    []string
+		want    string
+		wantErr string
+	}{
+		{nil, "table", ""},
+		{[]string{"--json"}, "json", ""},
+		{[]string{"-o", "JSON"}, "json", ""},
+		{[]string{"--json", "--output", "json"}, "json", ""},
+		{[]string{"--json", "--output", "csv"}, "", "--json conflicts with --output csv"},
+		{[]string{"--output", "xml"}, "", `unknown output format "xml"`},
+		{[]string{"--legacy-json"}, "", "--legacy-json requires --json"},
+		{[]string{"--legacy-json", "-o", "yaml"}, "", "--legacy-json requires --json"},
+		{[]string{"--legacy-json", "--json"}, "json", ""},
+		{[]string{"--legacy-json", "-o", "json"}, "json", ""},
+		{[]string{"--template", "{{.Port}}"}, "template", ""},
+		{[]string{"-o", "template"}, "", "--output template requires --template"},
+		{[]string{"-o", "csv", "--template", "{{.Port}}"}, "", "--template requires --output template"},
+		{[]string{"--json", "--columns", "port"}, "", "--columns cannot be used with --output json"},
+		{[]string{"-o", "csv", "--columns", "port", "--no-headers"}, "csv", ""},
+	}
+	for _, tt := range tests {
+		cmd := &cobra.Command{Use: "list"}
+		registerOutputFlags(cmd)
+		if err := cmd.ParseFlags(tt.args); err != nil {
+			t.Fatal(err)
+		}
+		got, err := outputFormat(cmd)
+		switch {
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("%v: got error %v, want one containing %q", tt.args, err, tt.wantErr)
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("%v: unexpected error %v", tt.args, err)
+		case got != tt.want:
+			t.Errorf("%v: got format %q, want %q", tt.args, got, tt.want)
+		}
+	}
+	registerOutputFlags(&cobra.Command{}) // reset the flag values
+}
+
+// failingWriter fails every write.
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("disk full") }
+
+func TestWritersReturnErrors(t *testing.T) {
+	// enough rows to overflow the CSV writer's buffer before Flush
+	var items []ports.PortInfo
+	for i := 0; i < 200; i++ {
+		items = append(items, ports.PortInfo{Port: 3000 + i, Process: "node", Command: strings.Repeat("x", 64)})
+	}
+	cols := mustColumns(t, "port,process,command")
+	writers := map[string]func() error{
+		"csv":      func() error { return writeCSV(failingWriter{}, items, cols, false) },
+		"tsv":      func() error { return writeTSV(failingWriter{}, items, cols, false) },
+		"table":    func() error { return writeTable(failingWriter{}, items, cols, true) },
+		"ndjson":   func() error { return writeNDJSON(failingWriter{}, items, cols) },
+		"yaml":     func() error { return writeYAML(failingWriter{}, items, cols) },
+		"template": func() error { return writeTemplate(failingWriter{}, items, "{{.Port}}") },
+	}
+	for name, write := range writers {
+		if err := write(); err == nil || !strings.Contains(err.Error(), "disk full") {
+			t.Errorf("%s: got %v, want the write error", name, err)
+		}
+	}
+}
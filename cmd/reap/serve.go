@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/legostin/reap/internal/config"
+	"github.com/legostin/reap/internal/server"
+	"github.com/spf13/cobra"
+)
+
+var (
+	serveListen   string
+	serveToken    string
+	serveReadOnly bool
+	serveInterval time.Duration
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve the port inventory as a local HTTP/JSON API",
+	Long: `Serve the port inventory as a local HTTP/JSON API.
+
+Endpoints:
+  GET  /api/v1/ports            list ports (?port=, ?name=, ?container=, ?proto=tcp|udp,
+                                ?q= query as in reap list --query)
+  GET  /api/v1/ports/{port}     entries on one port
+  POST /api/v1/pids/{pid}/kill  kill a process, or stop its container
+                                body: {"force", "escalate", "container_action"}
+  GET  /api/v1/events           server-sent events: snapshot, opened, closed, owner
+
+Kill requests need a token (--token or $REAP_TOKEN) and a JSON Content-Type;
+without a token the server is read-only. Requests on a loopback address must
+name localhost in their Host header.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg := config.Load()
+		scanner, err := newScanner(cfg)
+		if err != nil {
+			return err
+		}
+		if serveToken == "" {
+			serveToken = os.Getenv("REAP_TOKEN")
+		}
+		if serveToken == "" && !server.IsLocal(serveListen) {
+			return fmt.Errorf("refusing to listen on %s without --token", serveListen)
+		}
+		interval := time.Duration(cfg.RefreshInterval) * time.Second
+		if cmd.Flags().Changed("interval") {
+			interval = serveInterval
+		}
+
+		srv := server.New(scanner, server.Options{
+			Token:            serveToken,
+			ReadOnly:         serveReadOnly,
+			AnyHost:          !loopback(serveListen),
+			Interval:         interval,
+			Grace:            time.Duration(cfg.KillGrace) * time.Second,
+			ContainerTimeout: time.Duration(cfg.ContainerTimeout) * time.Second,
+		})
+		l, err := server.Listen(serveListen)
+		if err != nil {
+			return err
+		}
+		httpSrv := &http.Server{Handler: srv.Handler(), ReadHeaderTimeout: 10 * time.Second}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		go func() {
+			<-ctx.Done()
+			shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			httpSrv.Shutdown(shutdown)
+		}()
+
+		mode := ""
+		if serveReadOnly {
+			mode = " (read-only)"
+		} else if serveToken == "" {
+			mode = " (read-only: kill requests need --token)"
+		}
+		fmt.Fprintf(os.Stderr, "serving on %s%s\n", serveListen, mode)
+		if err := httpSrv.Serve(l); !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	},
+}
+
+// loopback reports whether addr is a TCP address on the loopback interface,
+// which web pages could reach through DNS rebinding. Unix sockets and
+// public addresses, which need a token, accept any Host header.
+func loopback(addr string) bool {
+	return server.IsLocal(addr) && !strings.HasPrefix(addr, "unix://")
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&serveListen, "listen", "127.0.0.1:7878", "address to listen on: host:port or unix:///path/to.sock")
+	serveCmd.Flags().StringVar(&serveToken, "token", "", "require this bearer token (default $REAP_TOKEN)")
+	serveCmd.Flags().BoolVar(&serveReadOnly, "read-only", false, "reject kill requests, even with a token")
+	serveCmd.Flags().DurationVar(&serveInterval, "interval", 2*time.Second, "rescan interval of event streams (overrides refresh_interval)")
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/legostin/reap/internal/config"
+	"github.com/legostin/reap/internal/ports"
+	"github.com/spf13/cobra"
+)
+
+// Exit codes of reap wait besides 0 (condition met) and 1 (usage errors).
+const (
+	exitTimeout     = 2
+	exitScanError   = 3
+	exitInterrupted = 130 // 128 + SIGINT, as shells report it
+)
+
+var (
+	waitUntil     string
+	waitTimeout   time.Duration
+	waitInterval  time.Duration
+	waitName      string
+	waitContainer string
+	waitQuiet     bool
+)
+
+var waitCmd = &cobra.Command{
+	Use:   "wait <port>[/tcp|/udp]...",
+	Short: "Block until ports are listening or free",
+	Long: `Block until every port is listening (the default) or free.
+
+Exit codes:
+    0  the condition was met
+    1  invalid arguments
+    2  timed out
+    3  scanning failed
+  130  interrupted (Ctrl-C or SIGTERM)`,
+	Args:          cobra.MinimumNArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cond, err := ports.ParseWaitCondition(waitUntil)
+		if err != nil {
+			return err
+		}
+		var targets []ports.WaitTarget
+		for _, arg := range args {
+			port, protocol, err := parsePortArg(arg)
+			if err != nil {
+				return fmt.Errorf("invalid port %s: %w", arg, err)
+			}
+			targets = append(targets, ports.WaitTarget{Port: port, Protocol: protocol})
+		}
+		if waitInterval <= 0 {
+			return fmt.Errorf("--interval must be positive")
+		}
+		if cond == ports.WaitFree && (waitName != "" || waitContainer != "") {
+			return fmt.Errorf("--name and --container only apply to --until listening: a port is free only when nothing holds it")
+		}
+		scanner, err := newScanner(config.Load())
+		if err != nil {
+			return err
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		if waitTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, waitTimeout)
+			defer cancel()
+		}
+
+		// With --name or --container, other processes on the port do not
+		// satisfy "listening".
+		filter := ports.Filter{Name: waitName, Container: waitContainer}
+		found, err := ports.Wait(ctx, scanner, waitInterval, targets, cond, filter)
+		switch {
+		case errors.Is(err, context.DeadlineExceeded):
+			return &exitError{exitTimeout, fmt.Errorf("timed out after %s waiting for %s to be %s", waitTimeout, describeTargets(targets), cond)}
+		case errors.Is(err, context.Canceled):
+			return &exitError{exitInterrupted, fmt.Errorf("interrupted waiting for %s to be %s", describeTargets(targets), cond)}
+		case err != nil:
+			return &exitError{exitScanError, err}
+		}
+
+		if waitQuiet {
+			return nil
+		}
+		if cond == ports.WaitFree {
+			fmt.Printf("%s free\n", describeTargets(targets))
+			return nil
+		}
+		for _, p := range found {
+			fmt.Printf("%d/%s listening: %s (PID %d)\n", p.Port, p.Protocol, p.Process, p.PID)
+		}
+		return nil
+	},
+}
+
+// describeTargets renders targets as "3000, 53/udp".
+func describeTargets(targets []ports.WaitTarget) string {
+	s := ""
+	for i, t := range targets {
+		if i > 0 {
+			s += ", "
+		}
+		s += t.String()
+	}
+	return s
+}
+
+func init() {
+	waitCmd.Flags().StringVar(&waitUntil, "until", "listening", "condition to wait for: listening or free")
+	waitCmd.Flags().DurationVar(&waitTimeout, "timeout", 30*time.Second, "give up after this long (0 waits forever)")
+	waitCmd.Flags().DurationVar(&waitInterval, "interval", 250*time.Millisecond, "time between scans")
+	waitCmd.Flags().StringVarP(&waitName, "name", "n", "", "only count listeners whose process name contains this (--until listening)")
+	waitCmd.Flags().StringVar(&waitContainer, "container", "", "only count listeners in a container whose name contains this (--until listening)")
+	waitCmd.Flags().BoolVarP(&waitQuiet, "quiet", "q", false, "print nothing on success")
+}
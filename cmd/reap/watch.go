@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/legostin/reap/internal/config"
+	"github.com/legostin/reap/internal/ports"
+	"github.com/spf13/cobra"
+)
+
+var (
+	watchFilters  filterFlags
+	watchJSON     bool
+	watchExisting bool
+	watchInterval time.Duration
+)
+
+// watchEvent is a line of `reap watch --json`.
+type watchEvent struct {
+	Time time.Time `json:"time"`
+	ports.Change
+}
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Print a line whenever a port opens, closes or changes owner",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg := config.Load()
+		scanner, err := newScanner(cfg)
+		if err != nil {
+			return err
+		}
+		interval := time.Duration(cfg.RefreshInterval) * time.Second
+		if cmd.Flags().Changed("interval") {
+			interval = watchInterval
+		}
+		if interval <= 0 {
+			return fmt.Errorf("--interval must be positive")
+		}
+
+		filter, err := watchFilters.filter()
+		if err != nil {
+			return err
+		}
+		results, err := scanner.Scan()
+		if err != nil {
+			return fmt.Errorf("scan failed: %w", err)
+		}
+		current := filter.Apply(results)
+		if watchExisting {
+			// report what is already listening as if it had just opened
+			printChanges(ports.DiffPorts(nil, current))
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		ports.Watch(ctx, scanner, interval, filter, current, func(changes []ports.Change, err error) {
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "scan failed: %s\n", err)
+				return
+			}
+			printChanges(changes)
+		})
+		return nil
+	},
+}
+
+// printChanges writes one line per change, human-readable or NDJSON.
+func printChanges(changes []ports.Change) {
+	now := time.Now()
+	enc := json.NewEncoder(os.Stdout)
+	for _, c := range changes {
+		if watchJSON {
+			enc.Encode(watchEvent{Time: now, Change: c})
+			continue
+		}
+		fmt.Printf("%s  %s\n", now.Format(time.RFC3339), c)
+	}
+}
+
+func init() {
+	watchFilters.register(watchCmd)
+	watchCmd.Flags().BoolVar(&watchJSON, "json", false, "output newline-delimited JSON")
+	watchCmd.Flags().BoolVar(&watchExisting, "existing", false, "report ports already listening at start as opened")
+	watchCmd.Flags().DurationVar(&watchInterval, "interval", 2*time.Second, "time between scans (overrides refresh_interval)")
+}
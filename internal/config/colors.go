@@ -1,6 +1,12 @@
 package config
 
-import "strconv"
+import (
+	"cmp"
+	"slices"
+	"strconv"
+
+	"github.com/legostin/reap/internal/ports"
+)
 
 var defaultPortColors = map[int]string{
 	// Frontend
@@ -21,19 +27,131 @@ var defaultPortColors = map[int]string{
 	80: "white", 443: "white",
 }
 
-// PortColor returns the color name for a given port number.
-// User config overrides take precedence over defaults.
+// defaultColorNames name the built-in port colors in the legend.
+var defaultColorNames = map[string]string{
+	"green":   "frontend",
+	"yellow":  "backend",
+	"cyan":    "flask/vite",
+	"magenta": "postgres",
+	"red":     "redis",
+	"blue":    "mysql/mongo",
+	"white":   "http/s",
+}
+
+// legendColors is the order of colors in the legend. Other colors follow.
+var legendColors = []string{"green", "yellow", "cyan", "magenta", "red", "blue", "white", "dim"}
+
+// PortColor returns the color name for a given port number, as Color does
+// for a port that no process rule matches.
 func (c Config) PortColor(port int) string {
-	// Check user overrides first
-	if color, ok := c.PortColors[portKey(port)]; ok {
-		return color
+	return c.Color(ports.PortInfo{Port: port})
+}
+
+// Color returns the color name of p. In order of precedence:
+//
+//  1. a port_colors entry for the port
+//  2. the first rule that matches p and sets a color
+//  3. the narrowest port_colors range that contains the port
+//  4. the built-in port colors
+//  5. the color of the service p is labelled with or listed under in the
+//     catalog: that of its default port, or else of its category
+//  6. dim
+func (c Config) Color(p ports.PortInfo) string {
+	color, _ := c.colorRule(p)
+	return color
+}
+
+// colorRule returns the color of p and the name the legend gives to the
+// rule that chose it.
+func (c Config) colorRule(p ports.PortInfo) (color, name string) {
+	if color, name, ok := c.userColor(p); ok {
+		return color, name
+	}
+	if color, ok := defaultPortColors[p.Port]; ok {
+		return color, defaultColorNames[color]
+	}
+	if svcPort, svc, ok := c.service(p); ok {
+		if color, ok := defaultPortColors[svcPort]; ok {
+			return color, defaultColorNames[color]
+		}
+		if color, ok := categoryColors[svc.Category]; ok {
+			return color, svc.Category
+		}
+	}
+	return "dim", "other"
+}
+
+// userColor returns the color the config gives p, if any.
+func (c Config) userColor(p ports.PortInfo) (color, name string, ok bool) {
+	key := portKey(p.Port)
+	if color, ok := c.PortColors[key]; ok {
+		return color, cmp.Or(c.Label(p), key), true
 	}
-	if color, ok := defaultPortColors[port]; ok {
-		return color
+	for _, r := range c.Rules {
+		if r.Color != "" && r.matches(p) {
+			return r.Color, r.name(), true
+		}
 	}
-	return "dim"
+	if key, color, ok := portRange(c.PortColors, p.Port); ok {
+		return color, cmp.Or(c.Label(p), key), true
+	}
+	return "", "", false
 }
 
 func portKey(port int) string {
 	return strconv.Itoa(port)
 }
+
+// WellKnown reports whether port is marked as a known service, either by a
+// built-in or user color, by a user label, a port rule or a range, or by the
+// service catalog.
+func (c Config) WellKnown(port int) bool {
+	p := ports.PortInfo{Port: port}
+	if _, _, ok := c.userColor(p); ok {
+		return true
+	}
+	if _, ok := c.userLabel(p); ok {
+		return true
+	}
+	if _, ok := serviceCatalog[port]; ok {
+		return true
+	}
+	_, ok := defaultPortColors[port]
+	return ok
+}
+
+// LegendEntry is a color of the legend and the names of the rules that
+// chose it.
+type LegendEntry struct {
+	Color string
+	Names []string
+}
+
+// Legend lists the colors given to items with the rules that chose them,
+// so it only shows what is in use. Colors are in the order of legendColors
+// and names are sorted.
+func (c Config) Legend(items []ports.PortInfo) []LegendEntry {
+	var legend []LegendEntry
+	for _, p := range items {
+		color, name := c.colorRule(p)
+		i := slices.IndexFunc(legend, func(e LegendEntry) bool { return e.Color == color })
+		if i < 0 {
+			legend = append(legend, LegendEntry{Color: color})
+			i = len(legend) - 1
+		}
+		if !slices.Contains(legend[i].Names, name) {
+			legend[i].Names = append(legend[i].Names, name)
+		}
+	}
+	rank := func(color string) int {
+		if i := slices.Index(legendColors, color); i >= 0 {
+			return i
+		}
+		return len(legendColors)
+	}
+	slices.SortStableFunc(legend, func(a, b LegendEntry) int { return cmp.Compare(rank(a.Color), rank(b.Color)) })
+	for _, e := range legend {
+		slices.Sort(e.Names)
+	}
+	return legend
+}
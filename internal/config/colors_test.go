@@ -167,3 +167,25 @@ func TestAllColorCategories(t *testing.T) {
 		}
 	}
 }
+
+func TestWellKnown(t *testing.T) {
+	cfg := Default()
+	cfg.PortColors["3333"] = "dim"
+	cfg.PortLabels["7777"] = "Internal API"
+
+	tests := []struct {
+		port int
+		want bool
+	}{
+		{5432, true}, // built-in color
+		{3333, true}, // user color, even "dim"
+		{7777, true}, // user label
+		{3002, false},
+		{65000, false},
+	}
+	for _, tt := range tests {
+		if got := cfg.WellKnown(tt.port); got != tt.want {
+			t.Errorf("WellKnown(%d) = %v, want %v", tt.port, got, tt.want)
+		}
+	}
+}
@@ -8,18 +8,37 @@ import (
 )
 
 type Config struct {
-	RefreshInterval int               `toml:"refresh_interval"`
-	ShowSystem      bool              `toml:"show_system"`
-	PortColors      map[string]string `toml:"port_colors"`
-	PortLabels      map[string]string `toml:"port_labels"`
+	RefreshInterval  int               `toml:"refresh_interval"`
+	ShowSystem       bool              `toml:"show_system"`
+	PortColors       map[string]string `toml:"port_colors"`
+	PortLabels       map[string]string `toml:"port_labels"`
+	Backend          string            `toml:"backend"`           // socket scanner backend, empty = platform default
+	KillGrace        int               `toml:"kill_grace"`        // seconds between SIGTERM and SIGKILL in graceful kills
+	ContainerTimeout int               `toml:"container_timeout"` // seconds a container gets to stop before it is killed
+	Columns          []string          `toml:"columns"`           // TUI table columns in order, empty = default set
+	Theme            string            `toml:"theme"`             // auto, dark, light, high-contrast or a name from themes
+
+	ColumnLayout map[string]ColumnLayout `toml:"column_layout"` // per-column width and alignment, keyed by column name
+	Rules        []Rule                  `toml:"rules"`         // colors and labels by process, user, container or directory
+	Themes       map[string]Theme        `toml:"themes"`        // user themes by name
+}
+
+// ColumnLayout overrides how a TUI table column is sized and aligned. Zero
+// values keep the column's defaults.
+type ColumnLayout struct {
+	MinWidth int    `toml:"min_width"`
+	MaxWidth int    `toml:"max_width"` // -1 lets the column take all free space
+	Align    string `toml:"align"`     // left, right or center
 }
 
 func Default() Config {
 	return Config{
-		RefreshInterval: 2,
-		ShowSystem:      false,
-		PortColors:      map[string]string{},
-		PortLabels:      map[string]string{},
+		RefreshInterval:  2,
+		ShowSystem:       false,
+		KillGrace:        5,
+		ContainerTimeout: 10,
+		PortColors:       map[string]string{},
+		PortLabels:       map[string]string{},
 	}
 }
 
@@ -42,6 +61,12 @@ func Load() Config {
 	if cfg.RefreshInterval < 1 {
 		cfg.RefreshInterval = 2
 	}
+	if cfg.KillGrace < 1 {
+		cfg.KillGrace = 5
+	}
+	if cfg.ContainerTimeout < 0 {
+		cfg.ContainerTimeout = 10
+	}
 
 	return cfg
 }
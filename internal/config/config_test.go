@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -21,6 +22,12 @@ func TestDefault(t *testing.T) {
 	if cfg.PortLabels == nil {
 		t.Error("expected PortLabels to be initialized")
 	}
+	if cfg.KillGrace != 5 {
+		t.Errorf("expected KillGrace=5, got %d", cfg.KillGrace)
+	}
+	if cfg.ContainerTimeout != 10 {
+		t.Errorf("expected ContainerTimeout=10, got %d", cfg.ContainerTimeout)
+	}
 	if len(cfg.PortColors) != 0 {
 		t.Errorf("expected empty PortColors, got %d entries", len(cfg.PortColors))
 	}
@@ -52,6 +59,10 @@ func TestLoadValidConfig(t *testing.T) {
 	configContent := `
 refresh_interval = 5
 show_system = true
+backend = "procfs"
+kill_grace = 8
+container_timeout = 30
+columns = ["port", "process", "cpu"]
 
 [port_colors]
 "3000" = "green"
@@ -80,6 +91,18 @@ show_system = true
 	if cfg.ShowSystem != true {
 		t.Errorf("expected ShowSystem=true, got %v", cfg.ShowSystem)
 	}
+	if cfg.Backend != "procfs" {
+		t.Errorf("expected Backend=procfs, got %q", cfg.Backend)
+	}
+	if cfg.KillGrace != 8 {
+		t.Errorf("expected KillGrace=8, got %d", cfg.KillGrace)
+	}
+	if cfg.ContainerTimeout != 30 {
+		t.Errorf("expected ContainerTimeout=30, got %d", cfg.ContainerTimeout)
+	}
+	if strings.Join(cfg.Columns, ",") != "port,process,cpu" {
+		t.Errorf("expected Columns=[port process cpu], got %v", cfg.Columns)
+	}
 	if cfg.PortColors["3000"] != "green" {
 		t.Errorf("expected PortColors[3000]=green, got %q", cfg.PortColors["3000"])
 	}
@@ -200,3 +223,81 @@ func TestLoadPartialConfig(t *testing.T) {
 		t.Errorf("expected default RefreshInterval=2, got %d", cfg.RefreshInterval)
 	}
 }
+
+func TestLoadInvalidTimeouts(t *testing.T) {
+	tmpDir := t.TempDir()
+	configDir := filepath.Join(tmpDir, ".config", "reap")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatalf("failed to create config dir: %v", err)
+	}
+
+	configContent := `
+kill_grace = 0
+container_timeout = -1
+`
+
+	configPath := filepath.Join(configDir, "config.toml")
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	oldHome := os.Getenv("HOME")
+	os.Setenv("HOME", tmpDir)
+	defer os.Setenv("HOME", oldHome)
+
+	cfg := Load()
+
+	if cfg.KillGrace != 5 {
+		t.Errorf("expected KillGrace=5 for invalid value, got %d", cfg.KillGrace)
+	}
+	if cfg.ContainerTimeout != 10 {
+		t.Errorf("expected ContainerTimeout=10 for invalid value, got %d", cfg.ContainerTimeout)
+	}
+}
+
+func TestLoadThemes(t *testing.T) {
+	tmpDir := t.TempDir()
+	configDir := filepath.Join(tmpDir, ".config", "reap")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatalf("failed to create config dir: %v", err)
+	}
+
+	configContent := `
+theme = "paper"
+
+[themes.paper]
+base = "light"
+header = "#fdf6e3"
+header_bg = "#268bd2"
+selected_bg = "33"
+
+[themes.paper.ports]
+green = "#859900"
+orange = "#cb4b16"
+`
+
+	configPath := filepath.Join(configDir, "config.toml")
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	oldHome := os.Getenv("HOME")
+	os.Setenv("HOME", tmpDir)
+	defer os.Setenv("HOME", oldHome)
+
+	cfg := Load()
+
+	if cfg.Theme != "paper" {
+		t.Errorf("expected Theme=paper, got %q", cfg.Theme)
+	}
+	th, ok := cfg.Themes["paper"]
+	if !ok {
+		t.Fatalf("expected a paper theme, got %v", cfg.Themes)
+	}
+	if th.Base != "light" || th.Header != "#fdf6e3" || th.HeaderBg != "#268bd2" || th.SelectedBg != "33" {
+		t.Errorf("unexpected theme colors: %+v", th)
+	}
+	if th.Ports["green"] != "#859900" || th.Ports["orange"] != "#cb4b16" {
+		t.Errorf("unexpected port colors: %v", th.Ports)
+	}
+}
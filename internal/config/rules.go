@@ -0,0 +1,157 @@
+package config
+
+import (
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/legostin/reap/internal/ports"
+)
+
+// Rule colors or labels the ports of the processes it matches. Every field
+// that is set must match, case-insensitively; a rule with none set matches
+// nothing.
+type Rule struct {
+	Process   string `toml:"process"`   // regular expression over the process name
+	User      string `toml:"user"`      // user name
+	Container string `toml:"container"` // glob over the container name
+	Image     string `toml:"image"`     // glob over the container image
+	CWD       string `toml:"cwd"`       // glob over the working directory, ~ for home
+	Port      string `toml:"port"`      // port or range such as 7000-7099
+
+	Color string `toml:"color"`
+	Label string `toml:"label"`
+}
+
+func (r Rule) matches(p ports.PortInfo) bool {
+	if r.Process == "" && r.User == "" && r.Container == "" && r.Image == "" && r.CWD == "" && r.Port == "" {
+		return false
+	}
+	if r.Process != "" && !matchRegexp(r.Process, p.Process) {
+		return false
+	}
+	if r.User != "" && !strings.EqualFold(r.User, p.User) {
+		return false
+	}
+	if r.Container != "" && !matchGlob(r.Container, p.Container) {
+		return false
+	}
+	if r.Image != "" && (p.ContainerInfo == nil || !matchGlob(r.Image, p.ContainerInfo.Image)) {
+		return false
+	}
+	if r.CWD != "" && (p.CWD == "" || !matchGlob(expandHome(r.CWD), p.CWD)) {
+		return false
+	}
+	if r.Port != "" {
+		lo, hi, ok := parsePortRange(r.Port)
+		if !ok || p.Port < lo || p.Port > hi {
+			return false
+		}
+	}
+	return true
+}
+
+// name describes the rule in the legend: its label, or else its first
+// criterion in filter query syntax.
+func (r Rule) name() string {
+	switch {
+	case r.Label != "":
+		return r.Label
+	case r.Process != "":
+		return "proc:~" + r.Process
+	case r.User != "":
+		return "user:" + r.User
+	case r.Container != "":
+		return "container:" + r.Container
+	case r.Image != "":
+		return "image:" + r.Image
+	case r.CWD != "":
+		return "dir:" + r.CWD
+	}
+	return "port:" + r.Port
+}
+
+// portRange finds the narrowest range key of m, such as "7000-7099", that
+// contains port. Of equally wide ranges the lowest wins.
+func portRange(m map[string]string, port int) (key, value string, ok bool) {
+	bestLo, bestHi := 0, -1
+	for k, v := range m {
+		if !strings.Contains(k, "-") {
+			continue
+		}
+		lo, hi, valid := parsePortRange(k)
+		if !valid || port < lo || port > hi {
+			continue
+		}
+		if ok && (hi-lo > bestHi-bestLo || hi-lo == bestHi-bestLo && lo >= bestLo) {
+			continue
+		}
+		key, value, ok = k, v, true
+		bestLo, bestHi = lo, hi
+	}
+	return key, value, ok
+}
+
+// parsePortRange parses a port such as "8080" or an inclusive range such as
+// "7000-7099".
+func parsePortRange(s string) (lo, hi int, ok bool) {
+	first, last, isRange := strings.Cut(s, "-")
+	lo, err := strconv.Atoi(strings.TrimSpace(first))
+	if err != nil {
+		return 0, 0, false
+	}
+	hi = lo
+	if isRange {
+		if hi, err = strconv.Atoi(strings.TrimSpace(last)); err != nil {
+			return 0, 0, false
+		}
+	}
+	return lo, hi, lo <= hi
+}
+
+// patterns caches compiled rule patterns by source; invalid ones are stored
+// as nil and never match.
+var patterns sync.Map
+
+func matchRegexp(expr, s string) bool {
+	v, ok := patterns.Load(expr)
+	if !ok {
+		re, _ := regexp.Compile("(?i)" + expr)
+		v, _ = patterns.LoadOrStore(expr, re)
+	}
+	re := v.(*regexp.Regexp)
+	return re != nil && re.MatchString(s)
+}
+
+// matchGlob matches s against a glob in which * matches any run of
+// characters, slashes included, and ? any one character.
+func matchGlob(glob, s string) bool {
+	var b strings.Builder
+	b.WriteByte('^')
+	for _, r := range glob {
+		switch r {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteByte('.')
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteByte('$')
+	return matchRegexp(b.String(), s)
+}
+
+func expandHome(path string) string {
+	rest, ok := strings.CutPrefix(path, "~")
+	if !ok || rest != "" && rest[0] != '/' {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return home + rest
+}
@@ -0,0 +1,269 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/legostin/reap/internal/ports"
+)
+
+func TestPortColorRanges(t *testing.T) {
+	cfg := Default()
+	cfg.PortColors["7000-7099"] = "yellow"
+	cfg.PortColors["7050-7059"] = "red"
+	cfg.PortColors["7055"] = "white"
+	cfg.PortColors["3000-3999"] = "blue"
+	cfg.PortColors["9000-"] = "cyan" // invalid, ignored
+	cfg.PortColors["100-50"] = "cyan"
+
+	tests := []struct {
+		port int
+		want string
+	}{
+		{7000, "yellow"},
+		{7099, "yellow"},
+		{7100, "dim"},
+		{7051, "red"},   // the narrowest range wins
+		{7055, "white"}, // an exact port beats any range
+		{3500, "blue"},
+		{3000, "blue"}, // a range beats the built-in colors
+		{9001, "dim"},
+		{75, "dim"},
+	}
+	for _, tt := range tests {
+		if got := cfg.PortColor(tt.port); got != tt.want {
+			t.Errorf("PortColor(%d) = %q, want %q", tt.port, got, tt.want)
+		}
+	}
+}
+
+func TestPortLabelRanges(t *testing.T) {
+	cfg := Default()
+	cfg.PortLabels["7000-7099"] = "services"
+	cfg.PortLabels["6379-6380"] = "cache"
+	cfg.PortLabels["6379"] = "sessions"
+
+	tests := []struct {
+		port int
+		want string
+	}{
+		{7042, "services"},
+		{6380, "cache"},
+		{6379, "sessions"},
+		{5432, "postgres"},
+		{7100, ""},
+	}
+	for _, tt := range tests {
+		if got := cfg.PortLabel(tt.port); got != tt.want {
+			t.Errorf("PortLabel(%d) = %q, want %q", tt.port, got, tt.want)
+		}
+	}
+}
+
+func TestRuleMatches(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+	p := ports.PortInfo{
+		Port: 7012, Process: "orders-api", User: "dev", Container: "shop-orders-1",
+		CWD: filepath.Join(home, "work", "shop", "orders"), ContainerInfo: &ports.Container{Image: "ghcr.io/acme/orders:1.4"},
+	}
+
+	tests := []struct {
+		rule Rule
+		want bool
+	}{
+		{Rule{}, false},
+		{Rule{Color: "red"}, false},
+		{Rule{Process: "^orders"}, true},
+		{Rule{Process: "^ORDERS-API$"}, true},
+		{Rule{Process: "^billing"}, false},
+		{Rule{Process: "(unclosed"}, false},
+		{Rule{User: "DEV"}, true},
+		{Rule{User: "root"}, false},
+		{Rule{Container: "shop-*"}, true},
+		{Rule{Container: "shop"}, false},
+		{Rule{Image: "*/acme/*"}, true},
+		{Rule{CWD: "~/work/*"}, true},
+		{Rule{CWD: "~/work/shop/orders"}, true},
+		{Rule{CWD: "/srv/*"}, false},
+		{Rule{Port: "7000-7099"}, true},
+		{Rule{Port: "7012"}, true},
+		{Rule{Port: "8000-8099"}, false},
+		{Rule{Port: "bogus"}, false},
+		{Rule{Process: "^orders", User: "dev", Port: "7000-7099"}, true},
+		{Rule{Process: "^orders", User: "root"}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.rule.matches(p); got != tt.want {
+			t.Errorf("%+v.matches = %v, want %v", tt.rule, got, tt.want)
+		}
+	}
+
+	if (Rule{Image: "*"}).matches(ports.PortInfo{Port: 7012}) {
+		t.Error("an image rule should not match ports outside containers")
+	}
+	if (Rule{CWD: "*"}).matches(ports.PortInfo{Port: 7012}) {
+		t.Error("a directory rule should not match an unknown directory")
+	}
+}
+
+func TestRulePrecedence(t *testing.T) {
+	cfg := Default()
+	cfg.PortColors["7000-7099"] = "yellow"
+	cfg.PortColors["7001"] = "white"
+	cfg.PortLabels["7000-7099"] = "services"
+	cfg.Rules = []Rule{
+		{Process: "^orders", Label: "orders"}, // label only
+		{User: "dev", Color: "green"},
+		{Process: "^orders", Color: "red", Label: "not used"},
+		{Process: "^pg", Label: "postgres"},
+	}
+
+	tests := []struct {
+		p     ports.PortInfo
+		color string
+		label string
+		desc  string
+	}{
+		{ports.PortInfo{Port: 7001, Process: "orders", User: "dev"}, "white", "orders", "exact port color first"},
+		{ports.PortInfo{Port: 7002, Process: "orders", User: "dev"}, "green", "orders", "the first rule with a color"},
+		{ports.PortInfo{Port: 7002, Process: "orders", User: "ci"}, "red", "orders", "a later rule"},
+		{ports.PortInfo{Port: 7002, Process: "billing"}, "yellow", "services", "the range after rules"},
+		{ports.PortInfo{Port: 5432, Process: "postgres", User: "dev"}, "green", "postgres", "rules before built-in colors"},
+		{ports.PortInfo{Port: 15432, Process: "pgbouncer"}, "magenta", "postgres", "a rule label names the service"},
+	}
+	for _, tt := range tests {
+		if got := cfg.Color(tt.p); got != tt.color {
+			t.Errorf("%s: Color = %q, want %q", tt.desc, got, tt.color)
+		}
+		if got := cfg.Label(tt.p); got != tt.label {
+			t.Errorf("%s: Label = %q, want %q", tt.desc, got, tt.label)
+		}
+	}
+}
+
+func TestWellKnownRanges(t *testing.T) {
+	cfg := Default()
+	cfg.PortColors["7000-7099"] = "yellow"
+	cfg.PortLabels["7200-7299"] = "workers"
+	cfg.Rules = []Rule{
+		{Port: "7300-7399", Color: "red"},
+		{Port: "7400-7499", Process: "java", Color: "red"},
+	}
+
+	tests := []struct {
+		port int
+		want bool
+	}{
+		{7050, true},
+		{7250, true},
+		{7350, true},
+		{7450, false}, // depends on the process
+		{7150, false},
+	}
+	for _, tt := range tests {
+		if got := cfg.WellKnown(tt.port); got != tt.want {
+			t.Errorf("WellKnown(%d) = %v, want %v", tt.port, got, tt.want)
+		}
+	}
+}
+
+func TestLegend(t *testing.T) {
+	cfg := Default()
+	cfg.PortColors["7000-7099"] = "yellow"
+	cfg.PortColors["7100"] = "pink"
+	cfg.Rules = []Rule{
+		{Process: "^orders", Color: "red", Label: "orders"},
+		{User: "ci", Color: "red"},
+	}
+
+	legend := cfg.Legend([]ports.PortInfo{
+		{Port: 12345},
+		{Port: 7001, Process: "billing"},
+		{Port: 7002, Process: "orders"},
+		{Port: 7003, User: "ci"},
+		{Port: 4000},
+		{Port: 9092},
+		{Port: 7100},
+		{Port: 3000},
+		{Port: 3001},
+	})
+
+	var got []string
+	for _, e := range legend {
+		got = append(got, e.Color+"="+strings.Join(e.Names, "/"))
+	}
+	want := "green=frontend yellow=7000-7099/backend/queue red=orders/user:ci dim=other pink=7100"
+	if s := strings.Join(got, " "); s != want {
+		t.Errorf("Legend:\n got %s\nwant %s", s, want)
+	}
+	if len(cfg.Legend(nil)) != 0 {
+		t.Error("an empty list should have an empty legend")
+	}
+}
+
+func TestParsePortRange(t *testing.T) {
+	tests := []struct {
+		in     string
+		lo, hi int
+		ok     bool
+	}{
+		{"8080", 8080, 8080, true},
+		{"7000-7099", 7000, 7099, true},
+		{" 7000 - 7099 ", 7000, 7099, true},
+		{"7099-7000", 0, 0, false},
+		{"7000-", 0, 0, false},
+		{"web", 0, 0, false},
+	}
+	for _, tt := range tests {
+		lo, hi, ok := parsePortRange(tt.in)
+		if ok != tt.ok || ok && (lo != tt.lo || hi != tt.hi) {
+			t.Errorf("parsePortRange(%q) = %d, %d, %v, want %d, %d, %v", tt.in, lo, hi, ok, tt.lo, tt.hi, tt.ok)
+		}
+	}
+}
+
+func TestLoadRules(t *testing.T) {
+	tmpDir := t.TempDir()
+	configDir := filepath.Join(tmpDir, ".config", "reap")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	content := `
+[port_colors]
+"7000-7099" = "yellow"
+
+[[rules]]
+process = "^orders-"
+color = "red"
+label = "orders"
+
+[[rules]]
+cwd = "~/work/payments/*"
+port = "8000-8999"
+color = "cyan"
+`
+	if err := os.WriteFile(filepath.Join(configDir, "config.toml"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOME", tmpDir)
+
+	cfg := Load()
+	if len(cfg.Rules) != 2 {
+		t.Fatalf("expected 2 rules, got %+v", cfg.Rules)
+	}
+	if r := cfg.Rules[1]; r.CWD != "~/work/payments/*" || r.Port != "8000-8999" || r.Color != "cyan" {
+		t.Errorf("second rule: got %+v", r)
+	}
+	p := ports.PortInfo{Port: 8001, CWD: filepath.Join(tmpDir, "work", "payments", "api")}
+	if got := cfg.Color(p); got != "cyan" {
+		t.Errorf("expected the directory rule to color the port, got %q", got)
+	}
+	if got := cfg.PortColor(7010); got != "yellow" {
+		t.Errorf("expected the range color, got %q", got)
+	}
+}
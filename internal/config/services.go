@@ -0,0 +1,139 @@
+package config
+
+import (
+	"strings"
+
+	"github.com/legostin/reap/internal/ports"
+)
+
+// Service is an entry of the built-in service catalog.
+type Service struct {
+	Name     string
+	Category string
+}
+
+// serviceCatalog labels the default ports of common services. port_labels
+// adds to it and takes precedence.
+var serviceCatalog = map[int]Service{
+	// Web
+	80:  {"http", "web"},
+	443: {"https", "web"},
+	// Frontend dev servers
+	4200:  {"angular", "frontend"},
+	5173:  {"vite", "frontend"},
+	5174:  {"vite", "frontend"},
+	6006:  {"storybook", "frontend"},
+	24678: {"vite-hmr", "frontend"},
+	// Databases
+	3306:  {"mysql", "database"},
+	5432:  {"postgres", "database"},
+	7687:  {"neo4j", "database"},
+	8086:  {"influxdb", "database"},
+	8123:  {"clickhouse", "database"},
+	9042:  {"cassandra", "database"},
+	26257: {"cockroachdb", "database"},
+	27017: {"mongodb", "database"},
+	// Caches
+	6379:  {"redis", "cache"},
+	11211: {"memcached", "cache"},
+	// Queues and streaming
+	2181:  {"zookeeper", "queue"},
+	4222:  {"nats", "queue"},
+	5672:  {"rabbitmq", "queue"},
+	9092:  {"kafka", "queue"},
+	15672: {"rabbitmq-admin", "queue"},
+	// Search
+	5601: {"kibana", "search"},
+	7700: {"meilisearch", "search"},
+	9200: {"elasticsearch", "search"},
+	9300: {"elasticsearch", "search"},
+	// Observability
+	3100:  {"loki", "observability"},
+	4317:  {"otlp-grpc", "observability"},
+	4318:  {"otlp-http", "observability"},
+	9090:  {"prometheus", "observability"},
+	16686: {"jaeger", "observability"},
+	// Infrastructure
+	2379: {"etcd", "infra"},
+	8200: {"vault", "infra"},
+	8500: {"consul", "infra"},
+	// Development tools
+	1025: {"mailhog-smtp", "tools"},
+	8025: {"mailhog", "tools"},
+	8888: {"jupyter", "tools"},
+	9229: {"node-inspect", "tools"},
+}
+
+// categoryColors color ports of a service category that have no color of
+// their own.
+var categoryColors = map[string]string{
+	"web":           "white",
+	"frontend":      "green",
+	"database":      "blue",
+	"cache":         "red",
+	"queue":         "yellow",
+	"search":        "cyan",
+	"observability": "cyan",
+	"infra":         "magenta",
+	"tools":         "dim",
+}
+
+// PortLabel returns the label of a port, as Label does for a port that no
+// process rule matches.
+func (c Config) PortLabel(port int) string {
+	return c.Label(ports.PortInfo{Port: port})
+}
+
+// Label returns the label of p: the user label if there is one, otherwise
+// the name of the catalog service, or "" if the port is unknown. User labels
+// take the same precedence as colors: a port_labels entry for the port, then
+// the first matching rule that sets a label, then the narrowest range.
+func (c Config) Label(p ports.PortInfo) string {
+	if label, ok := c.userLabel(p); ok {
+		return label
+	}
+	return serviceCatalog[p.Port].Name
+}
+
+func (c Config) userLabel(p ports.PortInfo) (string, bool) {
+	if label, ok := c.PortLabels[portKey(p.Port)]; ok {
+		return label, true
+	}
+	for _, r := range c.Rules {
+		if r.Label != "" && r.matches(p) {
+			return r.Label, true
+		}
+	}
+	_, label, ok := portRange(c.PortLabels, p.Port)
+	return label, ok
+}
+
+// PortCategory returns the service category of a port, or "". A user label
+// that names a catalog service, such as "postgres" on port 5433, puts the
+// port in that service's category.
+func (c Config) PortCategory(port int) string {
+	_, svc, _ := c.service(ports.PortInfo{Port: port})
+	return svc.Category
+}
+
+// service returns the catalog service of p and the catalog port it is
+// listed under. A user label names the service.
+func (c Config) service(p ports.PortInfo) (int, Service, bool) {
+	if label, ok := c.userLabel(p); ok {
+		return lookupService(label)
+	}
+	svc, ok := serviceCatalog[p.Port]
+	return p.Port, svc, ok
+}
+
+// lookupService finds the catalog service called name. Services listed on
+// several ports resolve to the lowest.
+func lookupService(name string) (int, Service, bool) {
+	found := 0
+	for port, s := range serviceCatalog {
+		if strings.EqualFold(s.Name, name) && (found == 0 || port < found) {
+			found = port
+		}
+	}
+	return found, serviceCatalog[found], found != 0
+}
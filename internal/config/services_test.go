@@ -0,0 +1,98 @@
+package config
+
+import "testing"
+
+func TestPortLabel(t *testing.T) {
+	cfg := Default()
+	cfg.PortLabels["5432"] = "orders-db"
+	cfg.PortLabels["7001"] = "billing"
+
+	tests := []struct {
+		port int
+		want string
+	}{
+		{5432, "orders-db"}, // user label wins over the catalog
+		{7001, "billing"},
+		{6379, "redis"},
+		{9092, "kafka"},
+		{9200, "elasticsearch"},
+		{5173, "vite"},
+		{12345, ""},
+	}
+	for _, tt := range tests {
+		if got := cfg.PortLabel(tt.port); got != tt.want {
+			t.Errorf("PortLabel(%d) = %q, want %q", tt.port, got, tt.want)
+		}
+	}
+}
+
+func TestPortCategory(t *testing.T) {
+	cfg := Default()
+	cfg.PortLabels["5433"] = "Postgres"
+	cfg.PortLabels["7001"] = "billing"
+
+	tests := []struct {
+		port int
+		want string
+	}{
+		{5432, "database"},
+		{5433, "database"}, // labelled with a catalog name
+		{9092, "queue"},
+		{7001, ""}, // labelled, but not a catalog service
+		{12345, ""},
+	}
+	for _, tt := range tests {
+		if got := cfg.PortCategory(tt.port); got != tt.want {
+			t.Errorf("PortCategory(%d) = %q, want %q", tt.port, got, tt.want)
+		}
+	}
+}
+
+func TestPortColorServices(t *testing.T) {
+	cfg := Default()
+	cfg.PortLabels["5433"] = "postgres"
+	cfg.PortLabels["5175"] = "kafka"
+	cfg.PortLabels["7002"] = "Redis"
+	cfg.PortColors["9092"] = "white"
+
+	tests := []struct {
+		port int
+		want string
+	}{
+		{5433, "magenta"}, // the color of postgres' default port
+		{7002, "red"},
+		{5175, "cyan"},    // a built-in port color beats the label
+		{9092, "white"},   // a user color beats the catalog
+		{9200, "cyan"},    // search category
+		{11211, "red"},    // cache category
+		{2379, "magenta"}, // infra category
+		{12345, "dim"},
+	}
+	for _, tt := range tests {
+		if got := cfg.PortColor(tt.port); got != tt.want {
+			t.Errorf("PortColor(%d) = %q, want %q", tt.port, got, tt.want)
+		}
+	}
+}
+
+func TestServiceCatalogCategories(t *testing.T) {
+	for port, svc := range serviceCatalog {
+		if svc.Name == "" {
+			t.Errorf("port %d: empty service name", port)
+		}
+		if _, ok := categoryColors[svc.Category]; !ok {
+			t.Errorf("port %d (%s): category %q has no color", port, svc.Name, svc.Category)
+		}
+	}
+}
+
+func TestLookupServiceLowestPort(t *testing.T) {
+	for i := 0; i < 20; i++ { // map order varies between runs
+		if port, _, ok := lookupService("elasticsearch"); !ok || port != 9200 {
+			t.Fatalf("lookupService(elasticsearch) = %d, %v, want 9200", port, ok)
+		}
+	}
+	if _, _, ok := lookupService("nope"); ok {
+		t.Error("lookupService(nope) should not be found")
+	}
+}
@@ -0,0 +1,30 @@
+package config
+
+// Theme is a user theme for the TUI. Colors are true-color hex values such
+// as "#5f5fd7" or ANSI-256 numbers such as "62"; empty or invalid ones are
+// taken from the base theme.
+type Theme struct {
+	Base string `toml:"base"` // built-in theme to start from; empty detects dark or light
+
+	Header      string `toml:"header"`       // title bar text
+	HeaderBg    string `toml:"header_bg"`    // title bar background
+	TableHeader string `toml:"table_header"` // column titles
+	Selected    string `toml:"selected"`     // selected row text
+	SelectedBg  string `toml:"selected_bg"`  // selected row background
+	Text        string `toml:"text"`         // values in the expanded row
+	Muted       string `toml:"muted"`        // child rows, labels, hints
+	Status      string `toml:"status"`       // status bar
+	Accent      string `toml:"accent"`       // sorted column title, filter prompt
+	Mark        string `toml:"mark"`         // marked-row dot
+	Parent      string `toml:"parent"`       // parent PID
+	Sparkline   string `toml:"sparkline"`    // memory and CPU trends
+	Dialog      string `toml:"dialog"`       // kill dialog border and title
+	Picker      string `toml:"picker"`       // column picker border and title
+	Error       string `toml:"error"`
+	Success     string `toml:"success"`
+
+	// Ports maps port color names, as used in port_colors and rules, to
+	// colors. New names can be added alongside green, yellow, cyan, magenta,
+	// red, blue, white and dim.
+	Ports map[string]string `toml:"ports"`
+}
@@ -0,0 +1,162 @@
+// Package exporter serves listening ports as Prometheus metrics.
+package exporter
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/legostin/reap/internal/ports"
+)
+
+// Exporter scans on every scrape and renders the Prometheus text format.
+type Exporter struct {
+	scanner ports.Scanner
+	now     func() time.Time
+
+	mu       sync.Mutex
+	scans    int64   // scans attempted
+	errors   int64   // scans that failed
+	duration float64 // total scan time in seconds
+}
+
+// New returns an Exporter that answers from scanner.
+func New(scanner ports.Scanner) *Exporter {
+	return &Exporter{scanner: scanner, now: time.Now}
+}
+
+// ServeHTTP implements http.Handler for the /metrics endpoint. A failed
+// scan still answers 200 so the error counter can be scraped, and reports
+// reap_scan_success 0 so alerts on missing ports can tell reap is broken.
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	results, ok := e.scan()
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	e.write(w, results, ok)
+}
+
+// scan runs the scanner and updates the scan counters. ok is false when
+// the scan failed.
+func (e *Exporter) scan() (results []ports.PortInfo, ok bool) {
+	start := e.now()
+	results, err := e.scanner.Scan()
+	elapsed := e.now().Sub(start).Seconds()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.scans++
+	e.duration += elapsed
+	if err != nil {
+		e.errors++
+		return nil, false
+	}
+	return results, true
+}
+
+// write renders all metrics. Series are sorted so scrapes are stable.
+func (e *Exporter) write(w io.Writer, results []ports.PortInfo, ok bool) {
+	header(w, "reap_scan_success", "gauge", "Whether the scan for this scrape succeeded; port series are missing when it is 0.")
+	success := 0
+	if ok {
+		success = 1
+	}
+	fmt.Fprintf(w, "reap_scan_success %d\n", success)
+
+	// No PID or address labels: a restarted server keeps its series, so
+	// alerts on long-lived listeners survive restarts.
+	header(w, "reap_port_listening", "gauge", "A port with a listener, 1 per port, protocol and owning process.")
+	var listening []string
+	seen := make(map[string]bool)
+	for _, p := range results {
+		series := "reap_port_listening" + labels(
+			"port", strconv.Itoa(p.Port),
+			"protocol", p.Protocol,
+			"process", p.Process,
+			"user", p.User,
+			"container", p.Container,
+		)
+		if !seen[series] {
+			seen[series] = true
+			listening = append(listening, series+" 1")
+		}
+	}
+	writeSorted(w, listening)
+
+	header(w, "reap_process_resident_memory_bytes", "gauge", "Resident memory of processes owning listening ports.")
+	var memory []string
+	seenPID := make(map[int]bool)
+	for _, p := range results {
+		if seenPID[p.PID] || p.RSS == 0 {
+			continue
+		}
+		seenPID[p.PID] = true
+		memory = append(memory, "reap_process_resident_memory_bytes"+labels(
+			"pid", strconv.Itoa(p.PID),
+			"process", p.Process,
+			"user", p.User,
+		)+" "+strconv.FormatInt(p.RSS, 10))
+	}
+	writeSorted(w, memory)
+
+	header(w, "reap_user_ports", "gauge", "Listening ports per user.")
+	perUser := make(map[string]int)
+	for _, p := range results {
+		perUser[p.User]++
+	}
+	var users []string
+	for user, n := range perUser {
+		users = append(users, "reap_user_ports"+labels("user", user)+" "+strconv.Itoa(n))
+	}
+	writeSorted(w, users)
+
+	e.mu.Lock()
+	scans, errors, duration := e.scans, e.errors, e.duration
+	e.mu.Unlock()
+
+	header(w, "reap_scans_total", "counter", "Port scans performed.")
+	fmt.Fprintf(w, "reap_scans_total %d\n", scans)
+	header(w, "reap_scan_errors_total", "counter", "Port scans that failed.")
+	fmt.Fprintf(w, "reap_scan_errors_total %d\n", errors)
+	header(w, "reap_scan_duration_seconds", "summary", "Time spent scanning.")
+	fmt.Fprintf(w, "reap_scan_duration_seconds_sum %s\n", strconv.FormatFloat(duration, 'g', -1, 64))
+	fmt.Fprintf(w, "reap_scan_duration_seconds_count %d\n", scans)
+}
+
+func header(w io.Writer, name, kind, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func writeSorted(w io.Writer, lines []string) {
+	sort.Strings(lines)
+	for _, l := range lines {
+		fmt.Fprintln(w, l)
+	}
+}
+
+// labels renders name/value pairs as {name="value",...}.
+func labels(pairs ...string) string {
+	var b strings.Builder
+	b.WriteByte('{')
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(pairs[i])
+		b.WriteString(`="`)
+		b.WriteString(escapeLabel(pairs[i+1]))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// escapeLabel escapes a label value for the text exposition format.
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
@@ -0,0 +1,162 @@
+package exporter
+
+import (
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/legostin/reap/internal/ports"
+)
+
+type mockScanner struct {
+	ports []ports.PortInfo
+	err   error
+}
+
+func (m *mockScanner) Scan() ([]ports.PortInfo, error) {
+	return m.ports, m.err
+}
+
+func testPorts() []ports.PortInfo {
+	return []ports.PortInfo{
+		{Port: 3000, PID: 100, Process: "node", User: "dev", Protocol: "tcp", Address: "*", RSS: 52428800},
+		{Port: 3001, PID: 100, Process: "node", User: "dev", Protocol: "tcp", Address: "*", RSS: 52428800},
+		{Port: 5432, PID: 200, Process: "postgres", User: "postgres", Protocol: "tcp", Address: "127.0.0.1", RSS: 1024},
+		{Port: 8080, PID: 300, Process: "docker-proxy", User: "root", Protocol: "tcp", Address: "*", Container: "web"},
+	}
+}
+
+// scrape serves one /metrics request and returns the body.
+func scrape(t *testing.T, e *Exporter) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if rec.Code != 200 {
+		t.Fatalf("status %d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	body, _ := io.ReadAll(rec.Body)
+	return string(body)
+}
+
+func TestExporterMetrics(t *testing.T) {
+	e := New(&mockScanner{ports: testPorts()})
+	out := scrape(t, e)
+
+	for _, want := range []string{
+		"# TYPE reap_port_listening gauge\n",
+		`reap_port_listening{port="3000",protocol="tcp",process="node",user="dev",container=""} 1`,
+		`reap_port_listening{port="8080",protocol="tcp",process="docker-proxy",user="root",container="web"} 1`,
+		`reap_process_resident_memory_bytes{pid="100",process="node",user="dev"} 52428800`,
+		`reap_process_resident_memory_bytes{pid="200",process="postgres",user="postgres"} 1024`,
+		`reap_user_ports{user="dev"} 2`,
+		`reap_user_ports{user="root"} 1`,
+		"reap_scan_success 1\n",
+		"reap_scans_total 1\n",
+		"reap_scan_errors_total 0\n",
+		"reap_scan_duration_seconds_count 1\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+	// one memory series per process, none without RSS
+	if n := strings.Count(out, "reap_process_resident_memory_bytes{"); n != 2 {
+		t.Errorf("expected 2 memory series, got %d", n)
+	}
+}
+
+func TestExporterListeningSurvivesRestart(t *testing.T) {
+	// IPv4 and IPv6 sockets of one server are one series, and a restart
+	// under a new PID keeps it
+	scanner := &mockScanner{ports: []ports.PortInfo{
+		{Port: 3000, PID: 100, Process: "node", User: "dev", Protocol: "tcp", Address: "0.0.0.0"},
+		{Port: 3000, PID: 100, Process: "node", User: "dev", Protocol: "tcp", Address: "::"},
+	}}
+	e := New(scanner)
+	before := scrape(t, e)
+	if n := strings.Count(before, "reap_port_listening{"); n != 1 {
+		t.Errorf("expected 1 listening series, got %d:\n%s", n, before)
+	}
+
+	scanner.ports = []ports.PortInfo{{Port: 3000, PID: 4242, Process: "node", User: "dev", Protocol: "tcp", Address: "0.0.0.0"}}
+	series := `reap_port_listening{port="3000",protocol="tcp",process="node",user="dev",container=""} 1`
+	if after := scrape(t, e); !strings.Contains(before, series) || !strings.Contains(after, series) {
+		t.Errorf("expected %s before and after the restart", series)
+	}
+}
+
+func TestExporterScanErrors(t *testing.T) {
+	scanner := &mockScanner{err: errors.New("lsof failed")}
+	e := New(scanner)
+	scrape(t, e)
+	out := scrape(t, e)
+
+	if !strings.Contains(out, "reap_scans_total 2\n") || !strings.Contains(out, "reap_scan_errors_total 2\n") {
+		t.Errorf("unexpected counters:\n%s", out)
+	}
+	if strings.Contains(out, "reap_port_listening{") {
+		t.Errorf("expected no port series after a failed scan:\n%s", out)
+	}
+	if !strings.Contains(out, "# TYPE reap_scan_success gauge\nreap_scan_success 0\n") {
+		t.Errorf("expected reap_scan_success 0 after a failed scan:\n%s", out)
+	}
+
+	scanner.err = nil
+	scanner.ports = testPorts()
+	out = scrape(t, e)
+	if !strings.Contains(out, "reap_scan_errors_total 2\n") || !strings.Contains(out, "reap_scans_total 3\n") {
+		t.Errorf("unexpected counters after recovery:\n%s", out)
+	}
+	if !strings.Contains(out, "reap_scan_success 1\n") {
+		t.Errorf("expected reap_scan_success 1 after recovery:\n%s", out)
+	}
+}
+
+func TestExporterScanDuration(t *testing.T) {
+	e := New(&mockScanner{})
+	clock := time.Unix(0, 0)
+	e.now = func() time.Time {
+		clock = clock.Add(250 * time.Millisecond)
+		return clock
+	}
+	scrape(t, e)
+	out := scrape(t, e)
+	if !strings.Contains(out, "reap_scan_duration_seconds_sum 0.5\n") {
+		t.Errorf("expected 0.5s total, got:\n%s", out)
+	}
+}
+
+func TestExporterStableOrder(t *testing.T) {
+	p := testPorts()
+	reversed := []ports.PortInfo{p[3], p[2], p[1], p[0]}
+	newExporter := func(results []ports.PortInfo) *Exporter {
+		e := New(&mockScanner{ports: results})
+		e.now = func() time.Time { return time.Unix(0, 0) }
+		return e
+	}
+	if a, b := scrape(t, newExporter(p)), scrape(t, newExporter(reversed)); a != b {
+		t.Errorf("output depends on scan order:\n%s\nvs\n%s", a, b)
+	}
+}
+
+func TestEscapeLabel(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"node", "node"},
+		{`C:\dev`, `C:\\dev`},
+		{`say "hi"`, `say \"hi\"`},
+		{"a\nb", `a\nb`},
+	}
+	for _, tt := range tests {
+		if got := escapeLabel(tt.in); got != tt.want {
+			t.Errorf("escapeLabel(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package ports
+
+import "strconv"
+
+// Connection is a connected TCP socket accepted by a listening port.
+type Connection struct {
+	RemoteAddr   string `json:"remote_addr"`
+	RemotePort   int    `json:"remote_port"`
+	State        string `json:"state"`                   // e.g. "ESTABLISHED", "CLOSE_WAIT"
+	LocalPID     int    `json:"local_pid,omitempty"`     // client PID when the peer runs on this machine, 0 otherwise
+	LocalProcess string `json:"local_process,omitempty"` // client process name, empty if unknown
+}
+
+// connSocket is one end of a connected TCP socket, as seen by a scanner.
+type connSocket struct {
+	localAddr  string
+	localPort  int
+	remoteAddr string
+	remotePort int
+	state      string
+	pid        int // owning process, 0 if unknown
+	process    string
+}
+
+func endpointKey(addr string, port int) string {
+	return addr + "|" + strconv.Itoa(port)
+}
+
+// attachConnections fills Connections for each TCP listener from the
+// connected sockets found during the scan. A socket belongs to a listener
+// when its local port matches and it is owned by the listening PID (or its
+// owner is unknown). When the peer socket is also on this machine, its
+// owning process is recorded as the client.
+func attachConnections(ports []PortInfo, conns []connSocket) {
+	if len(conns) == 0 {
+		return
+	}
+
+	byLocal := make(map[string]connSocket, len(conns))
+	for _, c := range conns {
+		byLocal[endpointKey(c.localAddr, c.localPort)] = c
+	}
+
+	for i := range ports {
+		p := &ports[i]
+		if p.Protocol != "tcp" {
+			continue
+		}
+		for _, c := range conns {
+			if c.localPort != p.Port {
+				continue
+			}
+			if c.pid != 0 && c.pid != p.PID {
+				continue
+			}
+			conn := Connection{
+				RemoteAddr: c.remoteAddr,
+				RemotePort: c.remotePort,
+				State:      c.state,
+			}
+			if peer, ok := byLocal[endpointKey(c.remoteAddr, c.remotePort)]; ok && peer.remotePort == p.Port {
+				conn.LocalPID = peer.pid
+				conn.LocalProcess = peer.process
+			}
+			p.Connections = append(p.Connections, conn)
+		}
+	}
+}
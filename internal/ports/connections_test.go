@@ -0,0 +1,67 @@
+package ports
+
+import "testing"
+
+func TestAttachConnections(t *testing.T) {
+	ports := []PortInfo{
+		{Port: 3000, PID: 100, Protocol: "tcp"},
+		{Port: 5432, PID: 200, Protocol: "tcp"},
+		{Port: 3000, PID: 100, Protocol: "udp"},
+	}
+	conns := []connSocket{
+		// accepted by node, peer is a local psql-like client
+		{localAddr: "127.0.0.1", localPort: 3000, remoteAddr: "127.0.0.1", remotePort: 40000, state: "ESTABLISHED", pid: 100},
+		{localAddr: "127.0.0.1", localPort: 40000, remoteAddr: "127.0.0.1", remotePort: 3000, state: "ESTABLISHED", pid: 300, process: "curl"},
+		// accepted by node, peer is remote
+		{localAddr: "10.0.0.1", localPort: 3000, remoteAddr: "10.0.0.9", remotePort: 51000, state: "ESTABLISHED", pid: 100},
+		// node's own outgoing connection to postgres
+		{localAddr: "127.0.0.1", localPort: 45000, remoteAddr: "127.0.0.1", remotePort: 5432, state: "ESTABLISHED", pid: 100, process: "node"},
+		{localAddr: "127.0.0.1", localPort: 5432, remoteAddr: "127.0.0.1", remotePort: 45000, state: "ESTABLISHED", pid: 200},
+		// same port but owned by an unrelated process
+		{localAddr: "127.0.0.1", localPort: 3000, remoteAddr: "127.0.0.1", remotePort: 41000, state: "ESTABLISHED", pid: 999},
+	}
+
+	attachConnections(ports, conns)
+
+	if n := len(ports[0].Connections); n != 2 {
+		t.Fatalf("port 3000: expected 2 connections, got %d: %+v", n, ports[0].Connections)
+	}
+	if c := ports[0].Connections[0]; c.LocalPID != 300 || c.LocalProcess != "curl" {
+		t.Errorf("port 3000: expected curl client, got %+v", c)
+	}
+	if c := ports[0].Connections[1]; c.LocalPID != 0 || c.RemoteAddr != "10.0.0.9" {
+		t.Errorf("port 3000: expected remote peer, got %+v", c)
+	}
+
+	if n := len(ports[1].Connections); n != 1 {
+		t.Fatalf("port 5432: expected 1 connection, got %d", n)
+	}
+	if c := ports[1].Connections[0]; c.LocalProcess != "node" || c.LocalPID != 100 {
+		t.Errorf("port 5432: expected node client, got %+v", c)
+	}
+
+	if len(ports[2].Connections) != 0 {
+		t.Errorf("udp entries should not get connections, got %+v", ports[2].Connections)
+	}
+}
+
+func TestAttachConnectionsUnknownOwner(t *testing.T) {
+	ports := []PortInfo{{Port: 8080, PID: 100, Protocol: "tcp"}}
+	conns := []connSocket{
+		{localAddr: "*", localPort: 8080, remoteAddr: "192.168.1.2", remotePort: 50000, state: "SYN_RECV"},
+	}
+
+	attachConnections(ports, conns)
+
+	if len(ports[0].Connections) != 1 {
+		t.Fatalf("expected connection with unknown owner to be attached by port, got %+v", ports[0].Connections)
+	}
+}
+
+func TestAttachConnectionsEmpty(t *testing.T) {
+	ports := []PortInfo{{Port: 8080, PID: 100, Protocol: "tcp"}}
+	attachConnections(ports, nil)
+	if ports[0].Connections != nil {
+		t.Errorf("expected no connections, got %+v", ports[0].Connections)
+	}
+}
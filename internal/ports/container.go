@@ -0,0 +1,285 @@
+package ports
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// runtimeTimeout bounds the container runtime queries made during a scan,
+// and is the slack added to a container stop timeout.
+const runtimeTimeout = 2 * time.Second
+
+// ContainerRuntime is a container engine that can report which containers
+// own ports and act on them.
+type ContainerRuntime interface {
+	// Name identifies the runtime, e.g. "docker". It is stored in
+	// Container.Runtime to route actions back to the right engine.
+	Name() string
+	// Containers lists running containers.
+	Containers(ctx context.Context) ([]Container, error)
+	// ApplyAction runs action on container id. For stop and restart,
+	// timeout is how long the container gets to exit before it is killed.
+	ApplyAction(ctx context.Context, id string, action ContainerAction, timeout time.Duration) error
+}
+
+// Container describes a running container that may own listening ports.
+type Container struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Image   string `json:"image"`
+	State   string `json:"state"`             // running, paused, restarting, ...
+	Runtime string `json:"runtime"`           // name of the ContainerRuntime that reported it
+	Project string `json:"project,omitempty"` // compose project, empty if not started by compose
+	Service string `json:"service,omitempty"` // compose service
+
+	// PIDs are the container's processes as seen from the host. They are
+	// only looked up for containers on the host network: sockets of other
+	// containers live in their own network namespace and never show up in
+	// a host scan.
+	PIDs        []int           `json:"pids,omitempty"`
+	HostNetwork bool            `json:"host_network"`
+	IPs         []string        `json:"ips,omitempty"`   // container addresses on its networks
+	Ports       []ContainerPort `json:"ports,omitempty"` // published ports
+}
+
+// ContainerPort is a port published on the host.
+type ContainerPort struct {
+	HostIP        string `json:"host_ip"`
+	HostPort      int    `json:"host_port"`
+	ContainerPort int    `json:"container_port"`
+	Protocol      string `json:"protocol"`
+}
+
+// ContainerAction is a lifecycle operation on a container.
+type ContainerAction string
+
+const (
+	ContainerStop    ContainerAction = "stop"
+	ContainerRestart ContainerAction = "restart"
+	ContainerPause   ContainerAction = "pause"
+	ContainerUnpause ContainerAction = "unpause"
+)
+
+// ParseContainerAction validates a user-supplied action name.
+func ParseContainerAction(s string) (ContainerAction, error) {
+	switch a := ContainerAction(strings.ToLower(s)); a {
+	case ContainerStop, ContainerRestart, ContainerPause, ContainerUnpause:
+		return a, nil
+	}
+	return "", fmt.Errorf("unknown container action %q (want stop, restart, pause or unpause)", s)
+}
+
+// Done returns the past tense of the action for status messages.
+func (a ContainerAction) Done() string {
+	switch a {
+	case ContainerStop:
+		return "stopped"
+	case ContainerRestart:
+		return "restarted"
+	case ContainerPause:
+		return "paused"
+	case ContainerUnpause:
+		return "unpaused"
+	default:
+		return string(a)
+	}
+}
+
+// containerRuntimes returns the available runtimes, in order. It is a
+// variable so tests can substitute fakes.
+var containerRuntimes = func() []ContainerRuntime {
+	var runtimes []ContainerRuntime
+	if c, err := NewDockerClient(dockerHost()); err == nil {
+		runtimes = append(runtimes, c)
+	}
+	if c, err := NewPodmanClient(podmanHost()); err == nil {
+		runtimes = append(runtimes, c)
+	}
+	if n, err := newNerdctl(); err == nil {
+		runtimes = append(runtimes, n)
+	}
+	return runtimes
+}
+
+// ApplyContainerAction runs action on ct through the runtime that reported
+// it, waiting at most timeout plus a little slack for the runtime.
+func ApplyContainerAction(ct *Container, action ContainerAction, timeout time.Duration) error {
+	runtime := ct.Runtime
+	if runtime == "" {
+		runtime = runtimeDocker
+	}
+	for _, rt := range containerRuntimes() {
+		if rt.Name() != runtime {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), timeout+runtimeTimeout)
+		defer cancel()
+		return rt.ApplyAction(ctx, ct.ID, action, timeout)
+	}
+	return fmt.Errorf("container runtime %q is not available", runtime)
+}
+
+// newSocketClient returns an HTTP client and URL prefix for an API host in
+// DOCKER_HOST syntax: unix:///path/to.sock or tcp://host:port. TLS is not
+// supported. For unix sockets the URL host is the placeholder name.
+func newSocketClient(host, name string) (*http.Client, string, error) {
+	u, err := url.Parse(host)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid host %q: %w", host, err)
+	}
+
+	transport := &http.Transport{}
+	base := ""
+	switch u.Scheme {
+	case "unix":
+		if u.Path == "" {
+			return nil, "", fmt.Errorf("invalid host %q: missing socket path", host)
+		}
+		socket := u.Path
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socket)
+		}
+		base = "http://" + name
+	case "tcp", "http":
+		if u.Host == "" {
+			return nil, "", fmt.Errorf("invalid host %q: missing address", host)
+		}
+		base = "http://" + u.Host
+	default:
+		return nil, "", fmt.Errorf("unsupported host %q", host)
+	}
+
+	// No client-wide timeout: stopping a container legitimately takes as
+	// long as its stop timeout. Callers bound requests with a context.
+	return &http.Client{Transport: transport}, base, nil
+}
+
+// firstSocket returns "unix://" plus the first path that exists, or "" if
+// none does. A leading "~" is replaced by the home directory.
+func firstSocket(paths ...string) string {
+	for _, path := range paths {
+		if rest, ok := strings.CutPrefix(path, "~"); ok {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				continue
+			}
+			path = home + rest
+		}
+		if _, err := os.Stat(path); err == nil {
+			return "unix://" + path
+		}
+	}
+	return ""
+}
+
+// isPortProxy reports whether a process forwards published container ports
+// on the host rather than serving them itself. lsof truncates command
+// names to 9 characters, so Docker Desktop's com.docker.backend shows up as
+// "com.docke".
+func isPortProxy(name string) bool {
+	switch name {
+	case "docker-proxy", "vpnkit", "vpnkit-bridge", // Docker
+		"rootlessport", "conmon", "gvproxy", // Podman
+		"rootlesskit": // rootless Docker and nerdctl
+		return true
+	}
+	return strings.HasPrefix(name, "com.docke")
+}
+
+// matchContainers sets Container and ContainerInfo on entries owned by a
+// container: either a container process on the host network, or a proxy
+// forwarding to one. Other processes are never matched by port number
+// alone.
+func matchContainers(ports []PortInfo, containers []Container) {
+	byPID := make(map[int]int)
+	byIP := make(map[string]int)
+	for i, c := range containers {
+		for _, pid := range c.PIDs {
+			byPID[pid] = i
+		}
+		for _, ip := range c.IPs {
+			byIP[ip] = i
+		}
+	}
+
+	for i := range ports {
+		p := &ports[i]
+		idx, ok := byPID[p.PID]
+		if !ok && isPortProxy(p.Process) {
+			idx, ok = matchProxy(*p, containers, byIP)
+		}
+		if !ok {
+			continue
+		}
+		c := containers[idx]
+		p.Container = c.Name
+		p.ContainerInfo = &c
+	}
+}
+
+// matchProxy finds the container a proxy process forwards p to, by the
+// container address on a docker-proxy command line or else by published
+// host port.
+func matchProxy(p PortInfo, containers []Container, byIP map[string]int) (int, bool) {
+	if ip, _, ok := parseDockerProxyTarget(p.Command); ok {
+		if idx, ok := byIP[ip]; ok {
+			return idx, true
+		}
+	}
+	for i, c := range containers {
+		for _, cp := range c.Ports {
+			if cp.HostPort == p.Port && (p.Protocol == "" || cp.Protocol == p.Protocol) {
+				return i, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// collectContainers queries every runtime and merges the results. A
+// container reported by two runtimes (DOCKER_HOST pointing at Podman's
+// compatibility socket) is kept once. Unreachable runtimes are skipped.
+func collectContainers(ctx context.Context, runtimes []ContainerRuntime) []Container {
+	var all []Container
+	seen := make(map[string]bool)
+	for _, rt := range runtimes {
+		containers, err := rt.Containers(ctx)
+		if err != nil {
+			continue
+		}
+		for _, c := range containers {
+			if seen[c.ID] {
+				continue
+			}
+			seen[c.ID] = true
+			c.Runtime = rt.Name()
+			all = append(all, c)
+		}
+	}
+	return all
+}
+
+// containerEnricher attributes ports to containers of every available
+// runtime and to kubectl port-forward processes. The runtimes are set up
+// on the first scan and reused, so per-runtime state such as the nerdctl
+// cache lives as long as the scanner.
+type containerEnricher struct {
+	once     sync.Once
+	runtimes []ContainerRuntime
+}
+
+func (e *containerEnricher) enrich(ports []PortInfo) {
+	e.once.Do(func() { e.runtimes = containerRuntimes() })
+	ctx, cancel := context.WithTimeout(context.Background(), runtimeTimeout)
+	defer cancel()
+	matchContainers(ports, collectContainers(ctx, e.runtimes))
+	detectPortForwards(ports)
+}
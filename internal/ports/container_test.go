@@ -0,0 +1,265 @@
+package ports
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// serveUnix serves handler on a unix socket and returns its host URL.
+func serveUnix(t *testing.T, handler http.Handler) string {
+	t.Helper()
+	socket := filepath.Join(t.TempDir(), "api.sock")
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	srv := httptest.NewUnstartedServer(handler)
+	srv.Listener = l
+	srv.Start()
+	t.Cleanup(srv.Close)
+	return "unix://" + socket
+}
+
+// fakeRuntime is a ContainerRuntime returning fixed containers and
+// recording actions.
+type fakeRuntime struct {
+	name       string
+	containers []Container
+	err        error
+	actions    []string
+}
+
+func (f *fakeRuntime) Name() string { return f.name }
+
+func (f *fakeRuntime) Containers(context.Context) ([]Container, error) {
+	return f.containers, f.err
+}
+
+func (f *fakeRuntime) ApplyAction(_ context.Context, id string, action ContainerAction, _ time.Duration) error {
+	f.actions = append(f.actions, string(action)+" "+id)
+	return nil
+}
+
+// withRuntimes replaces the runtimes queried by scans for one test.
+func withRuntimes(t *testing.T, runtimes ...ContainerRuntime) {
+	t.Helper()
+	orig := containerRuntimes
+	containerRuntimes = func() []ContainerRuntime { return runtimes }
+	t.Cleanup(func() { containerRuntimes = orig })
+}
+
+func TestCollectContainers(t *testing.T) {
+	docker := &fakeRuntime{name: "docker", containers: []Container{{ID: "aaa", Name: "web"}}}
+	broken := &fakeRuntime{name: "broken", err: errors.New("connection refused")}
+	// Podman behind DOCKER_HOST reports the same container twice
+	podman := &fakeRuntime{name: "podman", containers: []Container{{ID: "aaa", Name: "web"}, {ID: "bbb", Name: "db"}}}
+
+	got := collectContainers(context.Background(), []ContainerRuntime{docker, broken, podman})
+	if len(got) != 2 {
+		t.Fatalf("expected 2 containers, got %+v", got)
+	}
+	if got[0].ID != "aaa" || got[0].Runtime != "docker" {
+		t.Errorf("expected the first runtime to win, got %+v", got[0])
+	}
+	if got[1].ID != "bbb" || got[1].Runtime != "podman" {
+		t.Errorf("expected db from podman, got %+v", got[1])
+	}
+}
+
+func TestEnrichContainers(t *testing.T) {
+	withRuntimes(t, &fakeRuntime{name: "podman", containers: []Container{
+		{ID: "ccc", Name: "cache", Ports: []ContainerPort{{HostPort: 6379, ContainerPort: 6379, Protocol: "tcp"}}},
+	}})
+
+	ports := []PortInfo{
+		{Port: 6379, PID: 10, Process: "rootlessport", Protocol: "tcp"},
+		{Port: 8080, PID: 11, Process: "kubectl", Protocol: "tcp", Command: "kubectl port-forward svc/api 8080:80"},
+		{Port: 3000, PID: 12, Process: "node", Protocol: "tcp", Command: "node server.js"},
+	}
+	new(containerEnricher).enrich(ports)
+
+	if ports[0].Container != "cache" || ports[0].ContainerInfo.Runtime != "podman" {
+		t.Errorf("expected podman container, got %+v", ports[0])
+	}
+	if ports[1].PortForward == nil || ports[1].Container != "default/service/api" {
+		t.Errorf("expected port-forward, got %+v", ports[1])
+	}
+	if ports[2].Container != "" || ports[2].ContainerInfo != nil || ports[2].PortForward != nil {
+		t.Errorf("expected plain process, got %+v", ports[2])
+	}
+}
+
+func TestContainerEnricherReusesRuntimes(t *testing.T) {
+	built := 0
+	orig := containerRuntimes
+	containerRuntimes = func() []ContainerRuntime {
+		built++
+		return []ContainerRuntime{&fakeRuntime{name: "docker"}}
+	}
+	t.Cleanup(func() { containerRuntimes = orig })
+
+	var e containerEnricher
+	for range 3 {
+		e.enrich([]PortInfo{{Port: 3000, PID: 12, Process: "node", Protocol: "tcp"}})
+	}
+	if built != 1 {
+		t.Errorf("expected the runtimes to be built once, built %d times", built)
+	}
+}
+
+func TestApplyContainerActionRoutesByRuntime(t *testing.T) {
+	docker := &fakeRuntime{name: "docker"}
+	podman := &fakeRuntime{name: "podman"}
+	withRuntimes(t, docker, podman)
+
+	if err := ApplyContainerAction(&Container{ID: "aaa", Runtime: "podman"}, ContainerStop, time.Second); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Containers without a runtime predate runtime detection and are Docker's
+	if err := ApplyContainerAction(&Container{ID: "bbb"}, ContainerPause, time.Second); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(podman.actions) != 1 || podman.actions[0] != "stop aaa" {
+		t.Errorf("podman actions: %v", podman.actions)
+	}
+	if len(docker.actions) != 1 || docker.actions[0] != "pause bbb" {
+		t.Errorf("docker actions: %v", docker.actions)
+	}
+
+	if err := ApplyContainerAction(&Container{ID: "ccc", Runtime: "nerdctl"}, ContainerStop, time.Second); err == nil {
+		t.Error("expected error for an unavailable runtime")
+	}
+}
+
+func TestFirstSocket(t *testing.T) {
+	dir := t.TempDir()
+	sock := filepath.Join(dir, "b.sock")
+	if err := os.WriteFile(sock, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if got := firstSocket(filepath.Join(dir, "a.sock"), sock); got != "unix://"+sock {
+		t.Errorf("expected the existing socket, got %q", got)
+	}
+	if got := firstSocket(filepath.Join(dir, "a.sock")); got != "" {
+		t.Errorf("expected no socket, got %q", got)
+	}
+}
+
+func TestIsPortProxy(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"docker-proxy", true},
+		{"com.docke", true},
+		{"com.docker.backend", true},
+		{"rootlessport", true},
+		{"conmon", true},
+		{"gvproxy", true},
+		{"rootlesskit", true},
+		{"node", false},
+		{"dockerd", false},
+	}
+	for _, tt := range tests {
+		if got := isPortProxy(tt.name); got != tt.want {
+			t.Errorf("isPortProxy(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func testContainers() []Container {
+	return []Container{
+		{
+			ID: "aaa111", Name: "app-web-1", IPs: []string{"172.18.0.2"},
+			Ports: []ContainerPort{{HostPort: 8080, ContainerPort: 80, Protocol: "tcp"}},
+		},
+		{ID: "bbb222", Name: "metrics", HostNetwork: true, PIDs: []int{4242}},
+		{
+			ID: "ccc333", Name: "dns",
+			Ports: []ContainerPort{{HostPort: 5353, ContainerPort: 53, Protocol: "udp"}},
+		},
+	}
+}
+
+func TestMatchContainers(t *testing.T) {
+	ports := []PortInfo{
+		// docker-proxy with the container address on its command line
+		{Port: 8080, PID: 900, Process: "docker-proxy", Protocol: "tcp",
+			Command: "docker-proxy -proto tcp -host-port 8080 -container-ip 172.18.0.2 -container-port 80"},
+		// host-network container process
+		{Port: 9100, PID: 4242, Process: "node_exporter", Protocol: "tcp"},
+		// Docker Desktop backend, matched by published port and protocol
+		{Port: 5353, PID: 700, Process: "com.docke", Protocol: "udp"},
+		// same port number over TCP is not published by the container
+		{Port: 5353, PID: 700, Process: "com.docke", Protocol: "tcp"},
+		// an unrelated process that happens to use a published port number
+		{Port: 8080, PID: 123, Process: "node", Protocol: "tcp"},
+	}
+	matchContainers(ports, testContainers())
+
+	want := []string{"app-web-1", "metrics", "dns", "", ""}
+	for i, w := range want {
+		if ports[i].Container != w {
+			t.Errorf("port %d (%s): container = %q, want %q", ports[i].Port, ports[i].Process, ports[i].Container, w)
+		}
+		if (ports[i].ContainerInfo != nil) != (w != "") {
+			t.Errorf("port %d (%s): ContainerInfo = %+v", ports[i].Port, ports[i].Process, ports[i].ContainerInfo)
+		}
+	}
+	if ports[0].ContainerInfo.ID != "aaa111" {
+		t.Errorf("expected container ID, got %+v", ports[0].ContainerInfo)
+	}
+}
+
+func TestMatchContainersProxyFallsBackToPublishedPort(t *testing.T) {
+	// docker-proxy started without -container-ip (userland proxy disabled
+	// variants) is matched by the published host port.
+	ports := []PortInfo{{Port: 8080, PID: 900, Process: "docker-proxy", Protocol: "tcp"}}
+	matchContainers(ports, testContainers())
+	if ports[0].Container != "app-web-1" {
+		t.Errorf("expected app-web-1, got %q", ports[0].Container)
+	}
+}
+
+func TestMatchContainersNoContainers(t *testing.T) {
+	ports := []PortInfo{{Port: 8080, PID: 900, Process: "docker-proxy"}}
+	matchContainers(ports, nil)
+	if ports[0].Container != "" || ports[0].ContainerInfo != nil {
+		t.Errorf("expected no match, got %+v", ports[0])
+	}
+}
+
+func TestParseContainerAction(t *testing.T) {
+	for _, s := range []string{"stop", "restart", "pause", "UNPAUSE"} {
+		if _, err := ParseContainerAction(s); err != nil {
+			t.Errorf("ParseContainerAction(%q): %v", s, err)
+		}
+	}
+	if _, err := ParseContainerAction("kill"); err == nil {
+		t.Error("expected error for unknown action")
+	}
+}
+
+func TestContainerActionDone(t *testing.T) {
+	tests := []struct {
+		action ContainerAction
+		want   string
+	}{
+		{ContainerStop, "stopped"},
+		{ContainerRestart, "restarted"},
+		{ContainerPause, "paused"},
+		{ContainerUnpause, "unpaused"},
+	}
+	for _, tt := range tests {
+		if got := tt.action.Done(); got != tt.want {
+			t.Errorf("%s.Done() = %q, want %q", tt.action, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package ports
+
+import (
+	"sync"
+	"time"
+)
+
+// cpuTracker turns cumulative CPU times into a usage percentage between
+// successive scans. A scanner keeps one for its lifetime.
+type cpuTracker struct {
+	mu   sync.Mutex
+	prev map[int]cpuSample
+}
+
+// cpuSample is the CPU time of a process at a point in time. start tells
+// a reused PID apart from the process seen before.
+type cpuSample struct {
+	cpu   time.Duration
+	start time.Time
+	at    time.Time
+}
+
+func newCPUTracker() *cpuTracker {
+	return &cpuTracker{prev: make(map[int]cpuSample)}
+}
+
+// percent returns the CPU usage of pid in percent of one core since the
+// previous call, or since the process started when there is no previous
+// sample. A nil tracker always averages over the process lifetime.
+func (t *cpuTracker) percent(pid int, s cpuSample) float64 {
+	base := cpuSample{start: s.start, at: s.start}
+	if t != nil {
+		t.mu.Lock()
+		if prev, ok := t.prev[pid]; ok && prev.start.Equal(s.start) && s.at.After(prev.at) {
+			base = prev
+		}
+		t.prev[pid] = s
+		t.mu.Unlock()
+	}
+	wall := s.at.Sub(base.at)
+	if base.at.IsZero() || wall <= 0 || s.cpu < base.cpu {
+		return 0
+	}
+	return float64(s.cpu-base.cpu) / float64(wall) * 100
+}
+
+// prune forgets processes that were not seen in the last scan.
+func (t *cpuTracker) prune(seen map[int]bool) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for pid := range t.prev {
+		if !seen[pid] {
+			delete(t.prev, pid)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package ports
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestCPUTrackerPercent(t *testing.T) {
+	start := time.Date(2026, 10, 17, 8, 0, 0, 0, time.UTC)
+	at := start.Add(100 * time.Second)
+	tr := newCPUTracker()
+
+	// first sample: lifetime average, 25s of CPU in 100s
+	if got := tr.percent(42, cpuSample{cpu: 25 * time.Second, start: start, at: at}); !near(got, 25) {
+		t.Errorf("first sample = %v, want 25", got)
+	}
+	// 1.5s of CPU in the next 2s
+	at = at.Add(2 * time.Second)
+	if got := tr.percent(42, cpuSample{cpu: 26500 * time.Millisecond, start: start, at: at}); !near(got, 75) {
+		t.Errorf("second sample = %v, want 75", got)
+	}
+	// multithreaded processes can use more than one core
+	at = at.Add(time.Second)
+	if got := tr.percent(42, cpuSample{cpu: 28500 * time.Millisecond, start: start, at: at}); !near(got, 200) {
+		t.Errorf("third sample = %v, want 200", got)
+	}
+	// a reused PID starts over
+	restart := at.Add(-10 * time.Second)
+	if got := tr.percent(42, cpuSample{cpu: time.Second, start: restart, at: at.Add(time.Second)}); !near(got, 1.0/11*100) {
+		t.Errorf("reused PID = %v, want lifetime average", got)
+	}
+
+	tr.prune(map[int]bool{7: true})
+	if len(tr.prev) != 0 {
+		t.Errorf("prune kept %v", tr.prev)
+	}
+}
+
+func TestCPUTrackerNil(t *testing.T) {
+	var tr *cpuTracker
+	start := time.Date(2026, 10, 17, 8, 0, 0, 0, time.UTC)
+	if got := tr.percent(1, cpuSample{cpu: 10 * time.Second, start: start, at: start.Add(40 * time.Second)}); !near(got, 25) {
+		t.Errorf("nil tracker = %v, want the lifetime average 25", got)
+	}
+	if got := tr.percent(1, cpuSample{cpu: time.Second}); got != 0 {
+		t.Errorf("unknown start time = %v, want 0", got)
+	}
+	tr.prune(nil)
+}
+
+func near(a, b float64) bool { return math.Abs(a-b) < 0.01 }
@@ -0,0 +1,113 @@
+package ports
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// ChangeKind classifies a difference between two scans.
+type ChangeKind string
+
+const (
+	ChangeOpened ChangeKind = "opened" // a port started listening
+	ChangeClosed ChangeKind = "closed" // a port stopped listening
+	ChangeOwner  ChangeKind = "owner"  // a port is now held by another PID
+)
+
+// Change is a port that differs between two scans.
+type Change struct {
+	Kind     ChangeKind `json:"kind"`
+	Port     PortInfo   `json:"port"`               // the entry after the change, or the closed one
+	Previous *PortInfo  `json:"previous,omitempty"` // the former owner for ChangeOwner
+}
+
+// socketKey identifies a listening socket independently of its owner.
+type socketKey struct {
+	address  string
+	port     int
+	protocol string
+}
+
+func socketOf(p PortInfo) socketKey {
+	return socketKey{p.Address, p.Port, p.Protocol}
+}
+
+// DiffPorts compares two scans. Opened and owner changes come first in the
+// order of next, then closed ports in the order of prev.
+func DiffPorts(prev, next []PortInfo) []Change {
+	before := make(map[socketKey]PortInfo, len(prev))
+	owners := make(map[socketKey]map[int]bool, len(prev))
+	for _, p := range prev {
+		key := socketOf(p)
+		if owners[key] == nil {
+			before[key] = p
+			owners[key] = make(map[int]bool)
+		}
+		owners[key][p.PID] = true
+	}
+	after := make(map[socketKey]bool, len(next))
+
+	var changes []Change
+	for _, p := range next {
+		key := socketOf(p)
+		if after[key] {
+			continue // the same socket shared by several processes
+		}
+		after[key] = true
+		old, ok := before[key]
+		switch {
+		case !ok:
+			changes = append(changes, Change{Kind: ChangeOpened, Port: p})
+		case !owners[key][p.PID]:
+			changes = append(changes, Change{Kind: ChangeOwner, Port: p, Previous: &old})
+		}
+	}
+	for _, p := range prev {
+		key := socketOf(p)
+		if !after[key] {
+			after[key] = true
+			changes = append(changes, Change{Kind: ChangeClosed, Port: p})
+		}
+	}
+	return changes
+}
+
+// String describes the change in one line, e.g.
+// "opened 3000/tcp on *: node (PID 4242)".
+func (c Change) String() string {
+	p := c.Port
+	where := strconv.Itoa(p.Port) + "/" + p.Protocol + " on " + p.Address
+	owner := fmt.Sprintf("%s (PID %d)", p.Process, p.PID)
+	if c.Kind == ChangeOwner && c.Previous != nil {
+		return fmt.Sprintf("owner %s: %s (PID %d) -> %s", where, c.Previous.Process, c.Previous.PID, owner)
+	}
+	return fmt.Sprintf("%s %s: %s", c.Kind, where, owner)
+}
+
+// Watch rescans every interval until ctx is done. After each scan it calls
+// fn with the changes to the filtered ports since the previous successful
+// scan, starting from prev, or with the scan error. fn is not called when
+// nothing changed.
+func Watch(ctx context.Context, scanner Scanner, interval time.Duration, filter Filter, prev []PortInfo, fn func([]Change, error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		results, err := scanner.Scan()
+		if err != nil {
+			fn(nil, err)
+			continue
+		}
+		next := filter.Apply(results)
+		if changes := DiffPorts(prev, next); len(changes) > 0 {
+			fn(changes, nil)
+		}
+		prev = next
+	}
+}
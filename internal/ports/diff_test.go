@@ -0,0 +1,128 @@
+package ports
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDiffPorts(t *testing.T) {
+	prev := []PortInfo{
+		{Port: 3000, PID: 10, Process: "node", Protocol: "tcp", Address: "*"},
+		{Port: 5432, PID: 20, Process: "postgres", Protocol: "tcp", Address: "127.0.0.1"},
+		{Port: 8080, PID: 30, Process: "java", Protocol: "tcp", Address: "*"},
+	}
+	next := []PortInfo{
+		{Port: 3000, PID: 11, Process: "node", Protocol: "tcp", Address: "*"},
+		{Port: 5432, PID: 20, Process: "postgres", Protocol: "tcp", Address: "127.0.0.1"},
+		{Port: 5432, PID: 20, Process: "postgres", Protocol: "tcp", Address: "::1"},
+		{Port: 5353, PID: 40, Process: "mdns", Protocol: "udp", Address: "*"},
+	}
+
+	changes := DiffPorts(prev, next)
+	want := []struct {
+		kind    ChangeKind
+		port    int
+		address string
+	}{
+		{ChangeOwner, 3000, "*"},
+		{ChangeOpened, 5432, "::1"},
+		{ChangeOpened, 5353, "*"},
+		{ChangeClosed, 8080, "*"},
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("got %d changes, want %d: %+v", len(changes), len(want), changes)
+	}
+	for i, w := range want {
+		c := changes[i]
+		if c.Kind != w.kind || c.Port.Port != w.port || c.Port.Address != w.address {
+			t.Errorf("change %d: got %s %d %s, want %s %d %s", i, c.Kind, c.Port.Port, c.Port.Address, w.kind, w.port, w.address)
+		}
+	}
+	if changes[0].Previous == nil || changes[0].Previous.PID != 10 {
+		t.Errorf("expected previous owner, got %+v", changes[0].Previous)
+	}
+}
+
+func TestDiffPortsSharedSocket(t *testing.T) {
+	// forked workers share one listening socket; they are not an owner change
+	workers := []PortInfo{
+		{Port: 80, PID: 100, Process: "nginx", Protocol: "tcp", Address: "*"},
+		{Port: 80, PID: 101, Process: "nginx", Protocol: "tcp", Address: "*"},
+	}
+	if changes := DiffPorts(workers, workers); len(changes) != 0 {
+		t.Errorf("expected no changes, got %+v", changes)
+	}
+	if changes := DiffPorts(nil, nil); len(changes) != 0 {
+		t.Errorf("expected no changes, got %+v", changes)
+	}
+}
+
+func TestChangeString(t *testing.T) {
+	node := PortInfo{Port: 3000, PID: 11, Process: "node", Protocol: "tcp", Address: "*"}
+	old := PortInfo{Port: 3000, PID: 10, Process: "node", Protocol: "tcp", Address: "*"}
+	tests := []struct {
+		change Change
+		want   string
+	}{
+		{Change{Kind: ChangeOpened, Port: node}, "opened 3000/tcp on *: node (PID 11)"},
+		{Change{Kind: ChangeClosed, Port: node}, "closed 3000/tcp on *: node (PID 11)"},
+		{Change{Kind: ChangeOwner, Port: node, Previous: &old}, "owner 3000/tcp on *: node (PID 10) -> node (PID 11)"},
+	}
+	for _, tt := range tests {
+		if got := tt.change.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+// sequenceScanner returns one scan result after another, repeating the last.
+type sequenceScanner struct {
+	mu    sync.Mutex
+	scans [][]PortInfo
+	errs  []error
+}
+
+func (s *sequenceScanner) Scan() ([]PortInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	results, err := s.scans[0], s.errs[0]
+	if len(s.scans) > 1 {
+		s.scans, s.errs = s.scans[1:], s.errs[1:]
+	}
+	return results, err
+}
+
+func TestWatch(t *testing.T) {
+	node := PortInfo{Port: 3000, PID: 10, Process: "node", Protocol: "tcp", Address: "*"}
+	dns := PortInfo{Port: 53, PID: 20, Process: "dnsmasq", Protocol: "udp", Address: "*"}
+	scanner := &sequenceScanner{
+		scans: [][]PortInfo{{dns}, nil, {node, dns}, {node, dns}, {}},
+		errs:  []error{nil, errors.New("lsof failed"), nil, nil, nil},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var got []string
+	Watch(ctx, scanner, time.Millisecond, Filter{Protocol: "tcp"}, nil, func(changes []Change, err error) {
+		if err != nil {
+			got = append(got, "error")
+		}
+		for _, c := range changes {
+			got = append(got, string(c.Kind)+" "+strconv.Itoa(c.Port.Port))
+		}
+		if len(got) == 3 {
+			cancel()
+		}
+	})
+
+	// the udp socket is filtered out and the unchanged scan is not reported
+	want := []string{"error", "opened 3000", "closed 3000"}
+	if strings.Join(got, ", ") != strings.Join(want, ", ") {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -1,79 +1,243 @@
 package ports
 
 import (
-	"os/exec"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
 	"strconv"
 	"strings"
+	"time"
 )
 
-// dockerPortMap maps host port -> container name by parsing `docker ps`.
-type dockerPortMap map[int]string
+const runtimeDocker = "docker"
 
-// detectDocker runs `docker ps --format` and builds a map of
-// host port -> container name. Returns nil if docker is unavailable.
-func detectDocker() dockerPortMap {
-	out, err := exec.Command("docker", "ps",
-		"--format", "{{.Names}}\t{{.Ports}}").Output()
+// DockerClient talks to the Docker Engine API.
+type DockerClient struct {
+	http *http.Client
+	base string // URL prefix; the host part is ignored for unix sockets
+}
+
+// dockerHost returns DOCKER_HOST, or the first default socket that exists.
+// The second default is where Docker Desktop puts its socket when /var/run
+// is not linked.
+func dockerHost() string {
+	if host := os.Getenv("DOCKER_HOST"); host != "" {
+		return host
+	}
+	if host := firstSocket("/var/run/docker.sock", "~/.docker/run/docker.sock"); host != "" {
+		return host
+	}
+	return "unix:///var/run/docker.sock"
+}
+
+// NewDockerClient returns a client for host, which uses DOCKER_HOST syntax:
+// unix:///var/run/docker.sock or tcp://127.0.0.1:2375. TLS is not supported.
+func NewDockerClient(host string) (*DockerClient, error) {
+	client, base, err := newSocketClient(host, runtimeDocker)
 	if err != nil {
-		return nil
+		return nil, fmt.Errorf("docker: %w", err)
 	}
-	return parseDockerPS(string(out))
+	return &DockerClient{http: client, base: base}, nil
 }
 
-// parseDockerPS parses `docker ps --format "{{.Names}}\t{{.Ports}}"` output.
-// Ports look like: "0.0.0.0:3000->3000/tcp, :::3000->3000/tcp"
-func parseDockerPS(output string) dockerPortMap {
-	m := make(dockerPortMap)
-	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
-		if line == "" {
-			continue
-		}
-		parts := strings.SplitN(line, "\t", 2)
-		if len(parts) < 2 {
-			continue
-		}
-		name := parts[0]
-		portMappings := parts[1]
-
-		for _, mapping := range strings.Split(portMappings, ", ") {
-			// "0.0.0.0:3000->3000/tcp" or ":::3000->3000/tcp"
-			hostPort := extractHostPort(mapping)
-			if hostPort > 0 {
-				m[hostPort] = name
+// Name implements ContainerRuntime.
+func (c *DockerClient) Name() string { return runtimeDocker }
+
+// dockerContainer is an entry of GET /containers/json.
+type dockerContainer struct {
+	ID     string            `json:"Id"`
+	Names  []string          `json:"Names"`
+	Image  string            `json:"Image"`
+	State  string            `json:"State"`
+	Labels map[string]string `json:"Labels"`
+	Ports  []struct {
+		IP          string `json:"IP"`
+		PrivatePort int    `json:"PrivatePort"`
+		PublicPort  int    `json:"PublicPort"`
+		Type        string `json:"Type"`
+	} `json:"Ports"`
+	HostConfig struct {
+		NetworkMode string `json:"NetworkMode"`
+	} `json:"HostConfig"`
+	NetworkSettings struct {
+		Networks map[string]struct {
+			IPAddress         string `json:"IPAddress"`
+			GlobalIPv6Address string `json:"GlobalIPv6Address"`
+		} `json:"Networks"`
+	} `json:"NetworkSettings"`
+}
+
+// dockerTop is the response of GET /containers/{id}/top. The libpod API
+// uses the same shape.
+type dockerTop struct {
+	Titles    []string   `json:"Titles"`
+	Processes [][]string `json:"Processes"`
+}
+
+// Containers lists running containers. Host PIDs are filled in for
+// containers on the host network.
+func (c *DockerClient) Containers(ctx context.Context) ([]Container, error) {
+	var raw []dockerContainer
+	if err := c.get(ctx, "/containers/json", &raw); err != nil {
+		return nil, err
+	}
+
+	containers := make([]Container, 0, len(raw))
+	for _, dc := range raw {
+		ct := convertDockerContainer(dc)
+		if ct.HostNetwork {
+			var top dockerTop
+			if err := c.get(ctx, "/containers/"+dc.ID+"/top", &top); err == nil {
+				ct.PIDs = parseTopPIDs(top)
 			}
 		}
+		containers = append(containers, ct)
+	}
+	return containers, nil
+}
+
+func (c *DockerClient) get(ctx context.Context, path string, v any) error {
+	return apiGet(ctx, c.http, c.base+path, "docker api", v)
+}
+
+// ApplyAction runs action on container id. For stop and restart, timeout is
+// how long the daemon waits for the container to exit before killing it.
+func (c *DockerClient) ApplyAction(ctx context.Context, id string, action ContainerAction, timeout time.Duration) error {
+	path := "/containers/" + url.PathEscape(id) + "/" + string(action)
+	if action == ContainerStop || action == ContainerRestart {
+		path += "?t=" + strconv.Itoa(int(timeout.Seconds()))
 	}
-	return m
+	return apiPost(ctx, c.http, c.base+path, "docker api")
 }
 
-// extractHostPort extracts the host port from a mapping like "0.0.0.0:3000->3000/tcp".
-func extractHostPort(mapping string) int {
-	arrow := strings.Index(mapping, "->")
-	if arrow == -1 {
-		return 0
+// apiGet fetches url and decodes its JSON body into v. Errors are prefixed
+// with what.
+func apiGet(ctx context.Context, client *http.Client, url, what string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s: %w", what, err)
+	}
+	defer resp.Body.Close()
+	if err := checkAPIResponse(resp, what); err != nil {
+		return err
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("%s: decode %s: %w", what, req.URL.Path, err)
 	}
-	hostPart := mapping[:arrow] // "0.0.0.0:3000" or ":::3000"
+	return nil
+}
 
-	lastColon := strings.LastIndex(hostPart, ":")
-	if lastColon == -1 {
-		return 0
+// apiPost sends an empty POST to url. 304 Not Modified, returned when the
+// container is already in the requested state, is not an error.
+func apiPost(ctx context.Context, client *http.Client, url, what string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, nil)
+	if err != nil {
+		return err
 	}
-	port, err := strconv.Atoi(hostPart[lastColon+1:])
+	resp, err := client.Do(req)
 	if err != nil {
-		return 0
+		return fmt.Errorf("%s: %w", what, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified {
+		return nil
+	}
+	return checkAPIResponse(resp, what)
+}
+
+// checkAPIResponse turns an error status into an error carrying the
+// daemon's {"message": ...} body. Docker and Podman both use this shape.
+func checkAPIResponse(resp *http.Response, what string) error {
+	if resp.StatusCode < 300 {
+		return nil
+	}
+	var body struct {
+		Message string `json:"message"`
+	}
+	if json.NewDecoder(resp.Body).Decode(&body) == nil && body.Message != "" {
+		return fmt.Errorf("%s: %s", what, body.Message)
+	}
+	return fmt.Errorf("%s: %s", what, resp.Status)
+}
+
+func convertDockerContainer(dc dockerContainer) Container {
+	ct := Container{
+		ID:          dc.ID,
+		Image:       dc.Image,
+		State:       dc.State,
+		Runtime:     runtimeDocker,
+		Project:     dc.Labels["com.docker.compose.project"],
+		Service:     dc.Labels["com.docker.compose.service"],
+		HostNetwork: dc.HostConfig.NetworkMode == "host",
+	}
+	if len(dc.Names) > 0 {
+		ct.Name = strings.TrimPrefix(dc.Names[0], "/")
 	}
-	return port
+	for _, n := range dc.NetworkSettings.Networks {
+		for _, ip := range []string{n.IPAddress, n.GlobalIPv6Address} {
+			if ip != "" {
+				ct.IPs = append(ct.IPs, ip)
+			}
+		}
+	}
+	for _, p := range dc.Ports {
+		if p.PublicPort == 0 {
+			continue // exposed but not published
+		}
+		ct.Ports = append(ct.Ports, ContainerPort{
+			HostIP:        p.IP,
+			HostPort:      p.PublicPort,
+			ContainerPort: p.PrivatePort,
+			Protocol:      p.Type,
+		})
+	}
+	return ct
 }
 
-// enrichDockerInfo fills Container field for ports that match Docker port mappings.
-func enrichDockerInfo(ports []PortInfo) {
-	dm := detectDocker()
-	if dm == nil {
-		return
+// parseTopPIDs extracts host PIDs from a /containers/{id}/top response:
+// the HPID column of Podman if present, else the PID column.
+func parseTopPIDs(top dockerTop) []int {
+	col := -1
+	for i, title := range top.Titles {
+		if title == "HPID" || title == "PID" && col == -1 {
+			col = i
+		}
+	}
+	if col == -1 {
+		return nil
 	}
-	for i := range ports {
-		if name, ok := dm[ports[i].Port]; ok {
-			ports[i].Container = name
+	var pids []int
+	for _, row := range top.Processes {
+		if col >= len(row) {
+			continue
+		}
+		if pid, err := strconv.Atoi(row[col]); err == nil {
+			pids = append(pids, pid)
+		}
+	}
+	return pids
+}
+
+// parseDockerProxyTarget extracts the container address docker-proxy
+// forwards to from its command line:
+//
+//	docker-proxy -proto tcp -host-ip 0.0.0.0 -host-port 8080 -container-ip 172.17.0.2 -container-port 80
+func parseDockerProxyTarget(command string) (ip string, port int, ok bool) {
+	fields := strings.Fields(command)
+	for i := 0; i+1 < len(fields); i++ {
+		switch fields[i] {
+		case "-container-ip":
+			ip = fields[i+1]
+		case "-container-port":
+			port, _ = strconv.Atoi(fields[i+1])
 		}
 	}
+	return ip, port, ip != "" && port != 0
 }
@@ -1,228 +1,254 @@
 package ports
 
 import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
-func TestParseDockerPSBasic(t *testing.T) {
-	output := "my-redis\t0.0.0.0:6379->6379/tcp"
-	m := parseDockerPS(output)
-
-	if len(m) != 1 {
-		t.Fatalf("expected 1 entry, got %d", len(m))
-	}
-	if m[6379] != "my-redis" {
-		t.Errorf("expected my-redis, got %q", m[6379])
-	}
+const mockContainersJSON = `[
+  {
+    "Id": "aaa111",
+    "Names": ["/app-web-1"],
+    "Image": "nginx:1.25",
+    "State": "running",
+    "Labels": {"com.docker.compose.project": "app", "com.docker.compose.service": "web"},
+    "Ports": [
+      {"IP": "0.0.0.0", "PrivatePort": 80, "PublicPort": 8080, "Type": "tcp"},
+      {"IP": "::", "PrivatePort": 80, "PublicPort": 8080, "Type": "tcp"},
+      {"PrivatePort": 443, "Type": "tcp"}
+    ],
+    "HostConfig": {"NetworkMode": "app_default"},
+    "NetworkSettings": {"Networks": {"app_default": {"IPAddress": "172.18.0.2"}}}
+  },
+  {
+    "Id": "bbb222",
+    "Names": ["/metrics"],
+    "Image": "prom/node-exporter",
+    "State": "running",
+    "Labels": {},
+    "Ports": [],
+    "HostConfig": {"NetworkMode": "host"},
+    "NetworkSettings": {"Networks": {"host": {"IPAddress": ""}}}
+  }
+]`
+
+const mockTopJSON = `{
+  "Titles": ["UID", "PID", "PPID", "C", "STIME", "TTY", "TIME", "CMD"],
+  "Processes": [
+    ["nobody", "4242", "4200", "0", "10:00", "?", "00:00:01", "/bin/node_exporter"],
+    ["nobody", "4243", "4242", "0", "10:00", "?", "00:00:00", "sh"]
+  ]
+}`
+
+// newFakeDocker serves handler on a unix socket and returns a client for it.
+func newFakeDocker(t *testing.T, handler http.Handler) *DockerClient {
+	t.Helper()
+	client, err := NewDockerClient(serveUnix(t, handler))
+	if err != nil {
+		t.Fatalf("NewDockerClient: %v", err)
+	}
+	return client
+}
+
+func mockDockerAPI(t *testing.T) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /containers/json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(mockContainersJSON))
+	})
+	mux.HandleFunc("GET /containers/{id}/top", func(w http.ResponseWriter, r *http.Request) {
+		if id := r.PathValue("id"); id != "bbb222" {
+			t.Errorf("top requested for %s, only host-network containers need PIDs", id)
+		}
+		w.Write([]byte(mockTopJSON))
+	})
+	return mux
 }
 
-func TestParseDockerPSMultiplePorts(t *testing.T) {
-	output := "web-app\t0.0.0.0:3000->3000/tcp, 0.0.0.0:3001->3001/tcp"
-	m := parseDockerPS(output)
+func TestDockerClientContainers(t *testing.T) {
+	client := newFakeDocker(t, mockDockerAPI(t))
 
-	if len(m) != 2 {
-		t.Fatalf("expected 2 entries, got %d", len(m))
-	}
-	if m[3000] != "web-app" {
-		t.Errorf("port 3000: expected web-app, got %q", m[3000])
+	containers, err := client.Containers(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
 	}
-	if m[3001] != "web-app" {
-		t.Errorf("port 3001: expected web-app, got %q", m[3001])
+	if len(containers) != 2 {
+		t.Fatalf("expected 2 containers, got %d", len(containers))
 	}
-}
 
-func TestParseDockerPSIPv6(t *testing.T) {
-	output := "my-service\t:::8080->8080/tcp"
-	m := parseDockerPS(output)
-
-	if len(m) != 1 {
-		t.Fatalf("expected 1 entry, got %d", len(m))
+	web := containers[0]
+	if web.ID != "aaa111" || web.Name != "app-web-1" || web.Image != "nginx:1.25" || web.State != "running" {
+		t.Errorf("web: got %+v", web)
 	}
-	if m[8080] != "my-service" {
-		t.Errorf("expected my-service, got %q", m[8080])
+	if web.Project != "app" || web.Service != "web" {
+		t.Errorf("compose labels: got project %q service %q", web.Project, web.Service)
 	}
-}
-
-func TestParseDockerPSMixedIPv4IPv6(t *testing.T) {
-	output := "my-redis\t0.0.0.0:6379->6379/tcp, :::6379->6379/tcp"
-	m := parseDockerPS(output)
-
-	// Same port mapped for both IPv4 and IPv6, should still be one entry
-	if m[6379] != "my-redis" {
-		t.Errorf("expected my-redis, got %q", m[6379])
+	if len(web.IPs) != 1 || web.IPs[0] != "172.18.0.2" {
+		t.Errorf("IPs: got %v", web.IPs)
 	}
-}
-
-func TestParseDockerPSMultipleContainers(t *testing.T) {
-	output := `my-redis	0.0.0.0:6379->6379/tcp
-my-postgres	0.0.0.0:5432->5432/tcp
-web-app	0.0.0.0:3000->3000/tcp, 0.0.0.0:3001->3001/tcp`
-
-	m := parseDockerPS(output)
-
-	if len(m) != 4 {
-		t.Fatalf("expected 4 entries, got %d", len(m))
+	// The unpublished 443 port is dropped
+	if len(web.Ports) != 2 || web.Ports[0] != (ContainerPort{HostIP: "0.0.0.0", HostPort: 8080, ContainerPort: 80, Protocol: "tcp"}) {
+		t.Errorf("ports: got %+v", web.Ports)
 	}
-	if m[6379] != "my-redis" {
-		t.Errorf("port 6379: got %q", m[6379])
+	if web.HostNetwork || web.PIDs != nil {
+		t.Errorf("bridged container should have no PIDs, got %+v", web)
 	}
-	if m[5432] != "my-postgres" {
-		t.Errorf("port 5432: got %q", m[5432])
-	}
-	if m[3000] != "web-app" {
-		t.Errorf("port 3000: got %q", m[3000])
+
+	metrics := containers[1]
+	if !metrics.HostNetwork {
+		t.Error("expected host network")
 	}
-	if m[3001] != "web-app" {
-		t.Errorf("port 3001: got %q", m[3001])
+	if len(metrics.PIDs) != 2 || metrics.PIDs[0] != 4242 || metrics.PIDs[1] != 4243 {
+		t.Errorf("PIDs: got %v", metrics.PIDs)
 	}
 }
 
-func TestParseDockerPSEmptyOutput(t *testing.T) {
-	m := parseDockerPS("")
-	if len(m) != 0 {
-		t.Errorf("expected empty map, got %d entries", len(m))
-	}
-}
+func TestDockerClientErrorMessage(t *testing.T) {
+	client := newFakeDocker(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"message": "daemon is shutting down"}`))
+	}))
 
-func TestParseDockerPSWhitespaceOnly(t *testing.T) {
-	m := parseDockerPS("   \n\t\n   ")
-	if len(m) != 0 {
-		t.Errorf("expected empty map, got %d entries", len(m))
+	_, err := client.Containers(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "daemon is shutting down") {
+		t.Errorf("expected daemon message in error, got %v", err)
 	}
 }
 
-func TestParseDockerPSNoPortMappings(t *testing.T) {
-	output := "my-container\t"
-	m := parseDockerPS(output)
-	if len(m) != 0 {
-		t.Errorf("expected empty map for container with no ports, got %d", len(m))
+func TestDockerClientUnreachable(t *testing.T) {
+	client, err := NewDockerClient("unix://" + filepath.Join(t.TempDir(), "missing.sock"))
+	if err != nil {
+		t.Fatalf("NewDockerClient: %v", err)
 	}
-}
-
-func TestParseDockerPSMalformedLine(t *testing.T) {
-	// Line without tab separator
-	output := "malformed-no-tab"
-	m := parseDockerPS(output)
-	if len(m) != 0 {
-		t.Errorf("expected empty map for malformed line, got %d", len(m))
+	if _, err := client.Containers(context.Background()); err == nil {
+		t.Error("expected error for a missing socket")
 	}
 }
 
-func TestExtractHostPortBasic(t *testing.T) {
+func TestNewDockerClientHosts(t *testing.T) {
 	tests := []struct {
-		mapping string
-		want    int
+		host    string
+		base    string
+		wantErr bool
 	}{
-		{"0.0.0.0:3000->3000/tcp", 3000},
-		{"127.0.0.1:8080->80/tcp", 8080},
-		{":::6379->6379/tcp", 6379},
-		{"[::]:5432->5432/tcp", 5432},
+		{"unix:///var/run/docker.sock", "http://docker", false},
+		{"tcp://127.0.0.1:2375", "http://127.0.0.1:2375", false},
+		{"unix://", "", true},
+		{"tcp://", "", true},
+		{"ssh://user@host", "", true},
+		{"npipe:////./pipe/docker_engine", "", true},
 	}
-
 	for _, tt := range tests {
-		got := extractHostPort(tt.mapping)
-		if got != tt.want {
-			t.Errorf("extractHostPort(%q) = %d, want %d", tt.mapping, got, tt.want)
+		c, err := NewDockerClient(tt.host)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("NewDockerClient(%q): err = %v, wantErr %v", tt.host, err, tt.wantErr)
+			continue
+		}
+		if err == nil && c.base != tt.base {
+			t.Errorf("NewDockerClient(%q): base = %q, want %q", tt.host, c.base, tt.base)
 		}
 	}
 }
 
-func TestExtractHostPortInvalid(t *testing.T) {
-	tests := []struct {
-		mapping string
-		desc    string
-	}{
-		{"3000/tcp", "no arrow"},
-		{"", "empty"},
-		{"->3000/tcp", "no host part"},
-		{"abc->3000/tcp", "no colon in host"},
-		{"0.0.0.0:abc->3000/tcp", "non-numeric port"},
+func TestDockerHostFromEnv(t *testing.T) {
+	t.Setenv("DOCKER_HOST", "tcp://10.0.0.1:2375")
+	if got := dockerHost(); got != "tcp://10.0.0.1:2375" {
+		t.Errorf("expected DOCKER_HOST to win, got %q", got)
 	}
 
-	for _, tt := range tests {
-		got := extractHostPort(tt.mapping)
-		if got != 0 {
-			t.Errorf("extractHostPort(%q) = %d, want 0 (%s)", tt.mapping, got, tt.desc)
-		}
+	t.Setenv("DOCKER_HOST", "")
+	if got := dockerHost(); !strings.HasPrefix(got, "unix://") {
+		t.Errorf("expected a unix socket default, got %q", got)
 	}
 }
 
-func TestExtractHostPortDifferentHostContainerPorts(t *testing.T) {
-	// Host port can differ from container port
-	got := extractHostPort("0.0.0.0:8080->80/tcp")
-	if got != 8080 {
-		t.Errorf("expected host port 8080, got %d", got)
+func TestParseTopPIDs(t *testing.T) {
+	top := dockerTop{
+		Titles:    []string{"UID", "PID", "CMD"},
+		Processes: [][]string{{"root", "10", "a"}, {"root", "x", "b"}, {"root"}},
 	}
-}
-
-func TestExtractHostPortHighPort(t *testing.T) {
-	got := extractHostPort("0.0.0.0:65535->65535/tcp")
-	if got != 65535 {
-		t.Errorf("expected port 65535, got %d", got)
+	pids := parseTopPIDs(top)
+	if len(pids) != 1 || pids[0] != 10 {
+		t.Errorf("expected [10], got %v", pids)
 	}
-}
-
-func TestParseDockerPSInternalPorts(t *testing.T) {
-	// Container with internal-only port (no host mapping)
-	output := "my-container\t3000/tcp"
-	m := parseDockerPS(output)
-
-	// Internal ports without -> should not be included
-	if len(m) != 0 {
-		t.Errorf("internal ports should not be mapped, got %d entries", len(m))
+	if pids := parseTopPIDs(dockerTop{Titles: []string{"CMD"}}); pids != nil {
+		t.Errorf("expected nil without a PID column, got %v", pids)
 	}
 }
 
-func TestDockerPortMapType(t *testing.T) {
-	// Test that dockerPortMap is usable
-	var m dockerPortMap = make(map[int]string)
-	m[3000] = "test-container"
-
-	if m[3000] != "test-container" {
-		t.Error("dockerPortMap should work as map[int]string")
+func TestParseDockerProxyTarget(t *testing.T) {
+	cmd := "/usr/bin/docker-proxy -proto tcp -host-ip 0.0.0.0 -host-port 8080 -container-ip 172.18.0.2 -container-port 80"
+	ip, port, ok := parseDockerProxyTarget(cmd)
+	if !ok || ip != "172.18.0.2" || port != 80 {
+		t.Errorf("got (%q, %d, %v)", ip, port, ok)
 	}
-
-	// Test non-existent port
-	if name, ok := m[9999]; ok {
-		t.Errorf("non-existent port should return false, got %q", name)
+	if _, _, ok := parseDockerProxyTarget("/usr/bin/docker-proxy -proto tcp"); ok {
+		t.Error("expected no target without -container-ip")
 	}
 }
 
-func TestParseDockerPSContainerNamesWithSpecialChars(t *testing.T) {
+func TestDockerClientApplyAction(t *testing.T) {
+	var got []string
+	client := newFakeDocker(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("expected POST, got %s", r.Method)
+		}
+		got = append(got, r.URL.RequestURI())
+		if r.URL.Path == "/containers/gone/stop" {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	ctx := context.Background()
 	tests := []struct {
-		output string
-		name   string
-		port   int
+		id     string
+		action ContainerAction
+		want   string
 	}{
-		{"my-app_web_1\t0.0.0.0:3000->3000/tcp", "my-app_web_1", 3000},
-		{"project.service\t0.0.0.0:8080->8080/tcp", "project.service", 8080},
-		{"container-with-dashes\t0.0.0.0:5000->5000/tcp", "container-with-dashes", 5000},
-	}
-
-	for _, tt := range tests {
-		m := parseDockerPS(tt.output)
-		if m[tt.port] != tt.name {
-			t.Errorf("expected container name %q for port %d, got %q", tt.name, tt.port, m[tt.port])
+		{"aaa111", ContainerStop, "/containers/aaa111/stop?t=15"},
+		{"aaa111", ContainerRestart, "/containers/aaa111/restart?t=15"},
+		{"aaa111", ContainerPause, "/containers/aaa111/pause"},
+		{"aaa111", ContainerUnpause, "/containers/aaa111/unpause"},
+		// 304 Not Modified: already stopped is not an error
+		{"gone", ContainerStop, "/containers/gone/stop?t=15"},
+	}
+	for i, tt := range tests {
+		if err := client.ApplyAction(ctx, tt.id, tt.action, 15*time.Second); err != nil {
+			t.Errorf("%s %s: unexpected error: %v", tt.action, tt.id, err)
+			continue
+		}
+		if got[i] != tt.want {
+			t.Errorf("%s %s: requested %q, want %q", tt.action, tt.id, got[i], tt.want)
 		}
 	}
 }
 
-func TestParseDockerPSUDP(t *testing.T) {
-	// UDP port mappings
-	output := "my-dns\t0.0.0.0:53->53/udp"
-	m := parseDockerPS(output)
-
-	if m[53] != "my-dns" {
-		t.Errorf("expected my-dns for UDP port, got %q", m[53])
+func TestDockerClientApplyActionError(t *testing.T) {
+	client := newFakeDocker(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte(`{"message": "Container aaa111 is not running"}`))
+	}))
+	err := client.ApplyAction(context.Background(), "aaa111", ContainerPause, 0)
+	if err == nil || !strings.Contains(err.Error(), "is not running") {
+		t.Errorf("expected daemon message, got %v", err)
 	}
 }
 
-func TestParseDockerPSMixedProtocols(t *testing.T) {
-	output := "my-service\t0.0.0.0:53->53/tcp, 0.0.0.0:53->53/udp"
-	m := parseDockerPS(output)
+func TestDockerClientApplyActionTimeout(t *testing.T) {
+	release := make(chan struct{})
+	client := newFakeDocker(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer close(release)
 
-	// Same port with different protocols - last one wins
-	if m[53] == "" {
-		t.Error("expected port 53 to be mapped")
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := client.ApplyAction(ctx, "aaa111", ContainerStop, time.Second); err == nil {
+		t.Error("expected the request to time out")
 	}
 }
@@ -0,0 +1,45 @@
+package ports
+
+import "strings"
+
+// Filter selects ports the way the `reap list` flags do. Zero fields match
+// everything.
+type Filter struct {
+	Port      int
+	Name      string // case-insensitive substring of the process name
+	Protocol  string // "tcp", "udp", or empty for both
+	Container string // case-insensitive substring of the container name
+	Query     *Query // optional query expression, see ParseQuery
+}
+
+// Match reports whether p passes the filter.
+func (f Filter) Match(p PortInfo) bool {
+	if f.Port != 0 && p.Port != f.Port {
+		return false
+	}
+	if f.Protocol != "" && p.Protocol != f.Protocol {
+		return false
+	}
+	if f.Name != "" && !strings.Contains(strings.ToLower(p.Process), strings.ToLower(f.Name)) {
+		return false
+	}
+	if f.Container != "" && !strings.Contains(strings.ToLower(p.Container), strings.ToLower(f.Container)) {
+		return false
+	}
+	return f.Query.Match(p)
+}
+
+// Apply returns the entries of results that pass the filter. An empty
+// filter returns results unchanged.
+func (f Filter) Apply(results []PortInfo) []PortInfo {
+	if f == (Filter{}) {
+		return results
+	}
+	var filtered []PortInfo
+	for _, p := range results {
+		if f.Match(p) {
+			filtered = append(filtered, p)
+		}
+	}
+	return filtered
+}
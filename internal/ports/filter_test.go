@@ -0,0 +1,42 @@
+package ports
+
+import "testing"
+
+func TestFilterApply(t *testing.T) {
+	query, err := ParseQuery("port:3000-9000 -proto:udp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := []PortInfo{
+		{Port: 3000, Process: "node", Protocol: "tcp"},
+		{Port: 5353, Process: "mDNSResponder", Protocol: "udp"},
+		{Port: 8080, Process: "Node", Protocol: "tcp", Container: "shop-API-1"},
+	}
+	tests := []struct {
+		filter Filter
+		want   []int
+	}{
+		{Filter{}, []int{3000, 5353, 8080}},
+		{Filter{Port: 5353}, []int{5353}},
+		{Filter{Name: "node"}, []int{3000, 8080}},
+		{Filter{Name: "NODE", Port: 8080}, []int{8080}},
+		{Filter{Protocol: "udp"}, []int{5353}},
+		{Filter{Protocol: "udp", Name: "node"}, nil},
+		{Filter{Container: "api"}, []int{8080}},
+		{Filter{Container: "db"}, nil},
+		{Filter{Query: query}, []int{3000, 8080}},
+		{Filter{Query: query, Name: "node", Port: 3000}, []int{3000}},
+	}
+	for _, tt := range tests {
+		got := tt.filter.Apply(results)
+		if len(got) != len(tt.want) {
+			t.Errorf("%+v: got %d entries, want %v", tt.filter, len(got), tt.want)
+			continue
+		}
+		for i, p := range got {
+			if p.Port != tt.want[i] {
+				t.Errorf("%+v: got port %d at %d, want %d", tt.filter, p.Port, i, tt.want[i])
+			}
+		}
+	}
+}
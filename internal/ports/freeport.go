@@ -0,0 +1,81 @@
+package ports
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+)
+
+// FreePortQuery describes the ports FindFreePorts looks for.
+type FreePortQuery struct {
+	From, To   int            // inclusive range
+	Count      int            // how many ports, at least 1
+	Contiguous bool           // the ports must be consecutive
+	Protocol   string         // "tcp" or "udp"
+	Skip       func(int) bool // ports never to return, e.g. well-known services
+}
+
+// bindPort opens a socket on port on all interfaces. It is a variable so
+// tests can simulate ports in use.
+var bindPort = func(protocol string, port int) (io.Closer, error) {
+	addr := ":" + strconv.Itoa(port)
+	if protocol == "udp" {
+		return net.ListenPacket("udp", addr)
+	}
+	return net.Listen("tcp", addr)
+}
+
+// FindFreePorts returns q.Count ports in the range that no socket in
+// scanned uses and that can really be bound. The test sockets are returned
+// open so the caller can hold the ports; closing them releases the ports.
+func FindFreePorts(q FreePortQuery, scanned []PortInfo) ([]int, []io.Closer, error) {
+	if q.From < 1 || q.To > 65535 || q.From > q.To {
+		return nil, nil, fmt.Errorf("invalid port range %d-%d", q.From, q.To)
+	}
+	if q.Count < 1 {
+		return nil, nil, fmt.Errorf("count must be at least 1")
+	}
+
+	used := make(map[int]bool)
+	for _, p := range scanned {
+		if p.Protocol == q.Protocol {
+			used[p.Port] = true
+		}
+	}
+
+	var found []int
+	var held []io.Closer
+	release := func() {
+		for _, c := range held {
+			c.Close()
+		}
+		found, held = nil, nil
+	}
+	for port := q.From; port <= q.To && len(found) < q.Count; port++ {
+		free := !used[port] && (q.Skip == nil || !q.Skip(port))
+		var sock io.Closer
+		if free {
+			var err error
+			sock, err = bindPort(q.Protocol, port)
+			free = err == nil
+		}
+		if !free {
+			if q.Contiguous {
+				release() // the run is broken, start over after this port
+			}
+			continue
+		}
+		found = append(found, port)
+		held = append(held, sock)
+	}
+	if len(found) < q.Count {
+		release()
+		kind := "free"
+		if q.Contiguous {
+			kind = "contiguous free"
+		}
+		return nil, nil, fmt.Errorf("could not find %d %s %s port(s) in %d-%d", q.Count, kind, q.Protocol, q.From, q.To)
+	}
+	return found, held, nil
+}
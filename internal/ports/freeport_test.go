@@ -0,0 +1,129 @@
+package ports
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type fakeSocket struct {
+	port   int
+	closed map[int]bool
+}
+
+func (s fakeSocket) Close() error {
+	s.closed[s.port] = true
+	return nil
+}
+
+// withBindPort replaces bindPort with a fake where the ports in busy fail to
+// bind. It returns the set of ports whose sockets were closed.
+func withBindPort(t *testing.T, busy ...int) map[int]bool {
+	t.Helper()
+	closed := make(map[int]bool)
+	orig := bindPort
+	bindPort = func(protocol string, port int) (io.Closer, error) {
+		for _, b := range busy {
+			if b == port {
+				return nil, errors.New("address already in use")
+			}
+		}
+		return fakeSocket{port: port, closed: closed}, nil
+	}
+	t.Cleanup(func() { bindPort = orig })
+	return closed
+}
+
+func TestFindFreePorts(t *testing.T) {
+	scanned := []PortInfo{
+		{Port: 3000, Protocol: "tcp"},
+		{Port: 3002, Protocol: "tcp"},
+		{Port: 3003, Protocol: "udp"},
+	}
+	tests := []struct {
+		name string
+		q    FreePortQuery
+		busy []int
+		want []int
+	}{
+		{"first free", FreePortQuery{From: 3000, To: 3010, Count: 1, Protocol: "tcp"}, nil, []int{3001}},
+		{"several", FreePortQuery{From: 3000, To: 3010, Count: 3, Protocol: "tcp"}, nil, []int{3001, 3003, 3004}},
+		{"udp ignores tcp sockets", FreePortQuery{From: 3000, To: 3010, Count: 2, Protocol: "udp"}, nil, []int{3000, 3001}},
+		{"bind failure", FreePortQuery{From: 3000, To: 3010, Count: 1, Protocol: "tcp"}, []int{3001}, []int{3003}},
+		{"contiguous", FreePortQuery{From: 3000, To: 3010, Count: 3, Contiguous: true, Protocol: "tcp"}, []int{3005}, []int{3006, 3007, 3008}},
+		{"skip", FreePortQuery{From: 3000, To: 3010, Count: 2, Protocol: "tcp", Skip: func(p int) bool { return p == 3001 }}, nil, []int{3003, 3004}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			closed := withBindPort(t, tt.busy...)
+			got, held, err := FindFreePorts(tt.q, scanned)
+			if err != nil {
+				t.Fatalf("FindFreePorts: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ports = %v, want %v", got, tt.want)
+			}
+			if len(held) != len(got) {
+				t.Errorf("held %d sockets, want %d", len(held), len(got))
+			}
+			for _, p := range got {
+				if closed[p] {
+					t.Errorf("socket for returned port %d was closed", p)
+				}
+			}
+		})
+	}
+}
+
+func TestFindFreePortsContiguousReleasesBrokenRun(t *testing.T) {
+	closed := withBindPort(t, 3002)
+	q := FreePortQuery{From: 3000, To: 3010, Count: 3, Contiguous: true, Protocol: "tcp"}
+	got, _, err := FindFreePorts(q, nil)
+	if err != nil {
+		t.Fatalf("FindFreePorts: %v", err)
+	}
+	if want := []int{3003, 3004, 3005}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ports = %v, want %v", got, want)
+	}
+	for _, p := range []int{3000, 3001} {
+		if !closed[p] {
+			t.Errorf("socket for port %d in the broken run was not closed", p)
+		}
+	}
+}
+
+func TestFindFreePortsNotEnough(t *testing.T) {
+	closed := withBindPort(t, 3001)
+	q := FreePortQuery{From: 3000, To: 3002, Count: 3, Protocol: "tcp"}
+	got, held, err := FindFreePorts(q, nil)
+	if err == nil {
+		t.Fatalf("FindFreePorts = %v, want an error", got)
+	}
+	if !strings.Contains(err.Error(), "3000-3002") {
+		t.Errorf("error %q does not name the range", err)
+	}
+	if held != nil {
+		t.Errorf("held = %v, want nil", held)
+	}
+	for _, p := range []int{3000, 3002} {
+		if !closed[p] {
+			t.Errorf("socket for port %d was not released", p)
+		}
+	}
+}
+
+func TestFindFreePortsInvalid(t *testing.T) {
+	withBindPort(t)
+	for _, q := range []FreePortQuery{
+		{From: 0, To: 10, Count: 1, Protocol: "tcp"},
+		{From: 10, To: 70000, Count: 1, Protocol: "tcp"},
+		{From: 20, To: 10, Count: 1, Protocol: "tcp"},
+		{From: 10, To: 20, Count: 0, Protocol: "tcp"},
+	} {
+		if _, _, err := FindFreePorts(q, nil); err == nil {
+			t.Errorf("FindFreePorts(%+v) succeeded, want an error", q)
+		}
+	}
+}
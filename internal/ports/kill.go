@@ -0,0 +1,162 @@
+package ports
+
+import (
+	"fmt"
+	"syscall"
+	"time"
+)
+
+// KillStage identifies a step of a graceful kill.
+type KillStage int
+
+const (
+	StageTerm    KillStage = iota // SIGTERM sent, waiting for exit
+	StageKill                     // grace period expired, SIGKILL sent
+	StageRelease                  // process gone, checking who holds the port
+)
+
+// KillProgress is reported while Escalate waits for a process to exit.
+type KillProgress struct {
+	PID     int
+	Port    int
+	Stage   KillStage
+	Elapsed time.Duration
+}
+
+// KillResult is the outcome of Escalate.
+type KillResult struct {
+	PID      int
+	Port     int
+	Protocol string
+	Signal   syscall.Signal // last signal sent
+	Exited   bool           // process is gone
+	Freed    bool           // no process listens on the port any more
+	HeldBy   int            // PID holding the port afterwards, 0 if free or unknown
+	Err      error
+}
+
+// Killer sends SIGTERM, waits for the process to exit and escalates to
+// SIGKILL after a grace period. It polls the PID, which is cheap, and scans
+// once at the end to see who holds the port.
+type Killer struct {
+	scanner Scanner
+	grace   time.Duration
+	poll    time.Duration
+
+	signal func(pid int, sig syscall.Signal) error
+	alive  func(pid int) bool
+}
+
+// NewKiller returns a Killer that uses scanner to check whether ports have
+// been released after the process exits.
+func NewKiller(scanner Scanner, grace time.Duration) *Killer {
+	return &Killer{
+		scanner: scanner,
+		grace:   grace,
+		poll:    200 * time.Millisecond,
+		signal:  SendSignal,
+		alive:   processAlive,
+	}
+}
+
+// Grace returns how long Escalate waits after SIGTERM before SIGKILL.
+func (k *Killer) Grace() time.Duration { return k.grace }
+
+// Escalate terminates target gracefully. progress, if non-nil, is called
+// on every poll. The SIGKILL phase waits at most another grace period.
+func (k *Killer) Escalate(target PortInfo, progress func(KillProgress)) KillResult {
+	res := KillResult{PID: target.PID, Port: target.Port, Protocol: target.Protocol, Signal: syscall.SIGTERM}
+	report := func(stage KillStage, elapsed time.Duration) {
+		if progress != nil {
+			progress(KillProgress{PID: target.PID, Port: target.Port, Stage: stage, Elapsed: elapsed})
+		}
+	}
+
+	if err := k.signal(target.PID, syscall.SIGTERM); err != nil {
+		res.Err = err
+		return res
+	}
+
+	start := time.Now()
+	stage := StageTerm
+	deadline := start.Add(k.grace)
+	for {
+		if !k.alive(target.PID) {
+			res.Exited = true
+			break
+		}
+		if time.Now().After(deadline) {
+			if stage == StageKill {
+				res.Err = fmt.Errorf("PID %d still running after SIGKILL", target.PID)
+				return res
+			}
+			if err := k.signal(target.PID, syscall.SIGKILL); err != nil {
+				if !k.alive(target.PID) {
+					res.Exited = true
+					break
+				}
+				res.Err = err
+				return res
+			}
+			res.Signal = syscall.SIGKILL
+			stage = StageKill
+			deadline = time.Now().Add(k.grace)
+		}
+		report(stage, time.Since(start))
+		time.Sleep(k.poll)
+	}
+
+	// The process is gone and its sockets are closed; one scan tells
+	// whether the port is free or was taken over by a respawn.
+	report(StageRelease, time.Since(start))
+	holder, err := k.portHolder(target)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	if holder == 0 {
+		res.Freed = true
+	}
+	res.HeldBy = holder
+	return res
+}
+
+// portHolder returns the PID listening on target's port and protocol,
+// preferring target.PID if it still appears. Returns 0 if the port is free.
+// Container details are not needed, so the scan skips them.
+func (k *Killer) portHolder(target PortInfo) (int, error) {
+	results, err := scanPorts(k.scanner)
+	if err != nil {
+		return 0, fmt.Errorf("scan failed: %w", err)
+	}
+	holder := 0
+	for _, p := range results {
+		if p.Port != target.Port || (target.Protocol != "" && p.Protocol != target.Protocol) {
+			continue
+		}
+		if p.PID == target.PID {
+			return p.PID, nil
+		}
+		holder = p.PID
+	}
+	return holder, nil
+}
+
+// Describe returns a one-line human-readable summary of the outcome.
+func (r KillResult) Describe() string {
+	if r.Err != nil {
+		return fmt.Sprintf("PID %d: %s", r.PID, r.Err)
+	}
+	how := "exited after SIGTERM"
+	if r.Signal == syscall.SIGKILL {
+		how = "killed with SIGKILL"
+	}
+	switch {
+	case r.Freed:
+		return fmt.Sprintf("PID %d %s, port %d freed", r.PID, how, r.Port)
+	case r.HeldBy != 0 && r.HeldBy != r.PID:
+		return fmt.Sprintf("PID %d %s, but port %d is now held by PID %d", r.PID, how, r.Port, r.HeldBy)
+	default:
+		return fmt.Sprintf("PID %d %s, port %d not yet released", r.PID, how, r.Port)
+	}
+}
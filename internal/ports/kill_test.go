@@ -0,0 +1,228 @@
+package ports
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// fakeProcesses simulates processes that react to signals.
+type fakeProcesses struct {
+	mu      sync.Mutex
+	running map[int]bool
+	ignore  map[int]bool // PIDs that ignore SIGTERM
+	signals []syscall.Signal
+	ports   []PortInfo
+}
+
+func (f *fakeProcesses) signal(pid int, sig syscall.Signal) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.running[pid] {
+		return syscall.ESRCH
+	}
+	f.signals = append(f.signals, sig)
+	if sig == syscall.SIGKILL || !f.ignore[pid] {
+		delete(f.running, pid)
+		var remaining []PortInfo
+		for _, p := range f.ports {
+			if p.PID != pid {
+				remaining = append(remaining, p)
+			}
+		}
+		f.ports = remaining
+	}
+	return nil
+}
+
+func (f *fakeProcesses) alive(pid int) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.running[pid]
+}
+
+func (f *fakeProcesses) Scan() ([]PortInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]PortInfo(nil), f.ports...), nil
+}
+
+func newTestKiller(f *fakeProcesses, scanner Scanner) *Killer {
+	k := NewKiller(scanner, 50*time.Millisecond)
+	k.poll = 5 * time.Millisecond
+	k.signal = f.signal
+	k.alive = f.alive
+	return k
+}
+
+func TestEscalateExitsOnSIGTERM(t *testing.T) {
+	target := PortInfo{Port: 3000, PID: 100, Protocol: "tcp"}
+	f := &fakeProcesses{running: map[int]bool{100: true}, ports: []PortInfo{target}}
+
+	res := newTestKiller(f, f).Escalate(target, nil)
+
+	if res.Err != nil {
+		t.Fatalf("unexpected error: %v", res.Err)
+	}
+	if !res.Exited || !res.Freed {
+		t.Errorf("expected exited and freed, got %+v", res)
+	}
+	if res.Signal != syscall.SIGTERM {
+		t.Errorf("expected SIGTERM only, got %v", res.Signal)
+	}
+	if len(f.signals) != 1 {
+		t.Errorf("expected 1 signal, got %v", f.signals)
+	}
+}
+
+func TestEscalateSendsSIGKILLAfterGrace(t *testing.T) {
+	target := PortInfo{Port: 3000, PID: 100, Protocol: "tcp"}
+	f := &fakeProcesses{
+		running: map[int]bool{100: true},
+		ignore:  map[int]bool{100: true},
+		ports:   []PortInfo{target},
+	}
+
+	var stages []KillStage
+	res := newTestKiller(f, f).Escalate(target, func(p KillProgress) {
+		stages = append(stages, p.Stage)
+	})
+
+	if res.Err != nil {
+		t.Fatalf("unexpected error: %v", res.Err)
+	}
+	if res.Signal != syscall.SIGKILL || !res.Exited || !res.Freed {
+		t.Errorf("expected SIGKILL escalation, got %+v", res)
+	}
+	if len(f.signals) != 2 || f.signals[0] != syscall.SIGTERM || f.signals[1] != syscall.SIGKILL {
+		t.Errorf("expected SIGTERM then SIGKILL, got %v", f.signals)
+	}
+	if len(stages) == 0 || stages[0] != StageTerm {
+		t.Errorf("expected progress to start with StageTerm, got %v", stages)
+	}
+}
+
+func TestEscalateReportsRespawn(t *testing.T) {
+	target := PortInfo{Port: 3000, PID: 100, Protocol: "tcp"}
+	f := &fakeProcesses{
+		running: map[int]bool{100: true, 200: true},
+		ports:   []PortInfo{target, {Port: 3000, PID: 200, Protocol: "tcp"}},
+	}
+
+	res := newTestKiller(f, f).Escalate(target, nil)
+
+	if res.Freed {
+		t.Error("port should not be reported as freed")
+	}
+	if res.HeldBy != 200 {
+		t.Errorf("expected port held by 200, got %d", res.HeldBy)
+	}
+	if !strings.Contains(res.Describe(), "held by PID 200") {
+		t.Errorf("unexpected description %q", res.Describe())
+	}
+}
+
+func TestEscalateScansOnce(t *testing.T) {
+	target := PortInfo{Port: 3000, PID: 100, Protocol: "tcp"}
+	f := &fakeProcesses{running: map[int]bool{100: true}, ignore: map[int]bool{100: true}}
+	s := &splitScanner{}
+
+	res := newTestKiller(f, s).Escalate(target, nil)
+
+	if !res.Exited || res.Signal != syscall.SIGKILL {
+		t.Fatalf("expected SIGKILL escalation, got %+v", res)
+	}
+	// the PID is polled through the grace period; the port is scanned once
+	if s.bare+s.full != 1 {
+		t.Errorf("expected one scan, got %d", s.bare+s.full)
+	}
+}
+
+func TestProcessAliveZombie(t *testing.T) {
+	if _, err := os.Stat("/proc/self/stat"); err != nil {
+		t.Skip("no procfs")
+	}
+	cmd := exec.Command("true")
+	if err := cmd.Start(); err != nil {
+		t.Skipf("cannot start true: %v", err)
+	}
+	defer cmd.Wait()
+	pid := cmd.Process.Pid
+
+	// not waited for, the child stays a zombie after exiting
+	deadline := time.Now().Add(5 * time.Second)
+	for !(procFS{root: "/proc"}).zombie(pid) {
+		if time.Now().After(deadline) {
+			t.Fatal("child did not become a zombie")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if processAlive(pid) {
+		t.Error("a zombie should not count as alive")
+	}
+	if !processAlive(os.Getpid()) {
+		t.Error("the test process is alive")
+	}
+}
+
+func TestEscalateSkipsContainers(t *testing.T) {
+	target := PortInfo{Port: 3000, PID: 100, Protocol: "tcp"}
+	f := &fakeProcesses{running: map[int]bool{100: true}}
+	s := &splitScanner{}
+
+	res := newTestKiller(f, s).Escalate(target, nil)
+
+	if !res.Freed {
+		t.Errorf("expected freed, got %+v", res)
+	}
+	if s.full != 0 || s.bare == 0 {
+		t.Errorf("expected scans without containers, got %d full and %d bare", s.full, s.bare)
+	}
+}
+
+func TestEscalateSignalError(t *testing.T) {
+	target := PortInfo{Port: 3000, PID: 100}
+	f := &fakeProcesses{running: map[int]bool{}}
+
+	res := newTestKiller(f, f).Escalate(target, nil)
+
+	if !errors.Is(res.Err, syscall.ESRCH) {
+		t.Errorf("expected ESRCH, got %v", res.Err)
+	}
+}
+
+func TestEscalateIgnoresOtherProtocol(t *testing.T) {
+	target := PortInfo{Port: 53, PID: 100, Protocol: "udp"}
+	f := &fakeProcesses{
+		running: map[int]bool{100: true, 200: true},
+		ports:   []PortInfo{target, {Port: 53, PID: 200, Protocol: "tcp"}},
+	}
+
+	res := newTestKiller(f, f).Escalate(target, nil)
+
+	if !res.Freed {
+		t.Errorf("udp port should be freed regardless of tcp holder, got %+v", res)
+	}
+}
+
+func TestKillResultDescribe(t *testing.T) {
+	tests := []struct {
+		res  KillResult
+		want string
+	}{
+		{KillResult{PID: 1, Port: 3000, Signal: syscall.SIGTERM, Exited: true, Freed: true}, "PID 1 exited after SIGTERM, port 3000 freed"},
+		{KillResult{PID: 1, Port: 3000, Signal: syscall.SIGKILL, Exited: true, Freed: true}, "PID 1 killed with SIGKILL, port 3000 freed"},
+		{KillResult{PID: 1, Port: 3000, Signal: syscall.SIGTERM, Exited: true, HeldBy: 1}, "PID 1 exited after SIGTERM, port 3000 not yet released"},
+		{KillResult{PID: 1, Err: errors.New("boom")}, "PID 1: boom"},
+	}
+	for _, tt := range tests {
+		if got := tt.res.Describe(); got != tt.want {
+			t.Errorf("Describe() = %q, want %q", got, tt.want)
+		}
+	}
+}
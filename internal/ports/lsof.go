@@ -0,0 +1,146 @@
+package ports
+
+import (
+	"strconv"
+	"strings"
+)
+
+// parseLsofOutput parses lsof tabular output into PortInfo entries.
+// Connected sockets ("local->remote") and TCP sockets not in LISTEN state
+// are skipped, so only listeners and bound UDP sockets remain. Deduplicates
+// by (port, PID, protocol), preferring IPv4 over IPv6.
+func parseLsofOutput(output string) []PortInfo {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) < 2 {
+		return nil
+	}
+
+	type dedupKey struct {
+		port     int
+		pid      int
+		protocol string
+	}
+	seen := make(map[dedupKey]int) // key -> index in result
+	var result []PortInfo
+
+	for _, line := range lines[1:] { // skip header
+		fields := strings.Fields(line)
+		if len(fields) < 9 {
+			continue
+		}
+
+		process := fields[0]
+		pid, err := strconv.Atoi(fields[1])
+		if err != nil {
+			continue
+		}
+		user := fields[2]
+		protocol := strings.ToLower(fields[7]) // TCP -> tcp
+
+		// NAME field is after NODE (index 8). It may be followed by "(LISTEN)".
+		name := fields[8]
+		if strings.Contains(name, "->") {
+			continue
+		}
+		if protocol == "tcp" && (len(fields) < 10 || fields[9] != "(LISTEN)") {
+			continue
+		}
+		addr, portStr := parseNameField(name)
+		port, err := strconv.Atoi(portStr)
+		if err != nil {
+			continue
+		}
+
+		key := dedupKey{port: port, pid: pid, protocol: protocol}
+		isIPv4 := !strings.Contains(addr, ":")
+
+		if idx, exists := seen[key]; exists {
+			// prefer IPv4 over IPv6
+			if isIPv4 && strings.Contains(result[idx].Address, ":") {
+				result[idx].Address = addr
+			}
+			continue
+		}
+
+		seen[key] = len(result)
+		result = append(result, PortInfo{
+			Port:     port,
+			PID:      pid,
+			Process:  process,
+			User:     user,
+			Protocol: protocol,
+			Address:  addr,
+		})
+	}
+
+	return result
+}
+
+// parseNameField splits "addr:port" from lsof NAME column.
+// Handles IPv6 like "[::1]:8080" and IPv4 like "127.0.0.1:8080" or "*:8080".
+func parseNameField(name string) (addr, port string) {
+	// Remove any trailing state info like "(LISTEN)"
+	if idx := strings.Index(name, "("); idx != -1 {
+		name = name[:idx]
+	}
+
+	if strings.HasPrefix(name, "[") {
+		// IPv6: [::1]:8080
+		if closeBracket := strings.LastIndex(name, "]"); closeBracket != -1 {
+			addr = name[:closeBracket+1]
+			if closeBracket+2 < len(name) {
+				port = name[closeBracket+2:] // skip ]:
+			}
+			return addr, port
+		}
+	}
+
+	// IPv4 or *: last colon separates addr:port
+	if lastColon := strings.LastIndex(name, ":"); lastColon != -1 {
+		return name[:lastColon], name[lastColon+1:]
+	}
+	return name, ""
+}
+
+// parseLsofConnections extracts connected TCP sockets from lsof output.
+// NAME looks like "127.0.0.1:3000->127.0.0.1:54321" followed by the
+// state, e.g. "(ESTABLISHED)".
+func parseLsofConnections(output string) []connSocket {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) < 2 {
+		return nil
+	}
+
+	var result []connSocket
+	for _, line := range lines[1:] { // skip header
+		fields := strings.Fields(line)
+		if len(fields) < 10 || strings.ToLower(fields[7]) != "tcp" {
+			continue
+		}
+		local, remote, ok := strings.Cut(fields[8], "->")
+		if !ok {
+			continue
+		}
+		pid, err := strconv.Atoi(fields[1])
+		if err != nil {
+			continue
+		}
+		localAddr, localPortStr := parseNameField(local)
+		remoteAddr, remotePortStr := parseNameField(remote)
+		localPort, err1 := strconv.Atoi(localPortStr)
+		remotePort, err2 := strconv.Atoi(remotePortStr)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		result = append(result, connSocket{
+			localAddr:  localAddr,
+			localPort:  localPort,
+			remoteAddr: remoteAddr,
+			remotePort: remotePort,
+			state:      strings.Trim(fields[9], "()"),
+			pid:        pid,
+			process:    fields[0],
+		})
+	}
+	return result
+}
@@ -0,0 +1,273 @@
+package ports
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const runtimeNerdctl = "nerdctl"
+
+// nerdctlCacheTTL is how long container lists are reused. Every listing
+// runs nerdctl twice, which is too slow to repeat on each refresh.
+const nerdctlCacheTTL = 5 * time.Second
+
+// nerdctl reads containerd containers through the nerdctl CLI, which has
+// no API socket of its own.
+type nerdctl struct {
+	// run executes nerdctl with args and returns its stdout.
+	run func(ctx context.Context, args ...string) ([]byte, error)
+	ttl time.Duration // how long Containers results are reused; 0 disables caching
+
+	mu       sync.Mutex
+	cached   []Container
+	cachedAt time.Time
+}
+
+// newNerdctl returns a runtime backed by the nerdctl binary on PATH.
+func newNerdctl() (*nerdctl, error) {
+	path, err := exec.LookPath("nerdctl")
+	if err != nil {
+		return nil, err
+	}
+	return &nerdctl{run: func(ctx context.Context, args ...string) ([]byte, error) {
+		out, err := exec.CommandContext(ctx, path, args...).Output()
+		if ee, ok := err.(*exec.ExitError); ok && len(ee.Stderr) > 0 {
+			return nil, fmt.Errorf("nerdctl %s: %s", args[0], strings.TrimSpace(string(ee.Stderr)))
+		}
+		return out, err
+	}, ttl: nerdctlCacheTTL}, nil
+}
+
+// Name implements ContainerRuntime.
+func (n *nerdctl) Name() string { return runtimeNerdctl }
+
+// nerdctlPS is a line of `nerdctl ps --format '{{json .}}'`.
+type nerdctlPS struct {
+	ID     string `json:"ID"`
+	Names  string `json:"Names"`
+	Image  string `json:"Image"`
+	Status string `json:"Status"`
+	Ports  string `json:"Ports"`
+	Labels string `json:"Labels"`
+}
+
+// nerdctlInspect is the subset of `nerdctl inspect` output used to find
+// host-network containers and container addresses. nerdctl mirrors the
+// Docker inspect format.
+type nerdctlInspect struct {
+	ID    string `json:"Id"`
+	State struct {
+		Status string `json:"Status"`
+		Pid    int    `json:"Pid"`
+	} `json:"State"`
+	HostConfig struct {
+		NetworkMode string `json:"NetworkMode"`
+	} `json:"HostConfig"`
+	NetworkSettings struct {
+		Networks map[string]struct {
+			IPAddress         string `json:"IPAddress"`
+			GlobalIPv6Address string `json:"GlobalIPv6Address"`
+		} `json:"Networks"`
+	} `json:"NetworkSettings"`
+}
+
+// Containers lists running containers, reusing the last list for ttl.
+// Errors are not cached.
+func (n *nerdctl) Containers(ctx context.Context) ([]Container, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if !n.cachedAt.IsZero() && time.Since(n.cachedAt) < n.ttl {
+		return slices.Clone(n.cached), nil
+	}
+	containers, err := n.containers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	n.cached, n.cachedAt = containers, time.Now()
+	return slices.Clone(containers), nil
+}
+
+// containers runs nerdctl. Details from `nerdctl inspect` are best effort:
+// without them containers are matched by published port only.
+func (n *nerdctl) containers(ctx context.Context) ([]Container, error) {
+	out, err := n.run(ctx, "ps", "--no-trunc", "--format", "{{json .}}")
+	if err != nil {
+		return nil, err
+	}
+	containers, err := parseNerdctlPS(string(out))
+	if err != nil || len(containers) == 0 {
+		return containers, err
+	}
+
+	args := []string{"inspect"}
+	for _, c := range containers {
+		args = append(args, c.ID)
+	}
+	if out, err := n.run(ctx, args...); err == nil {
+		var details []nerdctlInspect
+		if json.Unmarshal(out, &details) == nil {
+			applyNerdctlInspect(containers, details)
+		}
+	}
+	return containers, nil
+}
+
+// ApplyAction implements ContainerRuntime.
+func (n *nerdctl) ApplyAction(ctx context.Context, id string, action ContainerAction, timeout time.Duration) error {
+	args := []string{string(action)}
+	if action == ContainerStop || action == ContainerRestart {
+		args = append(args, "-t", strconv.Itoa(int(timeout.Seconds())))
+	}
+	_, err := n.run(ctx, append(args, id)...)
+	n.mu.Lock()
+	n.cachedAt = time.Time{}
+	n.mu.Unlock()
+	return err
+}
+
+// parseNerdctlPS parses JSON lines from nerdctl ps.
+func parseNerdctlPS(output string) ([]Container, error) {
+	var containers []Container
+	sc := bufio.NewScanner(strings.NewReader(output))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		var ps nerdctlPS
+		if err := json.Unmarshal([]byte(line), &ps); err != nil {
+			return nil, fmt.Errorf("nerdctl ps: %w", err)
+		}
+		labels := parseNerdctlLabels(ps.Labels)
+		containers = append(containers, Container{
+			ID:      ps.ID,
+			Name:    ps.Names,
+			Image:   ps.Image,
+			State:   nerdctlState(ps.Status),
+			Runtime: runtimeNerdctl,
+			Project: labels["com.docker.compose.project"],
+			Service: labels["com.docker.compose.service"],
+			Ports:   parseNerdctlPorts(ps.Ports),
+		})
+	}
+	return containers, sc.Err()
+}
+
+// applyNerdctlInspect fills state, addresses and host PIDs from inspect
+// output into the matching containers.
+func applyNerdctlInspect(containers []Container, details []nerdctlInspect) {
+	byID := make(map[string]nerdctlInspect, len(details))
+	for _, d := range details {
+		byID[d.ID] = d
+	}
+	for i := range containers {
+		c := &containers[i]
+		d, ok := byID[c.ID]
+		if !ok {
+			continue
+		}
+		if d.State.Status != "" {
+			c.State = d.State.Status
+		}
+		c.HostNetwork = d.HostConfig.NetworkMode == "host"
+		if c.HostNetwork && d.State.Pid > 0 {
+			c.PIDs = []int{d.State.Pid}
+		}
+		for _, net := range d.NetworkSettings.Networks {
+			for _, ip := range []string{net.IPAddress, net.GlobalIPv6Address} {
+				if ip != "" {
+					c.IPs = append(c.IPs, ip)
+				}
+			}
+		}
+	}
+}
+
+// nerdctlState maps a ps status such as "Up 3 minutes" or "Up (Paused)" to
+// an API-style state.
+func nerdctlState(status string) string {
+	switch {
+	case strings.Contains(status, "Paused"):
+		return "paused"
+	case strings.HasPrefix(status, "Up"):
+		return "running"
+	case status == "":
+		return ""
+	}
+	state, _, _ := strings.Cut(status, " ")
+	return strings.ToLower(state)
+}
+
+// parseNerdctlPorts parses published ports such as
+// "0.0.0.0:8080->80/tcp, :::8443->443/tcp". Ranges are expanded.
+func parseNerdctlPorts(s string) []ContainerPort {
+	var ports []ContainerPort
+	for _, spec := range strings.Split(s, ",") {
+		host, target, ok := strings.Cut(strings.TrimSpace(spec), "->")
+		if !ok {
+			continue
+		}
+		i := strings.LastIndex(host, ":")
+		if i < 0 {
+			continue
+		}
+		hostIP := strings.Trim(host[:i], "[]")
+		target, proto, _ := strings.Cut(target, "/")
+		if proto == "" {
+			proto = "tcp"
+		}
+		hostFrom, hostTo, ok := parsePortRange(host[i+1:])
+		if !ok {
+			continue
+		}
+		ctFrom, _, ok := parsePortRange(target)
+		if !ok {
+			continue
+		}
+		for p := hostFrom; p <= hostTo; p++ {
+			ports = append(ports, ContainerPort{
+				HostIP:        hostIP,
+				HostPort:      p,
+				ContainerPort: ctFrom + p - hostFrom,
+				Protocol:      proto,
+			})
+		}
+	}
+	return ports
+}
+
+// parsePortRange parses "8080" or "8080-8081".
+func parsePortRange(s string) (from, to int, ok bool) {
+	lo, hi, isRange := strings.Cut(s, "-")
+	from, err := strconv.Atoi(lo)
+	if err != nil {
+		return 0, 0, false
+	}
+	if !isRange {
+		return from, from, true
+	}
+	to, err = strconv.Atoi(hi)
+	if err != nil || to < from {
+		return 0, 0, false
+	}
+	return from, to, true
+}
+
+// parseNerdctlLabels parses "k1=v1,k2=v2".
+func parseNerdctlLabels(s string) map[string]string {
+	labels := make(map[string]string)
+	for _, kv := range strings.Split(s, ",") {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			labels[k] = v
+		}
+	}
+	return labels
+}
@@ -0,0 +1,215 @@
+package ports
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+const mockNerdctlPS = `{"Command":"\"nginx -g 'daemon off;'\"","CreatedAt":"2024-05-01 10:00:00 +0000 UTC","ID":"fff666aaa","Image":"docker.io/library/nginx:alpine","Labels":"com.docker.compose.project=site,com.docker.compose.service=web","Names":"site-web-1","Ports":"0.0.0.0:8080->80/tcp, 0.0.0.0:9000-9001->9000-9001/udp","Status":"Up"}
+{"Command":"\"/bin/node_exporter\"","ID":"ggg777bbb","Image":"quay.io/prometheus/node-exporter","Labels":"","Names":"exporter","Ports":"","Status":"Up (Paused)"}
+`
+
+const mockNerdctlInspect = `[
+  {"Id": "fff666aaa", "State": {"Status": "running", "Pid": 5100},
+   "HostConfig": {"NetworkMode": "bridge"},
+   "NetworkSettings": {"Networks": {"unknown-eth0": {"IPAddress": "10.4.0.12"}}}},
+  {"Id": "ggg777bbb", "State": {"Status": "paused", "Pid": 5200},
+   "HostConfig": {"NetworkMode": "host"},
+   "NetworkSettings": {"Networks": {}}}
+]`
+
+// fakeNerdctl returns a nerdctl runtime whose commands are answered by
+// outputs, keyed by subcommand, and recorded in calls.
+func fakeNerdctl(outputs map[string]string, calls *[]string) *nerdctl {
+	return &nerdctl{run: func(_ context.Context, args ...string) ([]byte, error) {
+		*calls = append(*calls, strings.Join(args, " "))
+		out, ok := outputs[args[0]]
+		if !ok {
+			return nil, errors.New("unexpected command")
+		}
+		return []byte(out), nil
+	}}
+}
+
+func TestNerdctlContainers(t *testing.T) {
+	var calls []string
+	n := fakeNerdctl(map[string]string{"ps": mockNerdctlPS, "inspect": mockNerdctlInspect}, &calls)
+
+	containers, err := n.Containers(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(containers) != 2 {
+		t.Fatalf("expected 2 containers, got %d", len(containers))
+	}
+	if len(calls) != 2 || calls[1] != "inspect fff666aaa ggg777bbb" {
+		t.Errorf("unexpected calls %q", calls)
+	}
+
+	web := containers[0]
+	if web.Name != "site-web-1" || web.Runtime != "nerdctl" || web.State != "running" {
+		t.Errorf("web: got %+v", web)
+	}
+	if web.Project != "site" || web.Service != "web" {
+		t.Errorf("compose labels: got project %q service %q", web.Project, web.Service)
+	}
+	if len(web.IPs) != 1 || web.IPs[0] != "10.4.0.12" || web.HostNetwork || web.PIDs != nil {
+		t.Errorf("web network: got %+v", web)
+	}
+	if len(web.Ports) != 3 {
+		t.Errorf("ports: got %+v", web.Ports)
+	}
+
+	exporter := containers[1]
+	if !exporter.HostNetwork || len(exporter.PIDs) != 1 || exporter.PIDs[0] != 5200 || exporter.State != "paused" {
+		t.Errorf("exporter: got %+v", exporter)
+	}
+}
+
+func TestNerdctlContainersWithoutInspect(t *testing.T) {
+	var calls []string
+	n := fakeNerdctl(map[string]string{"ps": mockNerdctlPS}, &calls)
+
+	containers, err := n.Containers(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(containers) != 2 || containers[1].State != "paused" || containers[1].HostNetwork {
+		t.Errorf("expected ps data only, got %+v", containers)
+	}
+}
+
+func TestNerdctlCache(t *testing.T) {
+	var calls []string
+	n := fakeNerdctl(map[string]string{"ps": mockNerdctlPS, "inspect": mockNerdctlInspect}, &calls)
+	n.ttl = time.Minute
+
+	first, err := n.Containers(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	first[0].Name = "changed by the caller"
+	second, _ := n.Containers(context.Background())
+	if len(calls) != 2 {
+		t.Errorf("expected the second list to come from the cache, ran %q", calls)
+	}
+	if second[0].Name != "site-web-1" {
+		t.Errorf("callers must not change the cache, got %q", second[0].Name)
+	}
+
+	// expired
+	n.cachedAt = time.Now().Add(-time.Minute)
+	n.Containers(context.Background())
+	if len(calls) != 4 {
+		t.Errorf("expected an expired cache to run nerdctl again, ran %q", calls)
+	}
+
+	// an action changes the containers
+	n.ApplyAction(context.Background(), "fff666aaa", ContainerStop, time.Second)
+	calls = nil
+	n.Containers(context.Background())
+	if len(calls) != 2 {
+		t.Errorf("expected an action to clear the cache, ran %q", calls)
+	}
+}
+
+func TestNerdctlCacheEmptyAndErrors(t *testing.T) {
+	var calls []string
+	n := fakeNerdctl(map[string]string{"ps": ""}, &calls)
+	n.ttl = time.Minute
+	n.Containers(context.Background())
+	n.Containers(context.Background())
+	if len(calls) != 1 {
+		t.Errorf("expected no containers to be cached too, ran %q", calls)
+	}
+
+	calls = nil
+	n = fakeNerdctl(map[string]string{}, &calls)
+	n.ttl = time.Minute
+	n.Containers(context.Background())
+	n.Containers(context.Background())
+	if len(calls) != 2 {
+		t.Errorf("expected errors not to be cached, ran %q", calls)
+	}
+}
+
+func TestNerdctlApplyAction(t *testing.T) {
+	tests := []struct {
+		action ContainerAction
+		want   string
+	}{
+		{ContainerStop, "stop -t 4 fff666aaa"},
+		{ContainerRestart, "restart -t 4 fff666aaa"},
+		{ContainerPause, "pause fff666aaa"},
+		{ContainerUnpause, "unpause fff666aaa"},
+	}
+	for _, tt := range tests {
+		var calls []string
+		n := fakeNerdctl(map[string]string{string(tt.action): ""}, &calls)
+		if err := n.ApplyAction(context.Background(), "fff666aaa", tt.action, 4*time.Second); err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.action, err)
+		}
+		if len(calls) != 1 || calls[0] != tt.want {
+			t.Errorf("%s: ran %q, want %q", tt.action, calls, tt.want)
+		}
+	}
+}
+
+func TestParseNerdctlPorts(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []ContainerPort
+	}{
+		{"", nil},
+		{"0.0.0.0:8080->80/tcp", []ContainerPort{{HostIP: "0.0.0.0", HostPort: 8080, ContainerPort: 80, Protocol: "tcp"}}},
+		{":::53->53/udp", []ContainerPort{{HostIP: "::", HostPort: 53, ContainerPort: 53, Protocol: "udp"}}},
+		{"[::1]:8443->443", []ContainerPort{{HostIP: "::1", HostPort: 8443, ContainerPort: 443, Protocol: "tcp"}}},
+		{"127.0.0.1:7000-7001->8000-8001/tcp", []ContainerPort{
+			{HostIP: "127.0.0.1", HostPort: 7000, ContainerPort: 8000, Protocol: "tcp"},
+			{HostIP: "127.0.0.1", HostPort: 7001, ContainerPort: 8001, Protocol: "tcp"},
+		}},
+		{"80/tcp", nil},                    // exposed, not published
+		{"0.0.0.0:x->80/tcp", nil},         // malformed
+		{"0.0.0.0:9001-9000->80/tcp", nil}, // inverted range
+	}
+	for _, tt := range tests {
+		got := parseNerdctlPorts(tt.input)
+		if len(got) != len(tt.want) {
+			t.Errorf("parseNerdctlPorts(%q) = %+v, want %+v", tt.input, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("parseNerdctlPorts(%q)[%d] = %+v, want %+v", tt.input, i, got[i], tt.want[i])
+			}
+		}
+	}
+}
+
+func TestNerdctlState(t *testing.T) {
+	tests := []struct {
+		status string
+		want   string
+	}{
+		{"Up", "running"},
+		{"Up 3 minutes", "running"},
+		{"Up (Paused)", "paused"},
+		{"Created", "created"},
+		{"Exited (0) 2 hours ago", "exited"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := nerdctlState(tt.status); got != tt.want {
+			t.Errorf("nerdctlState(%q) = %q, want %q", tt.status, got, tt.want)
+		}
+	}
+}
+
+func TestParseNerdctlPSInvalid(t *testing.T) {
+	if _, err := parseNerdctlPS("not json\n"); err == nil {
+		t.Error("expected error for malformed output")
+	}
+}
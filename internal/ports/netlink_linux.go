@@ -0,0 +1,185 @@
+//go:build linux
+
+package ports
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"syscall"
+)
+
+// NETLINK_SOCK_DIAG constants from linux/sock_diag.h and linux/inet_diag.h.
+// They are not exported by the syscall package.
+const (
+	netlinkSockDiag  = 4
+	sockDiagByFamily = 20
+
+	inetDiagReqLen = 56 // sizeof(struct inet_diag_req_v2)
+	inetDiagMsgLen = 72 // sizeof(struct inet_diag_msg)
+
+	tcpStateListen = 10
+	tcpStateClose  = 7 // bound, unconnected UDP sockets
+)
+
+// tcpConnStates selects connected TCP states reported as connections.
+// TIME_WAIT and CLOSE sockets are excluded because they have no owner.
+const tcpConnStates = 1<<1 | 1<<2 | 1<<3 | 1<<4 | 1<<5 | 1<<8 | 1<<9 | 1<<11
+
+// netlinkListeningSockets enumerates listening TCP, bound UDP and connected
+// TCP sockets through NETLINK_SOCK_DIAG. It returns an error when netlink is unavailable
+// (old kernels, seccomp-restricted containers) so callers can fall back
+// to parsing /proc/net.
+func netlinkListeningSockets() ([]procSocket, error) {
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_DGRAM|syscall.SOCK_CLOEXEC, netlinkSockDiag)
+	if err != nil {
+		return nil, fmt.Errorf("netlink socket: %w", err)
+	}
+	defer syscall.Close(fd)
+
+	if err := syscall.Bind(fd, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK}); err != nil {
+		return nil, fmt.Errorf("netlink bind: %w", err)
+	}
+
+	queries := []struct {
+		family   uint8
+		protocol uint8
+		states   uint32
+	}{
+		{syscall.AF_INET, syscall.IPPROTO_TCP, 1<<tcpStateListen | tcpConnStates},
+		{syscall.AF_INET6, syscall.IPPROTO_TCP, 1<<tcpStateListen | tcpConnStates},
+		{syscall.AF_INET, syscall.IPPROTO_UDP, 1 << tcpStateClose},
+		{syscall.AF_INET6, syscall.IPPROTO_UDP, 1 << tcpStateClose},
+	}
+
+	var result []procSocket
+	for i, q := range queries {
+		sockets, err := sockDiagDump(fd, uint32(i+1), q.family, q.protocol, q.states)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, sockets...)
+	}
+	return result, nil
+}
+
+// sockDiagDump sends a SOCK_DIAG_BY_FAMILY dump request and collects
+// every inet_diag_msg until NLMSG_DONE.
+func sockDiagDump(fd int, seq uint32, family, protocol uint8, states uint32) ([]procSocket, error) {
+	req := newSockDiagRequest(seq, family, protocol, states)
+	if err := syscall.Sendto(fd, req, 0, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK}); err != nil {
+		return nil, fmt.Errorf("netlink send: %w", err)
+	}
+
+	var result []procSocket
+	buf := make([]byte, os.Getpagesize()*8)
+	for {
+		n, _, err := syscall.Recvfrom(fd, buf, 0)
+		if err != nil {
+			return nil, fmt.Errorf("netlink recv: %w", err)
+		}
+		msgs, err := syscall.ParseNetlinkMessage(buf[:n])
+		if err != nil {
+			return nil, fmt.Errorf("netlink parse: %w", err)
+		}
+		for _, m := range msgs {
+			if m.Header.Seq != seq {
+				continue
+			}
+			switch m.Header.Type {
+			case syscall.NLMSG_DONE:
+				return result, nil
+			case syscall.NLMSG_ERROR:
+				return nil, parseNetlinkError(m.Data)
+			}
+			if s, ok := parseInetDiagMsg(m.Data); ok && s.port != 0 {
+				s.protocol = protocolName(protocol)
+				if s.protocol == "udp" {
+					s.state = stateUnconn
+				}
+				result = append(result, s)
+			}
+		}
+	}
+}
+
+// newSockDiagRequest builds an nlmsghdr followed by inet_diag_req_v2.
+func newSockDiagRequest(seq uint32, family, protocol uint8, states uint32) []byte {
+	req := make([]byte, syscall.NLMSG_HDRLEN+inetDiagReqLen)
+	binary.NativeEndian.PutUint32(req[0:4], uint32(len(req)))
+	binary.NativeEndian.PutUint16(req[4:6], sockDiagByFamily)
+	binary.NativeEndian.PutUint16(req[6:8], syscall.NLM_F_REQUEST|syscall.NLM_F_DUMP)
+	binary.NativeEndian.PutUint32(req[8:12], seq)
+
+	body := req[syscall.NLMSG_HDRLEN:]
+	body[0] = family
+	body[1] = protocol
+	binary.NativeEndian.PutUint32(body[4:8], states)
+	return req
+}
+
+// parseInetDiagMsg decodes the fields we need from struct inet_diag_msg:
+//
+//	u8 family, state, timer, retrans
+//	inet_diag_sockid { be16 sport, dport; be32 src[4], dst[4]; u32 if, cookie[2] }
+//	u32 expires, rqueue, wqueue, uid, inode
+func parseInetDiagMsg(data []byte) (procSocket, bool) {
+	if len(data) < inetDiagMsgLen {
+		return procSocket{}, false
+	}
+	family := data[0]
+	port := int(binary.BigEndian.Uint16(data[4:6]))
+	remotePort := int(binary.BigEndian.Uint16(data[6:8]))
+
+	var ip, remote net.IP
+	switch family {
+	case syscall.AF_INET:
+		ip = net.IP(append([]byte(nil), data[8:12]...))
+		remote = net.IP(append([]byte(nil), data[24:28]...))
+	case syscall.AF_INET6:
+		ip = net.IP(append([]byte(nil), data[8:24]...))
+		remote = net.IP(append([]byte(nil), data[24:40]...))
+	default:
+		return procSocket{}, false
+	}
+
+	inode := binary.NativeEndian.Uint32(data[68:72])
+	if inode == 0 {
+		return procSocket{}, false
+	}
+
+	s := procSocket{
+		inode: strconv.FormatUint(uint64(inode), 10),
+		addr:  formatSocketAddr(ip),
+		port:  port,
+		state: tcpStateNames[int(data[1])],
+	}
+	if s.state != "" && s.state != stateListen {
+		s.remoteAddr = formatSocketAddr(remote)
+		s.remotePort = remotePort
+	}
+	return s, true
+}
+
+// parseNetlinkError extracts the errno from an NLMSG_ERROR payload.
+func parseNetlinkError(data []byte) error {
+	if len(data) < 4 {
+		return fmt.Errorf("netlink error: truncated message")
+	}
+	errno := -int32(binary.NativeEndian.Uint32(data[0:4]))
+	if errno == 0 {
+		return nil
+	}
+	return fmt.Errorf("netlink error: %w", syscall.Errno(errno))
+}
+
+func protocolName(protocol uint8) string {
+	switch protocol {
+	case syscall.IPPROTO_UDP:
+		return "udp"
+	default:
+		return "tcp"
+	}
+}
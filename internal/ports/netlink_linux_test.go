@@ -0,0 +1,139 @@
+//go:build linux
+
+package ports
+
+import (
+	"encoding/binary"
+	"syscall"
+	"testing"
+)
+
+func mockInetDiagMsg(family uint8, port uint16, addr []byte, inode uint32) []byte {
+	data := make([]byte, inetDiagMsgLen)
+	data[0] = family
+	data[1] = tcpStateListen
+	binary.BigEndian.PutUint16(data[4:6], port)
+	copy(data[8:24], addr)
+	binary.NativeEndian.PutUint32(data[68:72], inode)
+	return data
+}
+
+func TestParseInetDiagMsgIPv4(t *testing.T) {
+	data := mockInetDiagMsg(syscall.AF_INET, 5432, []byte{127, 0, 0, 1}, 22222)
+	s, ok := parseInetDiagMsg(data)
+	if !ok {
+		t.Fatal("expected message to parse")
+	}
+	if s.addr != "127.0.0.1" || s.port != 5432 || s.inode != "22222" {
+		t.Errorf("got %+v", s)
+	}
+}
+
+func TestParseInetDiagMsgWildcard(t *testing.T) {
+	s, ok := parseInetDiagMsg(mockInetDiagMsg(syscall.AF_INET, 3000, []byte{0, 0, 0, 0}, 1))
+	if !ok || s.addr != "*" {
+		t.Errorf("expected '*', got %+v", s)
+	}
+
+	s, ok = parseInetDiagMsg(mockInetDiagMsg(syscall.AF_INET6, 3000, make([]byte, 16), 2))
+	if !ok || s.addr != "[::]" {
+		t.Errorf("expected '[::]', got %+v", s)
+	}
+}
+
+func TestParseInetDiagMsgIPv6(t *testing.T) {
+	loopback := make([]byte, 16)
+	loopback[15] = 1
+	s, ok := parseInetDiagMsg(mockInetDiagMsg(syscall.AF_INET6, 8080, loopback, 55555))
+	if !ok {
+		t.Fatal("expected message to parse")
+	}
+	if s.addr != "[::1]" || s.port != 8080 {
+		t.Errorf("got %+v", s)
+	}
+}
+
+func TestParseInetDiagMsgInvalid(t *testing.T) {
+	if _, ok := parseInetDiagMsg(make([]byte, 10)); ok {
+		t.Error("expected truncated message to be rejected")
+	}
+	if _, ok := parseInetDiagMsg(mockInetDiagMsg(syscall.AF_UNIX, 1, nil, 1)); ok {
+		t.Error("expected unknown family to be rejected")
+	}
+	if _, ok := parseInetDiagMsg(mockInetDiagMsg(syscall.AF_INET, 1, []byte{1, 2, 3, 4}, 0)); ok {
+		t.Error("expected zero inode to be rejected")
+	}
+}
+
+func TestNewSockDiagRequest(t *testing.T) {
+	req := newSockDiagRequest(7, syscall.AF_INET6, syscall.IPPROTO_TCP, 1<<tcpStateListen)
+
+	if len(req) != syscall.NLMSG_HDRLEN+inetDiagReqLen {
+		t.Fatalf("unexpected length %d", len(req))
+	}
+	if got := binary.NativeEndian.Uint32(req[0:4]); got != uint32(len(req)) {
+		t.Errorf("nlmsg_len: got %d", got)
+	}
+	if got := binary.NativeEndian.Uint16(req[4:6]); got != sockDiagByFamily {
+		t.Errorf("nlmsg_type: got %d", got)
+	}
+	if got := binary.NativeEndian.Uint16(req[6:8]); got != syscall.NLM_F_REQUEST|syscall.NLM_F_DUMP {
+		t.Errorf("nlmsg_flags: got %#x", got)
+	}
+	if got := binary.NativeEndian.Uint32(req[8:12]); got != 7 {
+		t.Errorf("nlmsg_seq: got %d", got)
+	}
+	body := req[syscall.NLMSG_HDRLEN:]
+	if body[0] != syscall.AF_INET6 || body[1] != syscall.IPPROTO_TCP {
+		t.Errorf("family/protocol: got %d/%d", body[0], body[1])
+	}
+	if got := binary.NativeEndian.Uint32(body[4:8]); got != 1<<tcpStateListen {
+		t.Errorf("states: got %#x", got)
+	}
+}
+
+func TestParseNetlinkError(t *testing.T) {
+	data := make([]byte, 4)
+	errno := -int32(syscall.EPERM)
+	binary.NativeEndian.PutUint32(data, uint32(errno))
+	if err := parseNetlinkError(data); err == nil {
+		t.Error("expected EPERM error")
+	}
+
+	if err := parseNetlinkError(make([]byte, 4)); err != nil {
+		t.Errorf("expected nil for errno 0, got %v", err)
+	}
+	if err := parseNetlinkError(nil); err == nil {
+		t.Error("expected error for truncated payload")
+	}
+}
+
+func TestNewScannerWithBackendLinux(t *testing.T) {
+	for _, backend := range []string{"", BackendNetlink, BackendProcfs} {
+		s, err := NewScannerWithBackend(backend)
+		if err != nil {
+			t.Errorf("backend %q: unexpected error %v", backend, err)
+		}
+		if s == nil {
+			t.Errorf("backend %q: expected scanner", backend)
+		}
+	}
+}
+
+func TestParseInetDiagMsgConnection(t *testing.T) {
+	data := mockInetDiagMsg(syscall.AF_INET, 3000, []byte{127, 0, 0, 1}, 33333)
+	data[1] = 1 // ESTABLISHED
+	binary.BigEndian.PutUint16(data[6:8], 54321)
+	copy(data[24:28], []byte{10, 0, 0, 5})
+
+	s, ok := parseInetDiagMsg(data)
+	if !ok {
+		t.Fatal("expected message to parse")
+	}
+	if s.listening() || s.state != "ESTABLISHED" {
+		t.Errorf("expected ESTABLISHED connection, got %+v", s)
+	}
+	if s.remoteAddr != "10.0.0.5" || s.remotePort != 54321 {
+		t.Errorf("remote: got %s:%d", s.remoteAddr, s.remotePort)
+	}
+}
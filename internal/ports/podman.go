@@ -0,0 +1,199 @@
+package ports
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const runtimePodman = "podman"
+
+// podmanAPI prefixes libpod endpoints. Podman 4 and later serve this
+// version; it is the oldest one with the fields used here.
+const podmanAPI = "/v4.0.0/libpod"
+
+// PodmanClient talks to the Podman libpod REST API.
+type PodmanClient struct {
+	http *http.Client
+	base string // URL prefix; the host part is ignored for unix sockets
+
+	mu      sync.Mutex
+	hostNet map[string]bool // by container ID; a container's network mode never changes
+}
+
+// podmanHost returns CONTAINER_HOST, or the rootless socket of the current
+// user, or the rootful socket, whichever exists first. It returns "" when
+// Podman is not running.
+func podmanHost() string {
+	if host := os.Getenv("CONTAINER_HOST"); host != "" {
+		return host
+	}
+	var paths []string
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		paths = append(paths, filepath.Join(dir, "podman", "podman.sock"))
+	}
+	paths = append(paths, "/run/podman/podman.sock")
+	return firstSocket(paths...)
+}
+
+// NewPodmanClient returns a client for host, which uses CONTAINER_HOST
+// syntax: unix:///run/podman/podman.sock or tcp://127.0.0.1:8080. SSH
+// connections are not supported.
+func NewPodmanClient(host string) (*PodmanClient, error) {
+	client, base, err := newSocketClient(host, runtimePodman)
+	if err != nil {
+		return nil, fmt.Errorf("podman: %w", err)
+	}
+	return &PodmanClient{http: client, base: base + podmanAPI}, nil
+}
+
+// Name implements ContainerRuntime.
+func (c *PodmanClient) Name() string { return runtimePodman }
+
+// podmanContainer is an entry of GET /libpod/containers/json.
+type podmanContainer struct {
+	ID     string            `json:"Id"`
+	Names  []string          `json:"Names"`
+	Image  string            `json:"Image"`
+	State  string            `json:"State"`
+	Labels map[string]string `json:"Labels"`
+	Pid    int               `json:"Pid"`
+	Ports  []struct {
+		HostIP        string `json:"host_ip"`
+		ContainerPort int    `json:"container_port"`
+		HostPort      int    `json:"host_port"`
+		Range         int    `json:"range"`
+		Protocol      string `json:"protocol"`
+	} `json:"Ports"`
+	Networks []string `json:"Networks"`
+}
+
+// podmanInspect is the subset of GET /libpod/containers/{id}/json used to
+// tell host-network containers from rootless ones.
+type podmanInspect struct {
+	HostConfig struct {
+		NetworkMode string `json:"NetworkMode"`
+	} `json:"HostConfig"`
+}
+
+// Containers lists running containers. Host PIDs are filled in for
+// containers on the host network.
+func (c *PodmanClient) Containers(ctx context.Context) ([]Container, error) {
+	var raw []podmanContainer
+	if err := c.get(ctx, "/containers/json", &raw); err != nil {
+		return nil, err
+	}
+
+	containers := make([]Container, 0, len(raw))
+	for _, pc := range raw {
+		ct := convertPodmanContainer(pc, c.hostNetwork(ctx, pc))
+		if ct.HostNetwork {
+			var top dockerTop
+			// Podman's PID column is in the container's PID namespace;
+			// the hpid descriptor gives host PIDs.
+			if err := c.get(ctx, "/containers/"+pc.ID+"/top?ps_args=hpid", &top); err == nil {
+				ct.PIDs = append(ct.PIDs, parseTopPIDs(top)...)
+			}
+		}
+		containers = append(containers, ct)
+	}
+
+	// forget containers that are gone
+	c.mu.Lock()
+	for id := range c.hostNet {
+		if !slices.ContainsFunc(raw, func(pc podmanContainer) bool { return pc.ID == id }) {
+			delete(c.hostNet, id)
+		}
+	}
+	c.mu.Unlock()
+	return containers, nil
+}
+
+// hostNetwork reports whether pc shares the host's network. The list
+// shows no networks for host-network containers, but neither for rootless
+// slirp4netns or pasta ones, so those are inspected once.
+func (c *PodmanClient) hostNetwork(ctx context.Context, pc podmanContainer) bool {
+	if len(pc.Networks) > 0 {
+		return false
+	}
+	c.mu.Lock()
+	host, ok := c.hostNet[pc.ID]
+	c.mu.Unlock()
+	if ok {
+		return host
+	}
+	var info podmanInspect
+	if err := c.get(ctx, "/containers/"+url.PathEscape(pc.ID)+"/json", &info); err != nil {
+		return false
+	}
+	host = info.HostConfig.NetworkMode == "host"
+	c.mu.Lock()
+	if c.hostNet == nil {
+		c.hostNet = make(map[string]bool)
+	}
+	c.hostNet[pc.ID] = host
+	c.mu.Unlock()
+	return host
+}
+
+func (c *PodmanClient) get(ctx context.Context, path string, v any) error {
+	return apiGet(ctx, c.http, c.base+path, "podman api", v)
+}
+
+// ApplyAction runs action on container id. libpod names the stop timeout
+// parameter "timeout" and the restart one "t".
+func (c *PodmanClient) ApplyAction(ctx context.Context, id string, action ContainerAction, timeout time.Duration) error {
+	path := "/containers/" + url.PathEscape(id) + "/" + string(action)
+	secs := strconv.Itoa(int(timeout.Seconds()))
+	switch action {
+	case ContainerStop:
+		path += "?timeout=" + secs
+	case ContainerRestart:
+		path += "?t=" + secs
+	}
+	return apiPost(ctx, c.http, c.base+path, "podman api")
+}
+
+func convertPodmanContainer(pc podmanContainer, hostNetwork bool) Container {
+	ct := Container{
+		ID:          pc.ID,
+		Image:       pc.Image,
+		State:       pc.State,
+		Runtime:     runtimePodman,
+		Project:     pc.Labels["com.docker.compose.project"],
+		Service:     pc.Labels["com.docker.compose.service"],
+		HostNetwork: hostNetwork,
+	}
+	if ct.Project == "" {
+		ct.Project = pc.Labels["io.podman.compose.project"]
+		ct.Service = pc.Labels["io.podman.compose.service"]
+	}
+	if len(pc.Names) > 0 {
+		ct.Name = pc.Names[0]
+	}
+	if ct.HostNetwork && pc.Pid > 0 {
+		ct.PIDs = []int{pc.Pid}
+	}
+	for _, p := range pc.Ports {
+		if p.HostPort == 0 {
+			continue
+		}
+		// A published range is reported once with its length.
+		for i := range max(p.Range, 1) {
+			ct.Ports = append(ct.Ports, ContainerPort{
+				HostIP:        p.HostIP,
+				HostPort:      p.HostPort + i,
+				ContainerPort: p.ContainerPort + i,
+				Protocol:      p.Protocol,
+			})
+		}
+	}
+	return ct
+}
@@ -0,0 +1,226 @@
+package ports
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+const mockPodmanContainersJSON = `[
+  {
+    "Id": "ddd444",
+    "Names": ["db"],
+    "Image": "docker.io/library/postgres:16",
+    "State": "running",
+    "Labels": {"io.podman.compose.project": "shop", "io.podman.compose.service": "db"},
+    "Pid": 3100,
+    "Ports": [
+      {"host_ip": "", "container_port": 5432, "host_port": 15432, "range": 1, "protocol": "tcp"},
+      {"host_ip": "127.0.0.1", "container_port": 9000, "host_port": 9000, "range": 3, "protocol": "udp"},
+      {"host_ip": "", "container_port": 8080, "host_port": 0, "range": 1, "protocol": "tcp"}
+    ],
+    "Networks": ["podman"]
+  },
+  {
+    "Id": "eee555",
+    "Names": ["exporter"],
+    "Image": "quay.io/prometheus/node-exporter",
+    "State": "paused",
+    "Labels": null,
+    "Pid": 3200,
+    "Ports": null,
+    "Networks": []
+  },
+  {
+    "Id": "fff666",
+    "Names": ["web"],
+    "Image": "docker.io/library/nginx",
+    "State": "running",
+    "Labels": null,
+    "Pid": 3300,
+    "Ports": [
+      {"host_ip": "", "container_port": 80, "host_port": 8080, "range": 1, "protocol": "tcp"}
+    ],
+    "Networks": []
+  }
+]`
+
+// mockPodmanNetworkModes is HostConfig.NetworkMode by container ID. Rootless
+// containers list no networks either, so only inspect tells them apart.
+var mockPodmanNetworkModes = map[string]string{
+	"eee555": "host",
+	"fff666": "slirp4netns",
+}
+
+const mockPodmanTopJSON = `{
+  "Titles": ["HPID", "PID"],
+  "Processes": [["3200", "1"], ["3201", "7"]]
+}`
+
+// newFakePodman serves handler on a unix socket and returns a client for it.
+func newFakePodman(t *testing.T, handler http.Handler) *PodmanClient {
+	t.Helper()
+	client, err := NewPodmanClient(serveUnix(t, handler))
+	if err != nil {
+		t.Fatalf("NewPodmanClient: %v", err)
+	}
+	return client
+}
+
+func TestPodmanClientContainers(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v4.0.0/libpod/containers/json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(mockPodmanContainersJSON))
+	})
+	inspected := make(map[string]int)
+	mux.HandleFunc("GET /v4.0.0/libpod/containers/{id}/json", func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		inspected[id]++
+		mode, ok := mockPodmanNetworkModes[id]
+		if !ok {
+			t.Errorf("inspect requested for %s, which lists its networks", id)
+		}
+		fmt.Fprintf(w, `{"HostConfig": {"NetworkMode": %q}}`, mode)
+	})
+	mux.HandleFunc("GET /v4.0.0/libpod/containers/{id}/top", func(w http.ResponseWriter, r *http.Request) {
+		if id := r.PathValue("id"); id != "eee555" {
+			t.Errorf("top requested for %s, only host-network containers need PIDs", id)
+		}
+		if got := r.URL.Query().Get("ps_args"); got != "hpid" {
+			t.Errorf("expected host PIDs to be requested, got ps_args=%q", got)
+		}
+		w.Write([]byte(mockPodmanTopJSON))
+	})
+	client := newFakePodman(t, mux)
+
+	containers, err := client.Containers(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(containers) != 3 {
+		t.Fatalf("expected 3 containers, got %d", len(containers))
+	}
+
+	db := containers[0]
+	if db.Name != "db" || db.Runtime != "podman" || db.HostNetwork || db.PIDs != nil {
+		t.Errorf("db: got %+v", db)
+	}
+	if db.Project != "shop" || db.Service != "db" {
+		t.Errorf("podman-compose labels: got project %q service %q", db.Project, db.Service)
+	}
+	// one tcp port, a udp range of three, the unpublished 8080 is dropped
+	if len(db.Ports) != 4 {
+		t.Fatalf("ports: got %+v", db.Ports)
+	}
+	if db.Ports[0] != (ContainerPort{HostPort: 15432, ContainerPort: 5432, Protocol: "tcp"}) {
+		t.Errorf("ports[0]: got %+v", db.Ports[0])
+	}
+	if db.Ports[3] != (ContainerPort{HostIP: "127.0.0.1", HostPort: 9002, ContainerPort: 9002, Protocol: "udp"}) {
+		t.Errorf("ports[3]: got %+v", db.Ports[3])
+	}
+
+	exporter := containers[1]
+	if !exporter.HostNetwork || exporter.State != "paused" {
+		t.Errorf("exporter: got %+v", exporter)
+	}
+	want := []int{3200, 3200, 3201}
+	if len(exporter.PIDs) != len(want) {
+		t.Fatalf("PIDs: got %v, want %v", exporter.PIDs, want)
+	}
+	for i := range want {
+		if exporter.PIDs[i] != want[i] {
+			t.Errorf("PIDs: got %v, want %v", exporter.PIDs, want)
+		}
+	}
+
+	// a rootless container publishes through rootlessport; its own PID is
+	// not on the host network
+	web := containers[2]
+	if web.HostNetwork || web.PIDs != nil || len(web.Ports) != 1 {
+		t.Errorf("web: got %+v", web)
+	}
+
+	// the network mode is looked up once per container
+	if _, err := client.Containers(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for id, n := range inspected {
+		if n != 1 {
+			t.Errorf("%s inspected %d times, want 1", id, n)
+		}
+	}
+}
+
+func TestPodmanClientErrorMessage(t *testing.T) {
+	client := newFakePodman(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"cause": "x", "message": "database is locked"}`))
+	}))
+	_, err := client.Containers(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "podman api: database is locked") {
+		t.Errorf("expected podman message in error, got %v", err)
+	}
+}
+
+func TestPodmanClientApplyAction(t *testing.T) {
+	var got []string
+	client := newFakePodman(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("expected POST, got %s", r.Method)
+		}
+		got = append(got, r.URL.RequestURI())
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := []struct {
+		action ContainerAction
+		want   string
+	}{
+		{ContainerStop, "/v4.0.0/libpod/containers/ddd444/stop?timeout=3"},
+		{ContainerRestart, "/v4.0.0/libpod/containers/ddd444/restart?t=3"},
+		{ContainerPause, "/v4.0.0/libpod/containers/ddd444/pause"},
+		{ContainerUnpause, "/v4.0.0/libpod/containers/ddd444/unpause"},
+	}
+	for i, tt := range tests {
+		if err := client.ApplyAction(context.Background(), "ddd444", tt.action, 3*time.Second); err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.action, err)
+			continue
+		}
+		if got[i] != tt.want {
+			t.Errorf("%s: requested %q, want %q", tt.action, got[i], tt.want)
+		}
+	}
+}
+
+func TestPodmanHost(t *testing.T) {
+	t.Setenv("CONTAINER_HOST", "tcp://10.0.0.1:8080")
+	if got := podmanHost(); got != "tcp://10.0.0.1:8080" {
+		t.Errorf("expected CONTAINER_HOST to win, got %q", got)
+	}
+
+	t.Setenv("CONTAINER_HOST", "")
+	dir := t.TempDir()
+	t.Setenv("XDG_RUNTIME_DIR", dir)
+	if got := podmanHost(); got != "" && !strings.HasPrefix(got, "unix:///run/") {
+		t.Errorf("expected no rootless socket, got %q", got)
+	}
+}
+
+func TestNewPodmanClientHosts(t *testing.T) {
+	if _, err := NewPodmanClient(""); err == nil {
+		t.Error("expected error for an empty host")
+	}
+	if _, err := NewPodmanClient("ssh://core@localhost:22/run/podman/podman.sock"); err == nil {
+		t.Error("expected error for ssh connections")
+	}
+	c, err := NewPodmanClient("unix:///run/podman/podman.sock")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.base != "http://podman/v4.0.0/libpod" {
+		t.Errorf("base = %q", c.base)
+	}
+}
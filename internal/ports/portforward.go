@@ -0,0 +1,146 @@
+package ports
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// PortForward describes a `kubectl port-forward` process.
+type PortForward struct {
+	Namespace  string `json:"namespace"` // "default" unless -n or --namespace was given
+	Kind       string `json:"kind"`      // pod, service, deployment, replicaset or statefulset
+	Name       string `json:"name"`
+	Context    string `json:"context,omitempty"`     // kubeconfig context, empty for the current one
+	RemotePort int    `json:"remote_port,omitempty"` // port on the resource this local port forwards to, 0 if unknown
+}
+
+// Target returns the forwarded resource as "kind/name".
+func (f *PortForward) Target() string {
+	return f.Kind + "/" + f.Name
+}
+
+// Label returns "namespace/kind/name", shown in the container column.
+func (f *PortForward) Label() string {
+	return f.Namespace + "/" + f.Target()
+}
+
+// kubectlValueFlags are kubectl flags that take a separate value. Other
+// flags are assumed to be booleans or written as --flag=value.
+var kubectlValueFlags = map[string]bool{
+	"-n": true, "--namespace": true, "--context": true,
+	"--address": true, "--pod-running-timeout": true,
+	"--kubeconfig": true, "--cluster": true, "--user": true,
+	"-s": true, "--server": true, "--token": true,
+	"--as": true, "--as-group": true, "--as-uid": true,
+	"--request-timeout": true, "--cache-dir": true,
+	"--certificate-authority": true, "--client-certificate": true,
+	"--client-key": true, "--tls-server-name": true,
+	"-v": true, "--v": true,
+}
+
+// kubeKinds maps resource names and aliases accepted by port-forward to
+// their canonical kind.
+var kubeKinds = map[string]string{
+	"po": "pod", "pod": "pod", "pods": "pod",
+	"svc": "service", "service": "service", "services": "service",
+	"deploy": "deployment", "deployment": "deployment", "deployments": "deployment",
+	"rs": "replicaset", "replicaset": "replicaset", "replicasets": "replicaset",
+	"sts": "statefulset", "statefulset": "statefulset", "statefulsets": "statefulset",
+}
+
+// parsePortForward parses a kubectl command line such as
+//
+//	kubectl --context dev port-forward -n web svc/api 8080:80
+//
+// localPort selects the matching port mapping. It reports false for
+// commands that are not kubectl port-forward.
+func parsePortForward(command string, localPort int) (*PortForward, bool) {
+	fields := strings.Fields(command)
+	if len(fields) == 0 || !strings.HasPrefix(filepath.Base(fields[0]), "kubectl") {
+		return nil, false
+	}
+
+	f := &PortForward{Namespace: "default"}
+	var positional []string
+	forward := false
+	for i := 1; i < len(fields); i++ {
+		arg := fields[i]
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			if !forward {
+				if arg != "port-forward" {
+					return nil, false // another subcommand
+				}
+				forward = true
+				continue
+			}
+			positional = append(positional, arg)
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(arg, "=")
+		if !hasValue && kubectlValueFlags[name] && i+1 < len(fields) {
+			i++
+			value = fields[i]
+		}
+		switch {
+		case name == "-n" || name == "--namespace":
+			f.Namespace = value
+		case strings.HasPrefix(name, "-n") && !strings.HasPrefix(name, "--"):
+			f.Namespace = name[2:] // -nweb
+		case name == "--context":
+			f.Context = value
+		}
+	}
+	if !forward || len(positional) == 0 {
+		return nil, false
+	}
+
+	kind, name, ok := strings.Cut(positional[0], "/")
+	if !ok {
+		kind, name = "pod", positional[0]
+	}
+	if f.Kind = kubeKinds[strings.ToLower(kind)]; f.Kind == "" {
+		f.Kind = strings.ToLower(kind)
+	}
+	f.Name = name
+	f.RemotePort = forwardedPort(positional[1:], localPort)
+	return f, true
+}
+
+// forwardedPort finds the remote port of the mapping for localPort among
+// specs such as "8080:80", "9090" or ":5432". A spec without a local port
+// gets a random one, so it is used when it is the only mapping.
+func forwardedPort(specs []string, localPort int) int {
+	for _, spec := range specs {
+		local, remote, ok := strings.Cut(spec, ":")
+		if !ok {
+			remote = local
+		}
+		if l, err := strconv.Atoi(local); err == nil && l == localPort {
+			port, _ := strconv.Atoi(remote)
+			return port
+		}
+	}
+	if len(specs) == 1 && strings.HasPrefix(specs[0], ":") {
+		port, _ := strconv.Atoi(specs[0][1:])
+		return port
+	}
+	return 0
+}
+
+// detectPortForwards labels ports held by kubectl port-forward with the
+// resource they forward to. Ports already attributed to a container are
+// left alone.
+func detectPortForwards(ports []PortInfo) {
+	for i := range ports {
+		p := &ports[i]
+		if p.Container != "" {
+			continue
+		}
+		if f, ok := parsePortForward(p.Command, p.Port); ok {
+			p.PortForward = f
+			p.Container = f.Label()
+		}
+	}
+}
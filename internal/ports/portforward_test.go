@@ -0,0 +1,81 @@
+package ports
+
+import "testing"
+
+func TestParsePortForward(t *testing.T) {
+	tests := []struct {
+		command string
+		port    int
+		want    *PortForward
+	}{
+		{
+			"kubectl port-forward svc/api 8080:80", 8080,
+			&PortForward{Namespace: "default", Kind: "service", Name: "api", RemotePort: 80},
+		},
+		{
+			"/usr/local/bin/kubectl port-forward -n shop pod/db-0 5432", 5432,
+			&PortForward{Namespace: "shop", Kind: "pod", Name: "db-0", RemotePort: 5432},
+		},
+		{
+			// global flags before the subcommand, a bare pod name
+			"kubectl --context=kind-dev --kubeconfig /tmp/kc port-forward --namespace monitoring grafana-7d9 3000:3000 9090:9090", 9090,
+			&PortForward{Namespace: "monitoring", Kind: "pod", Name: "grafana-7d9", Context: "kind-dev", RemotePort: 9090},
+		},
+		{
+			"kubectl port-forward --address 0.0.0.0 --namespace=web deploy/frontend :443", 41234,
+			&PortForward{Namespace: "web", Kind: "deployment", Name: "frontend", RemotePort: 443},
+		},
+		{
+			"kubectl -nops port-forward sts/redis 6379 6380", 7000,
+			&PortForward{Namespace: "ops", Kind: "statefulset", Name: "redis"},
+		},
+		{
+			"kubectl port-forward services/api 8080:http", 8080,
+			&PortForward{Namespace: "default", Kind: "service", Name: "api"},
+		},
+		{"kubectl get pods", 8080, nil},
+		{"kubectl proxy --port 8001", 8001, nil},
+		{"kubectl port-forward", 8080, nil},
+		{"node port-forward.js", 8080, nil},
+		{"", 8080, nil},
+	}
+	for _, tt := range tests {
+		got, ok := parsePortForward(tt.command, tt.port)
+		if ok != (tt.want != nil) {
+			t.Errorf("parsePortForward(%q): ok = %v", tt.command, ok)
+			continue
+		}
+		if ok && *got != *tt.want {
+			t.Errorf("parsePortForward(%q) = %+v, want %+v", tt.command, *got, *tt.want)
+		}
+	}
+}
+
+func TestPortForwardLabel(t *testing.T) {
+	f := PortForward{Namespace: "shop", Kind: "service", Name: "api"}
+	if got := f.Target(); got != "service/api" {
+		t.Errorf("Target() = %q", got)
+	}
+	if got := f.Label(); got != "shop/service/api" {
+		t.Errorf("Label() = %q", got)
+	}
+}
+
+func TestDetectPortForwards(t *testing.T) {
+	ports := []PortInfo{
+		{Port: 8080, Process: "kubectl", Command: "kubectl port-forward -n shop svc/api 8080:80"},
+		{Port: 9000, Process: "kubectl", Command: "kubectl port-forward svc/x 9000", Container: "already"},
+		{Port: 3000, Process: "node", Command: "node server.js"},
+	}
+	detectPortForwards(ports)
+
+	if ports[0].PortForward == nil || ports[0].Container != "shop/service/api" {
+		t.Errorf("expected port-forward, got %+v", ports[0])
+	}
+	if ports[1].PortForward != nil || ports[1].Container != "already" {
+		t.Errorf("expected container attribution to be kept, got %+v", ports[1])
+	}
+	if ports[2].PortForward != nil || ports[2].Container != "" {
+		t.Errorf("expected no port-forward, got %+v", ports[2])
+	}
+}
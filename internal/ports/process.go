@@ -5,10 +5,12 @@ import (
 	"os/exec"
 	"strconv"
 	"strings"
+	"time"
 )
 
-// enrichProcessInfo enriches PortInfo entries with uptime, memory, and full
-// command by making a single ps call for all PIDs.
+// enrichProcessInfo enriches PortInfo entries with uptime, memory, CPU and
+// full command by making a single ps call for all PIDs. ps reports CPU as a
+// decaying average, and has no thread, FD or IO counts.
 func enrichProcessInfo(ports []PortInfo) {
 	if len(ports) == 0 {
 		return
@@ -23,7 +25,8 @@ func enrichProcessInfo(ports []PortInfo) {
 		}
 	}
 
-	out, err := exec.Command("ps", "-o", "pid=,ppid=,etime=,rss=,command=", "-p", strings.Join(pidArgs, ",")).Output()
+	now := time.Now()
+	out, err := exec.Command("ps", "-o", "pid=,ppid=,etime=,rss=,%cpu=,command=", "-p", strings.Join(pidArgs, ",")).Output()
 	if err != nil {
 		return
 	}
@@ -32,6 +35,7 @@ func enrichProcessInfo(ports []PortInfo) {
 		ppid    int
 		etime   string
 		rss     int64 // KB
+		cpu     float64
 		command string
 	}
 	info := make(map[int]psInfo)
@@ -42,7 +46,7 @@ func enrichProcessInfo(ports []PortInfo) {
 			continue
 		}
 		fields := strings.Fields(line)
-		if len(fields) < 5 {
+		if len(fields) < 6 {
 			continue
 		}
 		pid, err := strconv.Atoi(fields[0])
@@ -51,11 +55,14 @@ func enrichProcessInfo(ports []PortInfo) {
 		}
 		ppid, _ := strconv.Atoi(fields[1])
 		rss, _ := strconv.ParseInt(fields[3], 10, 64)
-		cmd := strings.Join(fields[4:], " ")
+		// some locales print a decimal comma
+		cpu, _ := strconv.ParseFloat(strings.Replace(fields[4], ",", ".", 1), 64)
+		cmd := strings.Join(fields[5:], " ")
 		info[pid] = psInfo{
 			ppid:    ppid,
 			etime:   fields[2],
 			rss:     rss,
+			cpu:     cpu,
 			command: cmd,
 		}
 	}
@@ -64,7 +71,11 @@ func enrichProcessInfo(ports []PortInfo) {
 		if ps, ok := info[ports[i].PID]; ok {
 			ports[i].PPID = ps.ppid
 			ports[i].Uptime = formatElapsed(ps.etime)
+			ports[i].UptimeSeconds = elapsedSeconds(ps.etime)
+			ports[i].StartTime = now.Add(-time.Duration(ports[i].UptimeSeconds) * time.Second).Truncate(time.Second)
 			ports[i].Memory = formatMemory(ps.rss)
+			ports[i].RSS = ps.rss * 1024
+			ports[i].CPUPercent = ps.cpu
 			ports[i].Command = ps.command
 		}
 	}
@@ -150,8 +161,18 @@ func LookupProcess(pid int) (name string, command string) {
 // formatElapsed converts ps etime format to human-readable.
 // Formats: DD-HH:MM:SS, HH:MM:SS, MM:SS, SS
 func formatElapsed(etime string) string {
+	return formatDuration(parseElapsed(etime))
+}
+
+// elapsedSeconds converts ps etime format to seconds.
+func elapsedSeconds(etime string) int64 {
+	days, hours, minutes, seconds := parseElapsed(etime)
+	return int64(days)*86400 + int64(hours)*3600 + int64(minutes)*60 + int64(seconds)
+}
+
+// parseElapsed splits ps etime format into its parts.
+func parseElapsed(etime string) (days, hours, minutes, seconds int) {
 	etime = strings.TrimSpace(etime)
-	var days, hours, minutes, seconds int
 
 	// Check for days: DD-...
 	if idx := strings.Index(etime, "-"); idx != -1 {
@@ -171,7 +192,23 @@ func formatElapsed(etime string) string {
 	case 1:
 		seconds, _ = strconv.Atoi(parts[0])
 	}
+	return days, hours, minutes, seconds
+}
 
+// formatUptime converts a number of elapsed seconds to the same
+// human-readable format as formatElapsed.
+func formatUptime(secs int64) string {
+	if secs < 0 {
+		secs = 0
+	}
+	days := int(secs / 86400)
+	hours := int(secs % 86400 / 3600)
+	minutes := int(secs % 3600 / 60)
+	seconds := int(secs % 60)
+	return formatDuration(days, hours, minutes, seconds)
+}
+
+func formatDuration(days, hours, minutes, seconds int) string {
 	if days > 0 {
 		return fmt.Sprintf("%dd %dh", days, hours)
 	}
@@ -198,3 +235,12 @@ func formatMemory(kb int64) string {
 	}
 	return fmt.Sprintf("%d KB", kb)
 }
+
+// FormatBytes converts bytes to human-readable format in the units of the
+// MEMORY column.
+func FormatBytes(n int64) string {
+	if n < 1024 {
+		return fmt.Sprintf("%d B", n)
+	}
+	return formatMemory(n / 1024)
+}
@@ -111,6 +111,25 @@ n/var/lib/postgresql
 	}
 }
 
+func TestElapsedSeconds(t *testing.T) {
+	tests := []struct {
+		input string
+		want  int64
+	}{
+		{"5", 5},
+		{"01:30", 90},
+		{"02:00:01", 7201},
+		{"3-04:05:06", 3*86400 + 4*3600 + 5*60 + 6},
+		{" 12:00 ", 720},
+	}
+
+	for _, tt := range tests {
+		if got := elapsedSeconds(tt.input); got != tt.want {
+			t.Errorf("elapsedSeconds(%q) = %d, want %d", tt.input, got, tt.want)
+		}
+	}
+}
+
 func TestFormatElapsedSeconds(t *testing.T) {
 	tests := []struct {
 		input string
@@ -212,6 +231,24 @@ func TestFormatMemoryZero(t *testing.T) {
 	}
 }
 
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{4096, "4 KB"},
+		{5 << 20, "5.0 MB"},
+		{3 << 30, "3.0 GB"},
+	}
+	for _, tt := range tests {
+		if got := FormatBytes(tt.n); got != tt.want {
+			t.Errorf("FormatBytes(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
+
 func TestFormatMemoryKB(t *testing.T) {
 	tests := []struct {
 		kb   int64
@@ -0,0 +1,616 @@
+package ports
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// clockTicks is USER_HZ, the unit of start times in /proc/<pid>/stat.
+// It is 100 on every mainstream Linux architecture.
+const clockTicks = 100
+
+// udpBound is the state code of bound, unconnected UDP sockets in
+// /proc/net/udp (TCP_CLOSE).
+const udpBound = "07"
+
+// Normalized socket states. stateUnconn follows ss naming for bound UDP.
+const (
+	stateListen = "LISTEN"
+	stateUnconn = "UNCONN"
+)
+
+// tcpStateNames maps kernel TCP state numbers (include/net/tcp_states.h)
+// to names. TIME_WAIT and CLOSE are absent: those sockets have no owner.
+var tcpStateNames = map[int]string{
+	1:  "ESTABLISHED",
+	2:  "SYN_SENT",
+	3:  "SYN_RECV",
+	4:  "FIN_WAIT1",
+	5:  "FIN_WAIT2",
+	8:  "CLOSE_WAIT",
+	9:  "LAST_ACK",
+	10: stateListen,
+	11: "CLOSING",
+}
+
+// procFS reads sockets and process details from a procfs mount.
+// root is normally "/proc"; tests point it at a fake directory tree.
+type procFS struct {
+	root string
+	cpu  *cpuTracker // nil reports CPU usage averaged since process start
+}
+
+// procSocket is a listening TCP socket, a bound UDP socket, or a
+// connected TCP socket.
+type procSocket struct {
+	inode      string
+	addr       string
+	port       int
+	protocol   string
+	state      string
+	remoteAddr string
+	remotePort int
+}
+
+func (s procSocket) listening() bool {
+	return s.state == stateListen || s.state == stateUnconn
+}
+
+func (fs procFS) path(elem ...string) string {
+	return filepath.Join(append([]string{fs.root}, elem...)...)
+}
+
+// scan returns all listening TCP and bound UDP sockets that can be mapped
+// to a process.
+// Sockets owned by processes we can't inspect (other users without root)
+// are skipped, matching lsof behaviour.
+func (fs procFS) scan() ([]PortInfo, error) {
+	sockets, err := fs.listeningSockets()
+	if err != nil {
+		return nil, err
+	}
+	return fs.ownedPorts(sockets), nil
+}
+
+// ownedPorts maps sockets, from any source, to their owning processes and
+// attaches connected sockets to the listeners they belong to.
+func (fs procFS) ownedPorts(sockets []procSocket) []PortInfo {
+	if len(sockets) == 0 {
+		return nil
+	}
+	owners := fs.socketOwners()
+	ports := buildPortInfos(sockets, owners)
+	attachConnections(ports, fs.connSockets(sockets, owners))
+	return ports
+}
+
+// connSockets converts connected sockets to connSocket, resolving owner
+// names from /proc/<pid>/stat. Each PID is read once.
+func (fs procFS) connSockets(sockets []procSocket, owners map[string][]int) []connSocket {
+	names := make(map[int]string)
+	var conns []connSocket
+	for _, s := range sockets {
+		if s.listening() {
+			continue
+		}
+		c := connSocket{
+			localAddr:  s.addr,
+			localPort:  s.port,
+			remoteAddr: s.remoteAddr,
+			remotePort: s.remotePort,
+			state:      s.state,
+		}
+		if pids := owners[s.inode]; len(pids) > 0 {
+			c.pid = pids[0]
+			name, ok := names[c.pid]
+			if !ok {
+				name = fs.processName(c.pid)
+				names[c.pid] = name
+			}
+			c.process = name
+		}
+		conns = append(conns, c)
+	}
+	return conns
+}
+
+// processName returns the comm of a PID, or "" if it can't be read.
+func (fs procFS) processName(pid int) string {
+	data, err := os.ReadFile(fs.path(strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return ""
+	}
+	st, err := parseProcStat(string(data))
+	if err != nil {
+		return ""
+	}
+	return st.comm
+}
+
+// zombie reports whether pid has exited but not been reaped by its parent.
+// Its sockets are closed, but signal 0 still succeeds. Without procfs the
+// answer is false.
+func (fs procFS) zombie(pid int) bool {
+	data, err := os.ReadFile(fs.path(strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return false
+	}
+	st, err := parseProcStat(string(data))
+	return err == nil && st.state == "Z"
+}
+
+// listeningSockets reads /proc/net/{tcp,tcp6,udp,udp6}, including
+// connected TCP sockets used to list connections per listener. Only
+// /proc/net/tcp is required; the others may be missing when IPv6 or UDP
+// support is compiled out.
+func (fs procFS) listeningSockets() ([]procSocket, error) {
+	data, err := os.ReadFile(fs.path("net", "tcp"))
+	if err != nil {
+		return nil, fmt.Errorf("read /proc/net/tcp: %w", err)
+	}
+	sockets := parseProcNet(string(data), "tcp")
+
+	for _, f := range []struct{ name, protocol string }{
+		{"tcp6", "tcp"},
+		{"udp", "udp"},
+		{"udp6", "udp"},
+	} {
+		if data, err := os.ReadFile(fs.path("net", f.name)); err == nil {
+			sockets = append(sockets, parseProcNet(string(data), f.protocol)...)
+		}
+	}
+	return sockets, nil
+}
+
+// buildPortInfos joins sockets with their owning PIDs.
+// Deduplicates by (port, PID, protocol), preferring IPv4 over IPv6.
+func buildPortInfos(sockets []procSocket, owners map[string][]int) []PortInfo {
+	type dedupKey struct {
+		port     int
+		pid      int
+		protocol string
+	}
+	seen := make(map[dedupKey]int)
+	var result []PortInfo
+
+	for _, s := range sockets {
+		if !s.listening() {
+			continue
+		}
+		for _, pid := range owners[s.inode] {
+			key := dedupKey{port: s.port, pid: pid, protocol: s.protocol}
+			isIPv4 := !strings.Contains(s.addr, ":")
+
+			if idx, exists := seen[key]; exists {
+				if isIPv4 && strings.Contains(result[idx].Address, ":") {
+					result[idx].Address = s.addr
+				}
+				continue
+			}
+
+			seen[key] = len(result)
+			result = append(result, PortInfo{
+				Port:     s.port,
+				PID:      pid,
+				Protocol: s.protocol,
+				Address:  s.addr,
+			})
+		}
+	}
+	return result
+}
+
+// parseProcNet parses /proc/net/{tcp,udp}{,6} content and returns TCP
+// sockets in LISTEN state, bound unconnected UDP sockets, and connected TCP
+// sockets that still have an owner. Format (after header):
+//
+//	sl local_address rem_address st tx_queue:rx_queue tr:tm->when retrnsmt uid timeout inode
+func parseProcNet(data, protocol string) []procSocket {
+	lines := strings.Split(strings.TrimSpace(data), "\n")
+	if len(lines) < 2 {
+		return nil
+	}
+
+	var result []procSocket
+	for _, line := range lines[1:] { // skip header
+		fields := strings.Fields(line)
+		if len(fields) < 10 {
+			continue
+		}
+		state, ok := procSocketState(protocol, fields[3])
+		if !ok {
+			continue
+		}
+		addr, port, err := parseProcAddr(fields[1])
+		if err != nil || port == 0 {
+			continue
+		}
+		inode := fields[9]
+		if inode == "0" {
+			continue
+		}
+		s := procSocket{
+			inode:    inode,
+			addr:     addr,
+			port:     port,
+			protocol: protocol,
+			state:    state,
+		}
+		if !s.listening() {
+			s.remoteAddr, s.remotePort, err = parseProcAddr(fields[2])
+			if err != nil {
+				continue
+			}
+		}
+		result = append(result, s)
+	}
+	return result
+}
+
+// procSocketState maps a hex state code to a normalized state name.
+// UDP sockets are only reported when bound and unconnected.
+func procSocketState(protocol, code string) (string, bool) {
+	if protocol == "udp" {
+		return stateUnconn, code == udpBound
+	}
+	n, err := strconv.ParseUint(code, 16, 8)
+	if err != nil {
+		return "", false
+	}
+	name, ok := tcpStateNames[int(n)]
+	return name, ok
+}
+
+// parseProcAddr decodes a hex "ADDR:PORT" pair from /proc/net/tcp{,6}.
+// Addresses are stored as 32-bit words in host byte order. The result
+// uses lsof conventions: "*" for 0.0.0.0 and brackets for IPv6.
+func parseProcAddr(s string) (addr string, port int, err error) {
+	hexAddr, hexPort, ok := strings.Cut(s, ":")
+	if !ok {
+		return "", 0, fmt.Errorf("malformed address %q", s)
+	}
+	p, err := strconv.ParseUint(hexPort, 16, 16)
+	if err != nil {
+		return "", 0, fmt.Errorf("malformed port %q: %w", hexPort, err)
+	}
+
+	raw, err := hex.DecodeString(hexAddr)
+	if err != nil || (len(raw) != net.IPv4len && len(raw) != net.IPv6len) {
+		return "", 0, fmt.Errorf("malformed address %q", hexAddr)
+	}
+
+	ip := make(net.IP, len(raw))
+	for i := 0; i < len(raw); i += 4 {
+		word := binary.BigEndian.Uint32(raw[i : i+4])
+		binary.NativeEndian.PutUint32(ip[i:i+4], word)
+	}
+
+	return formatSocketAddr(ip), int(p), nil
+}
+
+// formatSocketAddr renders a local socket address using lsof conventions:
+// "*" for 0.0.0.0 and brackets around IPv6 addresses.
+func formatSocketAddr(ip net.IP) string {
+	if len(ip) == net.IPv4len {
+		if ip.IsUnspecified() {
+			return "*"
+		}
+		return ip.String()
+	}
+	return "[" + ip.String() + "]"
+}
+
+// socketOwners maps socket inodes to the PIDs holding them by walking
+// /proc/<pid>/fd. Unreadable fd directories are silently skipped.
+func (fs procFS) socketOwners() map[string][]int {
+	owners := make(map[string][]int)
+
+	entries, err := os.ReadDir(fs.root)
+	if err != nil {
+		return owners
+	}
+
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue
+		}
+		fdDir := fs.path(e.Name(), "fd")
+		fds, err := os.ReadDir(fdDir)
+		if err != nil {
+			continue
+		}
+		seen := make(map[string]bool)
+		for _, fd := range fds {
+			link, err := os.Readlink(filepath.Join(fdDir, fd.Name()))
+			if err != nil {
+				continue
+			}
+			inode, ok := parseSocketLink(link)
+			if !ok || seen[inode] {
+				continue
+			}
+			seen[inode] = true
+			owners[inode] = append(owners[inode], pid)
+		}
+	}
+	return owners
+}
+
+// processes reads the process table from /proc/<pid>/stat. Processes that
+// exit while being read are skipped.
+func (fs procFS) processes() ([]Process, error) {
+	entries, err := os.ReadDir(fs.root)
+	if err != nil {
+		return nil, err
+	}
+
+	var procs []Process
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue
+		}
+		data, err := os.ReadFile(fs.path(e.Name(), "stat"))
+		if err != nil {
+			continue
+		}
+		st, err := parseProcStat(string(data))
+		if err != nil {
+			continue
+		}
+		procs = append(procs, Process{PID: pid, PPID: st.ppid, PGID: st.pgrp, SID: st.session, Name: st.comm})
+	}
+	return procs, nil
+}
+
+// parseSocketLink extracts the inode from a "socket:[12345]" fd link.
+func parseSocketLink(link string) (string, bool) {
+	if !strings.HasPrefix(link, "socket:[") || !strings.HasSuffix(link, "]") {
+		return "", false
+	}
+	return link[len("socket:[") : len(link)-1], true
+}
+
+// procStat holds the fields we need from /proc/<pid>/stat.
+type procStat struct {
+	comm       string
+	state      string // R, S, Z, ...
+	ppid       int
+	pgrp       int
+	session    int
+	cpuTicks   int64 // utime + stime
+	threads    int
+	startTicks int64
+}
+
+// parseProcStat parses /proc/<pid>/stat. The comm field is wrapped in
+// parentheses and may itself contain spaces or parentheses, so fields are
+// located relative to the last ')'.
+func parseProcStat(data string) (procStat, error) {
+	openIdx := strings.Index(data, "(")
+	closeIdx := strings.LastIndex(data, ")")
+	if openIdx == -1 || closeIdx < openIdx {
+		return procStat{}, fmt.Errorf("malformed stat")
+	}
+	comm := data[openIdx+1 : closeIdx]
+
+	// Fields after comm start at field 3 (state).
+	rest := strings.Fields(data[closeIdx+1:])
+	if len(rest) < 20 {
+		return procStat{}, fmt.Errorf("malformed stat: %d fields", len(rest))
+	}
+	ppid, err := strconv.Atoi(rest[1])
+	if err != nil {
+		return procStat{}, fmt.Errorf("malformed ppid: %w", err)
+	}
+	pgrp, err := strconv.Atoi(rest[2])
+	if err != nil {
+		return procStat{}, fmt.Errorf("malformed pgrp: %w", err)
+	}
+	session, err := strconv.Atoi(rest[3])
+	if err != nil {
+		return procStat{}, fmt.Errorf("malformed session: %w", err)
+	}
+	start, err := strconv.ParseInt(rest[19], 10, 64)
+	if err != nil {
+		return procStat{}, fmt.Errorf("malformed starttime: %w", err)
+	}
+	utime, _ := strconv.ParseInt(rest[11], 10, 64)
+	stime, _ := strconv.ParseInt(rest[12], 10, 64)
+	threads, _ := strconv.Atoi(rest[17])
+	return procStat{
+		comm: comm, state: rest[0], ppid: ppid, pgrp: pgrp, session: session,
+		cpuTicks: utime + stime, threads: threads, startTicks: start,
+	}, nil
+}
+
+// parseProcStatus extracts the real UID and VmRSS (KB) from /proc/<pid>/status.
+func parseProcStatus(data string) (uid string, rssKB int64) {
+	for _, line := range strings.Split(data, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(value)
+		if len(fields) == 0 {
+			continue
+		}
+		switch key {
+		case "Uid":
+			uid = fields[0]
+		case "VmRSS":
+			rssKB, _ = strconv.ParseInt(fields[0], 10, 64)
+		}
+	}
+	return uid, rssKB
+}
+
+// parseProcIO extracts read_bytes and write_bytes from /proc/<pid>/io.
+func parseProcIO(data string) (read, write int64) {
+	for _, line := range strings.Split(data, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			continue
+		}
+		switch key {
+		case "read_bytes":
+			read = n
+		case "write_bytes":
+			write = n
+		}
+	}
+	return read, write
+}
+
+// parseCmdline converts NUL-separated /proc/<pid>/cmdline to a single line.
+func parseCmdline(data string) string {
+	data = strings.TrimRight(data, "\x00")
+	return strings.Join(strings.Split(data, "\x00"), " ")
+}
+
+// readUptime returns seconds since boot from /proc/uptime.
+func (fs procFS) readUptime() (float64, error) {
+	data, err := os.ReadFile(fs.path("uptime"))
+	if err != nil {
+		return 0, err
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("malformed uptime")
+	}
+	return strconv.ParseFloat(fields[0], 64)
+}
+
+// enrich fills process name, PPID, user, command, uptime, memory, CPU,
+// threads, open FDs, IO and CWD for each entry from /proc/<pid>. Each PID
+// is read once.
+func (fs procFS) enrich(ports []PortInfo) {
+	if len(ports) == 0 {
+		return
+	}
+
+	now := time.Now()
+	bootUptime, uptimeErr := fs.readUptime()
+	users := make(map[string]string)
+
+	type procDetails struct {
+		process string
+		ppid    int
+		user    string
+		command string
+		uptime  string
+		elapsed int64 // seconds, -1 if unknown
+		memory  string
+		rss     int64 // bytes
+		cpu     float64
+		threads int
+		fds     int
+		read    int64
+		write   int64
+		cwd     string
+	}
+	cache := make(map[int]procDetails)
+	// CPU samples are timed on the boot clock, which start times are in.
+	boot := time.Unix(0, 0)
+	seen := make(map[int]bool)
+
+	for i := range ports {
+		pid := ports[i].PID
+		d, ok := cache[pid]
+		if !ok {
+			dir := strconv.Itoa(pid)
+			d.elapsed = -1
+
+			if data, err := os.ReadFile(fs.path(dir, "stat")); err == nil {
+				if st, err := parseProcStat(string(data)); err == nil {
+					d.process = st.comm
+					d.ppid = st.ppid
+					d.threads = st.threads
+					if uptimeErr == nil {
+						elapsed := int64(bootUptime) - st.startTicks/clockTicks
+						d.uptime = formatUptime(elapsed)
+						d.elapsed = max(elapsed, 0)
+						d.cpu = fs.cpu.percent(pid, cpuSample{
+							cpu:   time.Duration(st.cpuTicks) * time.Second / clockTicks,
+							start: boot.Add(time.Duration(st.startTicks) * time.Second / clockTicks),
+							at:    boot.Add(time.Duration(bootUptime * float64(time.Second))),
+						})
+					}
+				}
+			}
+			if data, err := os.ReadFile(fs.path(dir, "status")); err == nil {
+				uid, rss := parseProcStatus(string(data))
+				d.user = lookupUsername(users, uid)
+				d.memory = formatMemory(rss)
+				d.rss = rss * 1024
+			}
+			if data, err := os.ReadFile(fs.path(dir, "cmdline")); err == nil {
+				d.command = parseCmdline(string(data))
+			}
+			if entries, err := os.ReadDir(fs.path(dir, "fd")); err == nil {
+				d.fds = len(entries)
+			}
+			if data, err := os.ReadFile(fs.path(dir, "io")); err == nil {
+				d.read, d.write = parseProcIO(string(data))
+			}
+			if cwd, err := os.Readlink(fs.path(dir, "cwd")); err == nil {
+				d.cwd = cwd
+			}
+			cache[pid] = d
+			seen[pid] = true
+		}
+
+		ports[i].Process = d.process
+		ports[i].PPID = d.ppid
+		ports[i].User = d.user
+		ports[i].Command = d.command
+		ports[i].Uptime = d.uptime
+		if d.elapsed >= 0 {
+			ports[i].UptimeSeconds = d.elapsed
+			ports[i].StartTime = now.Add(-time.Duration(d.elapsed) * time.Second).Truncate(time.Second)
+		}
+		ports[i].Memory = d.memory
+		ports[i].RSS = d.rss
+		ports[i].CPUPercent = d.cpu
+		ports[i].Threads = d.threads
+		ports[i].FDs = d.fds
+		ports[i].ReadBytes = d.read
+		ports[i].WriteBytes = d.write
+		ports[i].CWD = d.cwd
+		if ports[i].Command == "" {
+			// kernel threads and zombies have an empty cmdline
+			ports[i].Command = d.process
+		}
+	}
+	fs.cpu.prune(seen)
+}
+
+// lookupUsername resolves a UID to a username, caching results.
+// Falls back to the numeric UID when no passwd entry exists.
+func lookupUsername(cache map[string]string, uid string) string {
+	if uid == "" {
+		return ""
+	}
+	if name, ok := cache[uid]; ok {
+		return name
+	}
+	name := uid
+	if u, err := user.LookupId(uid); err == nil {
+		name = u.Username
+	}
+	cache[uid] = name
+	return name
+}
@@ -0,0 +1,484 @@
+package ports
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const mockProcNetTCP = `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000:0BB8 00000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 11111 1 0000000000000000 100 0 0 10 0
+   1: 0100007F:1538 00000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 22222 1 0000000000000000 100 0 0 10 0
+   2: 0100007F:0BB8 0100007F:D431 01 00000000:00000000 00:00000000 00000000  1000        0 33333 1 0000000000000000 20 4 30 10 -1
+`
+
+const mockProcNetTCP6 = `  sl  local_address                         remote_address                        st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000000000000000000000000000:0BB8 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 44444 1 0000000000000000 100 0 0 10 0
+   1: 00000000000000000000000001000000:1F90 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 55555 1 0000000000000000 100 0 0 10 0
+`
+
+// fakeProc builds a minimal procfs tree under a temp directory.
+type fakeProc struct {
+	t    *testing.T
+	root string
+}
+
+func newFakeProc(t *testing.T) *fakeProc {
+	t.Helper()
+	root := t.TempDir()
+	fp := &fakeProc{t: t, root: root}
+	fp.write("net/tcp", mockProcNetTCP)
+	fp.write("net/tcp6", mockProcNetTCP6)
+	fp.write("uptime", "10000.50 20000.00\n")
+	return fp
+}
+
+func (fp *fakeProc) write(rel, content string) {
+	fp.t.Helper()
+	path := filepath.Join(fp.root, rel)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		fp.t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		fp.t.Fatalf("write %s: %v", rel, err)
+	}
+}
+
+func (fp *fakeProc) symlink(rel, target string) {
+	fp.t.Helper()
+	path := filepath.Join(fp.root, rel)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		fp.t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.Symlink(target, path); err != nil {
+		fp.t.Fatalf("symlink %s: %v", rel, err)
+	}
+}
+
+// addProcess creates /proc/<pid> with stat, status, cmdline, cwd and fds.
+func (fp *fakeProc) addProcess(pid, comm string, ppid string, startTicks string, rssKB string, cmdline, cwd string, inodes ...string) {
+	fp.t.Helper()
+	fp.write(pid+"/stat", pid+" ("+comm+") S "+ppid+" "+pid+" "+pid+" 0 -1 4194560 100 0 0 0 1 1 0 0 20 0 1 0 "+startTicks+" 1000000 250 18446744073709551615\n")
+	fp.write(pid+"/status", "Name:\t"+comm+"\nUid:\t99999\t99999\t99999\t99999\nVmRSS:\t   "+rssKB+" kB\n")
+	fp.write(pid+"/cmdline", cmdline)
+	fp.symlink(pid+"/cwd", cwd)
+	fp.symlink(pid+"/fd/0", "/dev/null")
+	for i, inode := range inodes {
+		fp.symlink(pid+"/fd/"+string(rune('3'+i)), "socket:["+inode+"]")
+	}
+}
+
+func TestParseProcNet(t *testing.T) {
+	sockets := parseProcNet(mockProcNetTCP, "tcp")
+
+	if len(sockets) != 3 {
+		t.Fatalf("expected 2 listening and 1 connected socket, got %d", len(sockets))
+	}
+	if sockets[0].addr != "*" || sockets[0].port != 3000 || sockets[0].inode != "11111" || !sockets[0].listening() {
+		t.Errorf("socket 0: got %+v", sockets[0])
+	}
+	if sockets[1].addr != "127.0.0.1" || sockets[1].port != 5432 || sockets[1].inode != "22222" {
+		t.Errorf("socket 1: got %+v", sockets[1])
+	}
+	conn := sockets[2]
+	if conn.listening() || conn.state != "ESTABLISHED" {
+		t.Errorf("socket 2: expected ESTABLISHED connection, got %+v", conn)
+	}
+	if conn.remoteAddr != "127.0.0.1" || conn.remotePort != 54321 {
+		t.Errorf("socket 2 remote: got %s:%d", conn.remoteAddr, conn.remotePort)
+	}
+}
+
+func TestParseProcNetIPv6(t *testing.T) {
+	sockets := parseProcNet(mockProcNetTCP6, "tcp")
+
+	if len(sockets) != 2 {
+		t.Fatalf("expected 2 listening sockets, got %d", len(sockets))
+	}
+	if sockets[0].addr != "[::]" || sockets[0].port != 3000 {
+		t.Errorf("socket 0: got %+v", sockets[0])
+	}
+	if sockets[1].addr != "[::1]" || sockets[1].port != 8080 {
+		t.Errorf("socket 1: got %+v", sockets[1])
+	}
+}
+
+func TestParseProcNetEmpty(t *testing.T) {
+	if got := parseProcNet("", "tcp"); len(got) != 0 {
+		t.Errorf("expected no sockets, got %d", len(got))
+	}
+	header := "  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode\n"
+	if got := parseProcNet(header, "tcp"); len(got) != 0 {
+		t.Errorf("expected no sockets for header-only, got %d", len(got))
+	}
+}
+
+func TestParseProcAddrInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		"0100007F",
+		"0100007F:ZZZZ",
+		"XYZ:0BB8",
+		"0100:0BB8",
+	}
+	for _, in := range tests {
+		if _, _, err := parseProcAddr(in); err == nil {
+			t.Errorf("parseProcAddr(%q): expected error", in)
+		}
+	}
+}
+
+func TestParseSocketLink(t *testing.T) {
+	tests := []struct {
+		link  string
+		inode string
+		ok    bool
+	}{
+		{"socket:[12345]", "12345", true},
+		{"pipe:[12345]", "", false},
+		{"/dev/null", "", false},
+		{"socket:[123", "", false},
+	}
+	for _, tt := range tests {
+		inode, ok := parseSocketLink(tt.link)
+		if inode != tt.inode || ok != tt.ok {
+			t.Errorf("parseSocketLink(%q) = (%q, %v), want (%q, %v)", tt.link, inode, ok, tt.inode, tt.ok)
+		}
+	}
+}
+
+func TestParseProcStat(t *testing.T) {
+	data := "1234 (my (weird) proc) S 99 1234 1234 0 -1 4194560 100 0 0 0 1 1 0 0 20 0 1 0 500000 1000000 250\n"
+	st, err := parseProcStat(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if st.comm != "my (weird) proc" {
+		t.Errorf("comm: got %q", st.comm)
+	}
+	if st.ppid != 99 {
+		t.Errorf("ppid: got %d", st.ppid)
+	}
+	if st.state != "S" {
+		t.Errorf("state: got %q", st.state)
+	}
+	if st.pgrp != 1234 || st.session != 1234 {
+		t.Errorf("pgrp/session: got %d/%d", st.pgrp, st.session)
+	}
+	if st.startTicks != 500000 {
+		t.Errorf("startTicks: got %d", st.startTicks)
+	}
+	if st.cpuTicks != 2 || st.threads != 1 {
+		t.Errorf("cpuTicks/threads: got %d/%d", st.cpuTicks, st.threads)
+	}
+}
+
+func TestParseProcStatMalformed(t *testing.T) {
+	for _, data := range []string{"", "1234 node S 1", "1234 (node) S 1 2 3"} {
+		if _, err := parseProcStat(data); err == nil {
+			t.Errorf("parseProcStat(%q): expected error", data)
+		}
+	}
+}
+
+func TestParseProcStatus(t *testing.T) {
+	data := "Name:\tnode\nUid:\t501\t501\t501\t501\nGid:\t20\t20\t20\t20\nVmRSS:\t  131072 kB\n"
+	uid, rss := parseProcStatus(data)
+	if uid != "501" {
+		t.Errorf("uid: got %q", uid)
+	}
+	if rss != 131072 {
+		t.Errorf("rss: got %d", rss)
+	}
+}
+
+func TestParseProcIO(t *testing.T) {
+	data := "rchar: 4096\nwchar: 1024\nsyscr: 10\nsyscw: 5\nread_bytes: 8192\nwrite_bytes: 512\ncancelled_write_bytes: 0\n"
+	read, write := parseProcIO(data)
+	if read != 8192 || write != 512 {
+		t.Errorf("got read=%d write=%d", read, write)
+	}
+	if read, write := parseProcIO(""); read != 0 || write != 0 {
+		t.Errorf("empty: got read=%d write=%d", read, write)
+	}
+}
+
+func TestParseCmdline(t *testing.T) {
+	got := parseCmdline("node\x00server.js\x00--port\x003000\x00")
+	if got != "node server.js --port 3000" {
+		t.Errorf("got %q", got)
+	}
+	if got := parseCmdline(""); got != "" {
+		t.Errorf("expected empty command, got %q", got)
+	}
+}
+
+func TestProcFSScan(t *testing.T) {
+	fp := newFakeProc(t)
+	// node holds both the IPv4 and IPv6 sockets on 3000
+	fp.addProcess("1234", "node", "1200", "700050", "51200", "node\x00server.js\x00", "/home/dev/app", "11111", "44444")
+	fp.addProcess("5678", "postgres", "1", "50", "20480", "postgres\x00-D\x00/var/lib/pg\x00", "/var/lib/pg", "22222")
+	fp.write("1234/io", "read_bytes: 8192\nwrite_bytes: 512\n")
+	// non-numeric entries are ignored
+	fp.write("self/stat", "")
+
+	fs := procFS{root: fp.root}
+	results, err := fs.scan()
+	if err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+
+	// socket 55555 has no owner and must be skipped
+	if len(results) != 2 {
+		t.Fatalf("expected 2 ports, got %d: %+v", len(results), results)
+	}
+
+	fs.enrich(results)
+
+	byPort := make(map[int]PortInfo)
+	for _, p := range results {
+		byPort[p.Port] = p
+	}
+
+	node := byPort[3000]
+	if node.PID != 1234 {
+		t.Errorf("node PID: got %d", node.PID)
+	}
+	if node.Address != "*" {
+		t.Errorf("expected IPv4 address preferred, got %q", node.Address)
+	}
+	if node.Process != "node" {
+		t.Errorf("node process: got %q", node.Process)
+	}
+	if node.PPID != 1200 {
+		t.Errorf("node PPID: got %d", node.PPID)
+	}
+	if node.Command != "node server.js" {
+		t.Errorf("node command: got %q", node.Command)
+	}
+	if node.CWD != "/home/dev/app" {
+		t.Errorf("node cwd: got %q", node.CWD)
+	}
+	if node.Memory != "50.0 MB" {
+		t.Errorf("node memory: got %q", node.Memory)
+	}
+	if node.RSS != 50*1024*1024 {
+		t.Errorf("node RSS: got %d", node.RSS)
+	}
+	// 10000s since boot, started 7000.5s after boot
+	if node.Uptime != "50m 0s" {
+		t.Errorf("node uptime: got %q", node.Uptime)
+	}
+	if node.UptimeSeconds != 3000 {
+		t.Errorf("node uptime seconds: got %d", node.UptimeSeconds)
+	}
+	if age := time.Since(node.StartTime); age < 2999*time.Second || age > 3010*time.Second {
+		t.Errorf("node start time: got %v, %v ago", node.StartTime, age)
+	}
+	if node.Threads != 1 {
+		t.Errorf("node threads: got %d", node.Threads)
+	}
+	// stdin plus the two sockets
+	if node.FDs != 3 {
+		t.Errorf("node FDs: got %d", node.FDs)
+	}
+	if node.ReadBytes != 8192 || node.WriteBytes != 512 {
+		t.Errorf("node IO: got read=%d write=%d", node.ReadBytes, node.WriteBytes)
+	}
+	// 0.02s of CPU over 2999.5s
+	if node.CPUPercent <= 0 || node.CPUPercent > 0.001 {
+		t.Errorf("node CPU: got %v", node.CPUPercent)
+	}
+	if node.User != "99999" {
+		t.Errorf("expected numeric fallback for unknown uid, got %q", node.User)
+	}
+	if node.Protocol != "tcp" {
+		t.Errorf("node protocol: got %q", node.Protocol)
+	}
+
+	pg := byPort[5432]
+	if pg.PID != 5678 || pg.Address != "127.0.0.1" {
+		t.Errorf("postgres: got %+v", pg)
+	}
+	if pg.Uptime != "2h 46m" {
+		t.Errorf("postgres uptime: got %q", pg.Uptime)
+	}
+}
+
+func TestProcFSScanMissingTCP(t *testing.T) {
+	fs := procFS{root: t.TempDir()}
+	if _, err := fs.scan(); err == nil {
+		t.Error("expected error when /proc/net/tcp is missing")
+	}
+}
+
+func TestProcFSScanWithoutTCP6(t *testing.T) {
+	fp := newFakeProc(t)
+	os.Remove(filepath.Join(fp.root, "net", "tcp6"))
+	fp.addProcess("1234", "node", "1", "0", "1024", "node\x00", "/", "11111")
+
+	results, err := procFS{root: fp.root}.scan()
+	if err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("expected 1 port, got %d", len(results))
+	}
+}
+
+func TestProcFSEnrichMissingProcess(t *testing.T) {
+	fp := newFakeProc(t)
+	ports := []PortInfo{{Port: 3000, PID: 4242}}
+
+	procFS{root: fp.root}.enrich(ports)
+
+	if ports[0].Process != "" || ports[0].Command != "" {
+		t.Errorf("expected empty fields for vanished process, got %+v", ports[0])
+	}
+}
+
+func TestProcFSZombie(t *testing.T) {
+	fp := newFakeProc(t)
+	fp.write("100/stat", "100 (node) Z 1 100 100 0 -1 0 0 0 0 0 0 0 0 0 20 0 1 0 500 0 0\n")
+	fp.write("200/stat", "200 (node) S 1 200 200 0 -1 0 0 0 0 0 0 0 0 0 20 0 1 0 500 0 0\n")
+	fs := procFS{root: fp.root}
+
+	if !fs.zombie(100) {
+		t.Error("expected PID 100 to be a zombie")
+	}
+	if fs.zombie(200) || fs.zombie(300) {
+		t.Error("running and missing processes are not zombies")
+	}
+}
+
+func TestProcFSEnrichCPUBetweenScans(t *testing.T) {
+	fp := newFakeProc(t)
+	fp.addProcess("1234", "node", "1", "700000", "1024", "node\x00", "/", "11111")
+	fs := procFS{root: fp.root, cpu: newCPUTracker()}
+
+	ports := []PortInfo{{Port: 3000, PID: 1234}}
+	fs.enrich(ports)
+
+	// one second later with 50 more ticks of user time
+	fp.write("uptime", "10001.50 20000.00\n")
+	fp.write("1234/stat", "1234 (node) S 1 1234 1234 0 -1 4194560 100 0 0 0 51 1 0 0 20 0 4 0 700000 1000000 250\n")
+	fs.enrich(ports)
+
+	if !near(ports[0].CPUPercent, 50) {
+		t.Errorf("CPU: got %v, want 50", ports[0].CPUPercent)
+	}
+	if ports[0].Threads != 4 {
+		t.Errorf("threads: got %d", ports[0].Threads)
+	}
+}
+
+func TestFormatUptime(t *testing.T) {
+	tests := []struct {
+		secs int64
+		want string
+	}{
+		{-5, "0s"},
+		{0, "0s"},
+		{45, "45s"},
+		{330, "5m 30s"},
+		{3930, "1h 5m"},
+		{183930, "2d 3h"},
+	}
+	for _, tt := range tests {
+		if got := formatUptime(tt.secs); got != tt.want {
+			t.Errorf("formatUptime(%d) = %q, want %q", tt.secs, got, tt.want)
+		}
+	}
+}
+
+const mockProcNetUDP = `   sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode ref pointer drops
+  100: 00000000:14E9 00000000:0000 07 00000000:00000000 00:00000000 00000000   101        0 66666 2 0000000000000000 0
+  101: 0100007F:0035 00000000:0000 07 00000000:00000000 00:00000000 00000000   101        0 77777 2 0000000000000000 0
+  102: 0100007F:C350 0100007F:0035 01 00000000:00000000 00:00000000 00000000  1000        0 88888 2 0000000000000000 0
+  103: 00000000:0000 00000000:0000 07 00000000:00000000 00:00000000 00000000  1000        0 99999 2 0000000000000000 0
+`
+
+func TestParseProcNetUDP(t *testing.T) {
+	sockets := parseProcNet(mockProcNetUDP, "udp")
+
+	// connected (01) and port-0 sockets are skipped
+	if len(sockets) != 2 {
+		t.Fatalf("expected 2 bound UDP sockets, got %d: %+v", len(sockets), sockets)
+	}
+	if sockets[0].port != 5353 || sockets[0].addr != "*" || sockets[0].protocol != "udp" {
+		t.Errorf("socket 0: got %+v", sockets[0])
+	}
+	if sockets[1].port != 53 || sockets[1].addr != "127.0.0.1" {
+		t.Errorf("socket 1: got %+v", sockets[1])
+	}
+}
+
+func TestProcFSScanTCPAndUDPSamePort(t *testing.T) {
+	fp := newFakeProc(t)
+	fp.write("net/udp", `   sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode ref pointer drops
+  100: 00000000:0BB8 00000000:0000 07 00000000:00000000 00:00000000 00000000   101        0 66666 2 0000000000000000 0
+`)
+	fp.addProcess("1234", "node", "1", "0", "1024", "node\x00", "/", "11111", "66666")
+
+	results, err := procFS{root: fp.root}.scan()
+	if err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected separate TCP and UDP entries, got %d: %+v", len(results), results)
+	}
+	protocols := map[string]bool{}
+	for _, p := range results {
+		if p.Port != 3000 {
+			t.Errorf("unexpected port %d", p.Port)
+		}
+		protocols[p.Protocol] = true
+	}
+	if !protocols["tcp"] || !protocols["udp"] {
+		t.Errorf("expected tcp and udp, got %v", protocols)
+	}
+}
+
+func TestProcFSScanConnections(t *testing.T) {
+	fp := newFakeProc(t)
+	fp.write("net/tcp", `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000:0BB8 00000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 11111 1 0000000000000000 100 0 0 10 0
+   1: 0100007F:0BB8 0100007F:D431 01 00000000:00000000 00:00000000 00000000  1000        0 33333 1 0000000000000000 20 4 30 10 -1
+   2: 0100007F:D431 0100007F:0BB8 01 00000000:00000000 00:00000000 00000000  1000        0 33334 1 0000000000000000 20 4 30 10 -1
+   3: 0100007F:0BB8 0500000A:C350 08 00000000:00000000 00:00000000 00000000  1000        0 33335 1 0000000000000000 20 4 30 10 -1
+   4: 0100007F:0BB8 0100007F:D432 06 00000000:00000000 00:00000000 00000000     0        0 0 1 0000000000000000 20 4 30 10 -1
+`)
+	fp.write("net/tcp6", "")
+	fp.addProcess("1234", "node", "1", "0", "1024", "node\x00", "/", "11111", "33333", "33335")
+	fp.addProcess("999", "curl", "1", "0", "1024", "curl\x00", "/", "33334")
+
+	results, err := procFS{root: fp.root}.scan()
+	if err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("expected 1 listener, got %d: %+v", len(results), results)
+	}
+
+	conns := results[0].Connections
+	if len(conns) != 2 {
+		t.Fatalf("expected 2 connections, got %d: %+v", len(conns), conns)
+	}
+
+	local := conns[0]
+	if local.RemoteAddr != "127.0.0.1" || local.RemotePort != 54321 || local.State != "ESTABLISHED" {
+		t.Errorf("local connection: got %+v", local)
+	}
+	if local.LocalPID != 999 || local.LocalProcess != "curl" {
+		t.Errorf("expected client curl (999), got %q (%d)", local.LocalProcess, local.LocalPID)
+	}
+
+	remote := conns[1]
+	if remote.RemoteAddr != "10.0.0.5" || remote.RemotePort != 50000 || remote.State != "CLOSE_WAIT" {
+		t.Errorf("remote connection: got %+v", remote)
+	}
+	if remote.LocalPID != 0 {
+		t.Errorf("remote peer should have no local PID, got %d", remote.LocalPID)
+	}
+}
@@ -0,0 +1,639 @@
+package ports
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Query is a compiled filter expression, used by the TUI filter and by
+// `reap list --query`. The syntax is:
+//
+//	node                 bare word: substring of process, port, PID, user,
+//	                     protocol, container, directory or label
+//	~^node(js)?$         bare regular expression over the same fields
+//	port:3000            field equals value (case-insensitive)
+//	port:3000-3999       numeric range, inclusive
+//	port:80,443          any of several values
+//	proc:node*           glob: * and ? wildcards
+//	proc:~^node          regular expression
+//	container:*          field is set
+//	mem>500MB uptime<1h  comparison: > >= < <= = !=
+//	cpu>=50 threads>100  CPU in percent of one core, thread and FD counts
+//	-user:root !udp      negation; NOT works as well
+//	a b, a AND b, a & b  all must match
+//	a OR b, a | b        either matches; AND binds tighter
+//	( ... )              grouping
+//
+// Values containing spaces can be quoted: dir:"/my app".
+type Query struct {
+	src  string
+	root queryNode
+}
+
+// QueryError is a syntax error in a query. Pos is the 1-based column of the
+// offending token.
+type QueryError struct {
+	Pos int
+	Msg string
+}
+
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("col %d: %s", e.Pos, e.Msg)
+}
+
+// ParseQuery compiles a query. An empty or blank query matches everything.
+func ParseQuery(s string) (*Query, error) {
+	toks, err := tokenizeQuery(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &queryParser{toks: toks, end: len(s) + 1}
+	q := &Query{src: s}
+	if len(toks) == 0 {
+		return q, nil
+	}
+	if q.root, err = p.parseOr(); err != nil {
+		return nil, err
+	}
+	if t, ok := p.peek(); ok {
+		return nil, &QueryError{t.pos, fmt.Sprintf("unexpected %q", t.text)}
+	}
+	return q, nil
+}
+
+// Match reports whether p satisfies the query. A nil query matches
+// everything.
+func (q *Query) Match(p PortInfo) bool {
+	if q == nil || q.root == nil {
+		return true
+	}
+	return q.root.match(&p)
+}
+
+// String returns the query as written.
+func (q *Query) String() string {
+	if q == nil {
+		return ""
+	}
+	return q.src
+}
+
+// queryNode is a node of the parsed expression.
+type queryNode interface {
+	match(p *PortInfo) bool
+}
+
+type andNode []queryNode
+
+func (n andNode) match(p *PortInfo) bool {
+	for _, c := range n {
+		if !c.match(p) {
+			return false
+		}
+	}
+	return true
+}
+
+type orNode []queryNode
+
+func (n orNode) match(p *PortInfo) bool {
+	for _, c := range n {
+		if c.match(p) {
+			return true
+		}
+	}
+	return false
+}
+
+type notNode struct{ node queryNode }
+
+func (n notNode) match(p *PortInfo) bool { return !n.node.match(p) }
+
+// textTerm matches a text field, or any bare-word field when field is nil.
+type textTerm struct {
+	field *queryField
+	fn    func(string) bool
+}
+
+func (t textTerm) match(p *PortInfo) bool {
+	if t.field != nil {
+		return t.fn(t.field.text(p))
+	}
+	for _, v := range []string{p.Process, strconv.Itoa(p.Port), strconv.Itoa(p.PID), p.User, p.Protocol, p.Container, p.CWD, p.Label} {
+		if t.fn(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// numTerm matches a numeric field against a predicate. Entries where the
+// field is unknown never match.
+type numTerm struct {
+	field *queryField
+	pred  func(int64) bool
+}
+
+func (t numTerm) match(p *PortInfo) bool {
+	v, ok := t.field.num(p)
+	return ok && t.pred(v)
+}
+
+// fieldKind is how a query field's values are parsed.
+type fieldKind int
+
+const (
+	kindText     fieldKind = iota
+	kindNumber             // plain integer
+	kindSize               // bytes, with an optional B, KB, MB, GB or TB suffix
+	kindDuration           // seconds, written as a Go duration or with a d suffix
+	kindPercent            // hundredths of a percent, written as a decimal with an optional % suffix
+)
+
+// queryField is a field that can be named in a query.
+type queryField struct {
+	names []string // the first is the canonical name
+	kind  fieldKind
+	text  func(p *PortInfo) string
+	num   func(p *PortInfo) (int64, bool)
+}
+
+var queryFields = []*queryField{
+	{names: []string{"port"}, kind: kindNumber, num: func(p *PortInfo) (int64, bool) { return int64(p.Port), true }},
+	{names: []string{"pid"}, kind: kindNumber, num: func(p *PortInfo) (int64, bool) { return int64(p.PID), true }},
+	{names: []string{"ppid"}, kind: kindNumber, num: func(p *PortInfo) (int64, bool) { return int64(p.PPID), p.PPID > 0 }},
+	{names: []string{"conns", "connections"}, kind: kindNumber, num: func(p *PortInfo) (int64, bool) {
+		return int64(len(p.Connections)), p.Protocol != "udp"
+	}},
+	{names: []string{"mem", "memory", "rss"}, kind: kindSize, num: func(p *PortInfo) (int64, bool) { return p.RSS, p.RSS > 0 }},
+	{names: []string{"uptime", "age"}, kind: kindDuration, num: func(p *PortInfo) (int64, bool) {
+		return p.UptimeSeconds, !p.StartTime.IsZero()
+	}},
+	{names: []string{"cpu"}, kind: kindPercent, num: func(p *PortInfo) (int64, bool) {
+		return int64(math.Round(p.CPUPercent * 100)), true
+	}},
+	{names: []string{"threads"}, kind: kindNumber, num: func(p *PortInfo) (int64, bool) { return int64(p.Threads), p.Threads > 0 }},
+	{names: []string{"fds"}, kind: kindNumber, num: func(p *PortInfo) (int64, bool) { return int64(p.FDs), p.FDs > 0 }},
+	{names: []string{"proc", "process", "name"}, text: func(p *PortInfo) string { return p.Process }},
+	{names: []string{"user"}, text: func(p *PortInfo) string { return p.User }},
+	{names: []string{"proto", "protocol"}, text: func(p *PortInfo) string { return p.Protocol }},
+	{names: []string{"container", "ct"}, text: func(p *PortInfo) string { return p.Container }},
+	{names: []string{"dir", "cwd"}, text: func(p *PortInfo) string { return p.CWD }},
+	{names: []string{"cmd", "command"}, text: func(p *PortInfo) string { return p.Command }},
+	{names: []string{"addr", "address"}, text: func(p *PortInfo) string { return p.Address }},
+	{names: []string{"label", "service", "svc"}, text: func(p *PortInfo) string { return p.Label }},
+}
+
+func lookupQueryField(name string) *queryField {
+	name = strings.ToLower(name)
+	for _, f := range queryFields {
+		for _, n := range f.names {
+			if n == name {
+				return f
+			}
+		}
+	}
+	return nil
+}
+
+// QueryFields returns the canonical names of the fields a query can use.
+func QueryFields() []string {
+	names := make([]string, len(queryFields))
+	for i, f := range queryFields {
+		names[i] = f.names[0]
+	}
+	return names
+}
+
+// queryToken is a lexical token. Operators have kind set; words carry
+// their text with quotes removed.
+type queryToken struct {
+	kind string // "(", ")", "and", "or", "not" or "word"
+	text string
+	pos  int
+}
+
+// tokenizeQuery splits a query into parentheses, operators and words.
+// Quotes group a value with spaces and are removed. Parentheses after a ~
+// belong to the regular expression.
+func tokenizeQuery(s string) ([]queryToken, error) {
+	var toks []queryToken
+	runes := []rune(s)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		pos := i + 1
+		switch {
+		case unicode.IsSpace(r):
+			i++
+			continue
+		case r == '(' || r == ')':
+			toks = append(toks, queryToken{string(r), string(r), pos})
+			i++
+			continue
+		case r == '|' || r == '&':
+			kind := "or"
+			if r == '&' {
+				kind = "and"
+			}
+			i++
+			if i < len(runes) && runes[i] == r { // || and &&
+				i++
+			}
+			toks = append(toks, queryToken{kind, string(runes[pos-1 : i]), pos})
+			continue
+		case (r == '!' || r == '-') && i+1 < len(runes) && !unicode.IsSpace(runes[i+1]):
+			toks = append(toks, queryToken{"not", string(r), pos})
+			i++
+			continue
+		}
+
+		var b strings.Builder
+		quoted, regex, depth := false, false, 0
+	word:
+		for ; i < len(runes); i++ {
+			r := runes[i]
+			if r == '"' {
+				quoted = !quoted
+				continue
+			}
+			if !quoted {
+				switch {
+				case unicode.IsSpace(r):
+					break word
+				case r == '~':
+					regex = true
+				case r == '(' && regex:
+					depth++
+				case r == ')' && depth > 0:
+					depth--
+				case r == '(' || r == ')':
+					break word
+				}
+			}
+			b.WriteRune(r)
+		}
+		if quoted {
+			return nil, &QueryError{pos, "unterminated quote"}
+		}
+		word := b.String()
+		switch strings.ToLower(word) {
+		case "and", "or", "not":
+			toks = append(toks, queryToken{strings.ToLower(word), word, pos})
+		default:
+			toks = append(toks, queryToken{"word", word, pos})
+		}
+	}
+	return toks, nil
+}
+
+type queryParser struct {
+	toks []queryToken
+	i    int
+	end  int // column reported for errors at the end of the input
+}
+
+func (p *queryParser) peek() (queryToken, bool) {
+	if p.i >= len(p.toks) {
+		return queryToken{}, false
+	}
+	return p.toks[p.i], true
+}
+
+func (p *queryParser) parseOr() (queryNode, error) {
+	var terms orNode
+	for {
+		n, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, n)
+		if t, ok := p.peek(); !ok || t.kind != "or" {
+			break
+		}
+		p.i++
+	}
+	if len(terms) == 1 {
+		return terms[0], nil
+	}
+	return terms, nil
+}
+
+func (p *queryParser) parseAnd() (queryNode, error) {
+	var terms andNode
+	for {
+		n, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, n)
+		t, ok := p.peek()
+		if !ok || t.kind == "or" || t.kind == ")" {
+			break
+		}
+		if t.kind == "and" {
+			p.i++
+		}
+	}
+	if len(terms) == 1 {
+		return terms[0], nil
+	}
+	return terms, nil
+}
+
+func (p *queryParser) parseUnary() (queryNode, error) {
+	t, ok := p.peek()
+	if !ok {
+		return nil, &QueryError{p.end, "expected a term"}
+	}
+	switch t.kind {
+	case "not":
+		p.i++
+		n, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{n}, nil
+	case "(":
+		p.i++
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if c, ok := p.peek(); !ok || c.kind != ")" {
+			return nil, &QueryError{t.pos, "unclosed parenthesis"}
+		}
+		p.i++
+		return n, nil
+	case "word":
+		p.i++
+		return parseTerm(t)
+	}
+	return nil, &QueryError{t.pos, fmt.Sprintf("unexpected %q", t.text)}
+}
+
+// comparisonOps are tried longest first.
+var comparisonOps = []string{">=", "<=", "!=", ">", "<", "="}
+
+// parseTerm parses a word: field:value, field<op>value or a bare word.
+func parseTerm(t queryToken) (queryNode, error) {
+	word := t.text
+	name, rest := splitFieldName(word)
+	if name == "" || rest == "" || (rest[0] != ':' && !strings.ContainsAny(rest[:1], "<>=!")) {
+		return parseBare(t)
+	}
+	field := lookupQueryField(name)
+	if field == nil {
+		return nil, &QueryError{t.pos, fmt.Sprintf("unknown field %q (fields: %s)", name, strings.Join(QueryFields(), ", "))}
+	}
+	valuePos := t.pos + len([]rune(name))
+	if rest[0] == ':' {
+		rest = rest[1:]
+		valuePos++
+	}
+	if rest == "" {
+		return nil, &QueryError{t.pos, fmt.Sprintf("missing value for %s", name)}
+	}
+	for _, op := range comparisonOps {
+		if v, ok := strings.CutPrefix(rest, op); ok {
+			return parseComparison(field, op, v, valuePos)
+		}
+	}
+	if field.kind == kindText {
+		m, err := textMatcher(rest, valuePos)
+		if err != nil {
+			return nil, err
+		}
+		return textTerm{field, m}, nil
+	}
+	return parseNumValues(field, rest, valuePos)
+}
+
+// splitFieldName splits a leading field name made of letters from the rest
+// of a word.
+func splitFieldName(word string) (string, string) {
+	i := strings.IndexFunc(word, func(r rune) bool { return !unicode.IsLetter(r) && r != '_' })
+	if i <= 0 {
+		return "", word
+	}
+	return word[:i], word[i:]
+}
+
+func parseBare(t queryToken) (queryNode, error) {
+	if re, ok := strings.CutPrefix(t.text, "~"); ok {
+		m, err := regexMatcher(re, t.pos+1)
+		if err != nil {
+			return nil, err
+		}
+		return textTerm{nil, m}, nil
+	}
+	needle := strings.ToLower(t.text)
+	return textTerm{nil, func(v string) bool { return strings.Contains(strings.ToLower(v), needle) }}, nil
+}
+
+// textMatcher compiles a text value: * alone for "set", ~regex, a glob,
+// a comma-separated list, or an exact case-insensitive value.
+func textMatcher(value string, pos int) (func(string) bool, error) {
+	if value == "*" {
+		return func(v string) bool { return v != "" }, nil
+	}
+	if re, ok := strings.CutPrefix(value, "~"); ok {
+		return regexMatcher(re, pos+1)
+	}
+	var matchers []func(string) bool
+	for _, alt := range strings.Split(value, ",") {
+		if strings.ContainsAny(alt, "*?") {
+			re := regexp.MustCompile("(?i)^" + globToRegexp(alt) + "$")
+			matchers = append(matchers, re.MatchString)
+			continue
+		}
+		matchers = append(matchers, func(v string) bool { return strings.EqualFold(v, alt) })
+	}
+	return func(v string) bool {
+		for _, m := range matchers {
+			if m(v) {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+func regexMatcher(expr string, pos int) (func(string) bool, error) {
+	if expr == "" {
+		return nil, &QueryError{pos, "empty regular expression"}
+	}
+	re, err := regexp.Compile("(?i)" + expr)
+	if err != nil {
+		return nil, &QueryError{pos, fmt.Sprintf("invalid regular expression: %s", strings.TrimPrefix(err.Error(), "error parsing regexp: "))}
+	}
+	return re.MatchString, nil
+}
+
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	for _, r := range glob {
+		switch r {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteByte('.')
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	return b.String()
+}
+
+func parseComparison(field *queryField, op, value string, pos int) (queryNode, error) {
+	pos += len(op)
+	if field.kind == kindText {
+		switch op {
+		case "=":
+			m, err := textMatcher(value, pos)
+			if err != nil {
+				return nil, err
+			}
+			return textTerm{field, m}, nil
+		case "!=":
+			m, err := textMatcher(value, pos)
+			if err != nil {
+				return nil, err
+			}
+			return notNode{textTerm{field, m}}, nil
+		}
+		return nil, &QueryError{pos - len(op), fmt.Sprintf("%s is text and cannot be compared with %s", field.names[0], op)}
+	}
+	n, err := parseQueryNumber(field.kind, value)
+	if err != nil {
+		return nil, &QueryError{pos, err.Error()}
+	}
+	var pred func(int64) bool
+	switch op {
+	case ">":
+		pred = func(v int64) bool { return v > n }
+	case ">=":
+		pred = func(v int64) bool { return v >= n }
+	case "<":
+		pred = func(v int64) bool { return v < n }
+	case "<=":
+		pred = func(v int64) bool { return v <= n }
+	case "=":
+		pred = func(v int64) bool { return v == n }
+	case "!=":
+		pred = func(v int64) bool { return v != n }
+	}
+	return numTerm{field, pred}, nil
+}
+
+// parseNumValues parses "*", "3000", "3000-3999" or a comma-separated list
+// of those.
+func parseNumValues(field *queryField, value string, pos int) (queryNode, error) {
+	if value == "*" {
+		return numTerm{field, func(int64) bool { return true }}, nil
+	}
+	type numRange struct{ lo, hi int64 }
+	var ranges []numRange
+	for _, alt := range strings.Split(value, ",") {
+		lo, hi, isRange := strings.Cut(alt, "-")
+		from, err := parseQueryNumber(field.kind, lo)
+		if err != nil {
+			return nil, &QueryError{pos, err.Error()}
+		}
+		to := from
+		if isRange {
+			if to, err = parseQueryNumber(field.kind, hi); err != nil {
+				return nil, &QueryError{pos, err.Error()}
+			}
+			if to < from {
+				return nil, &QueryError{pos, fmt.Sprintf("empty range %s", alt)}
+			}
+		}
+		ranges = append(ranges, numRange{from, to})
+	}
+	return numTerm{field, func(v int64) bool {
+		for _, r := range ranges {
+			if v >= r.lo && v <= r.hi {
+				return true
+			}
+		}
+		return false
+	}}, nil
+}
+
+// parseQueryNumber parses a value of a numeric field kind.
+func parseQueryNumber(kind fieldKind, s string) (int64, error) {
+	switch kind {
+	case kindSize:
+		return parseSize(s)
+	case kindDuration:
+		return parseDurationSeconds(s)
+	case kindPercent:
+		f, err := strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+		if err != nil || f < 0 {
+			return 0, fmt.Errorf("invalid percentage %q", s)
+		}
+		return int64(math.Round(f * 100)), nil
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q", s)
+	}
+	return n, nil
+}
+
+// sizeUnits are binary multiples, matching the MEMORY column.
+var sizeUnits = []struct {
+	suffix string
+	mult   int64
+}{
+	{"tb", 1 << 40}, {"gb", 1 << 30}, {"mb", 1 << 20}, {"kb", 1 << 10},
+	{"t", 1 << 40}, {"g", 1 << 30}, {"m", 1 << 20}, {"k", 1 << 10},
+	{"b", 1},
+}
+
+// parseSize parses sizes such as "512", "500MB", "1.5G" or "64k" to bytes.
+func parseSize(s string) (int64, error) {
+	lower := strings.ToLower(s)
+	mult := int64(1)
+	for _, u := range sizeUnits {
+		if num, ok := strings.CutSuffix(lower, u.suffix); ok {
+			lower, mult = num, u.mult
+			break
+		}
+	}
+	f, err := strconv.ParseFloat(lower, 64)
+	if err != nil || f < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return int64(f * float64(mult)), nil
+}
+
+// parseDurationSeconds parses durations such as "90s", "1h30m", "2d" or a
+// plain number of seconds.
+func parseDurationSeconds(s string) (int64, error) {
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return n, nil
+	}
+	var days int64
+	rest := s
+	if i := strings.IndexByte(s, 'd'); i > 0 {
+		n, err := strconv.ParseInt(s[:i], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		days, rest = n, s[i+1:]
+	}
+	var d time.Duration
+	if rest != "" {
+		var err error
+		if d, err = time.ParseDuration(rest); err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+	}
+	return days*86400 + int64(d/time.Second), nil
+}
@@ -0,0 +1,191 @@
+package ports
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQueryMatch(t *testing.T) {
+	started := time.Date(2026, 10, 17, 8, 0, 0, 0, time.UTC)
+	results := []PortInfo{
+		{Port: 80, PID: 8001, Process: "nginx", User: "root", Protocol: "tcp", Address: "*", RSS: 20 << 20,
+			UptimeSeconds: 3 * 86400, StartTime: started, CPUPercent: 0.4, Threads: 4, FDs: 12},
+		{Port: 3000, PID: 4242, PPID: 1, Process: "node", User: "dev", Protocol: "tcp", Address: "127.0.0.1",
+			RSS: 600 << 20, UptimeSeconds: 1800, StartTime: started, CWD: "/home/dev/My App",
+			Command: "node server.js", Connections: []Connection{{}, {}}, CPUPercent: 87.5, Threads: 11, FDs: 40},
+		{Port: 3999, PID: 5000, Process: "nodejs", User: "devops", Protocol: "tcp", Container: "web", Label: "storefront"},
+		{Port: 5353, PID: 153, Process: "mDNSResponder", User: "_mdnsresponder", Protocol: "udp"},
+		{Port: 8080, PID: 9000, Process: "java", User: "dev", Protocol: "tcp", RSS: 2 << 30,
+			UptimeSeconds: 7200, StartTime: started},
+	}
+	tests := []struct {
+		query string
+		want  []int
+	}{
+		{"", []int{80, 3000, 3999, 5353, 8080}},
+		{"80", []int{80, 8080}}, // bare words keep the substring match
+		{"port:80", []int{80}},
+		{"port:3000-3999", []int{3000, 3999}},
+		{"port:80,8080", []int{80, 8080}},
+		{"port>5000", []int{5353, 8080}},
+		{"port:>=3999", []int{3999, 5353, 8080}},
+		{"user:dev", []int{3000, 8080}},
+		{"user:DEV*", []int{3000, 3999, 8080}},
+		{"proc:~^node", []int{3000, 3999}},
+		{"proc:~^node(js)?$", []int{3000, 3999}},
+		{"~^(java|nginx)$", []int{80, 8080}},
+		{"container:*", []int{3999}},
+		{"label:storefront", []int{3999}},
+		{"service:store*", []int{3999}},
+		{"front", []int{3999}}, // bare words match labels too
+		{"cpu>50", []int{3000}},
+		{"cpu>0.3%", []int{80, 3000}},
+		{"cpu:87.5", []int{3000}},
+		{"threads>=4", []int{80, 3000}},
+		{"fds:10-20", []int{80}},
+		{"-container:*", []int{80, 3000, 5353, 8080}},
+		{"mem>500MB", []int{3000, 8080}},
+		{"mem<1g", []int{80, 3000}},
+		{"mem:100MB-1GB", []int{3000}},
+		{"uptime<1h", []int{3000}},
+		{"uptime>=2d", []int{80}},
+		{"uptime:1h-1d", []int{8080}},
+		{"conns>0", []int{3000}},
+		{"ppid:*", []int{3000}},
+		{"proto:udp", []int{5353}},
+		{"!udp", []int{80, 3000, 3999, 8080}},
+		{"NOT user:dev", []int{80, 3999, 5353}},
+		{"user:dev port>5000", []int{8080}},
+		{"user:dev AND port>5000", []int{8080}},
+		{"user:dev && port>5000", []int{8080}},
+		{"user:root OR proto:udp", []int{80, 5353}},
+		{"user:root | user:devops", []int{80, 3999}},
+		{"user:dev port:3000 OR user:root", []int{80, 3000}}, // AND binds tighter
+		{"user:dev (port:3000 OR port:80)", []int{3000}},
+		{"-(user:dev OR user:root)", []int{3999, 5353}},
+		{`dir:"/home/dev/My App"`, []int{3000}},
+		{`dir:*app*`, []int{3000}},
+		{"cmd:*server*", []int{3000}},
+		{"addr:127.0.0.1", []int{3000}},
+		{"user!=dev", []int{80, 3999, 5353}},
+		{"user=root", []int{80}},
+	}
+	for _, tt := range tests {
+		q, err := ParseQuery(tt.query)
+		if err != nil {
+			t.Errorf("ParseQuery(%q): %v", tt.query, err)
+			continue
+		}
+		var got []int
+		for _, p := range results {
+			if q.Match(p) {
+				got = append(got, p.Port)
+			}
+		}
+		if !equalInts(got, tt.want) {
+			t.Errorf("%q matched %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestQueryErrors(t *testing.T) {
+	tests := []struct {
+		query string
+		pos   int
+		msg   string
+	}{
+		{"colour:red", 1, `unknown field "colour"`},
+		{"port:abc", 6, `invalid number "abc"`},
+		{"port:10-5", 6, "empty range"},
+		{"mem>lots", 5, `invalid size "lots"`},
+		{"uptime<soon", 8, `invalid duration "soon"`},
+		{"cpu>busy", 5, `invalid percentage "busy"`},
+		{"user>root", 5, "cannot be compared"},
+		{"proc:~[", 7, "invalid regular expression"},
+		{"(port:80", 1, "unclosed parenthesis"},
+		{"port:80)", 8, `unexpected ")"`},
+		{"user:dev OR", 12, "expected a term"},
+		{`dir:"/tmp`, 1, "unterminated quote"},
+		{"port:", 1, "missing value"},
+	}
+	for _, tt := range tests {
+		_, err := ParseQuery(tt.query)
+		var qe *QueryError
+		if !errors.As(err, &qe) {
+			t.Errorf("ParseQuery(%q) = %v, want a QueryError", tt.query, err)
+			continue
+		}
+		if qe.Pos != tt.pos || !strings.Contains(qe.Msg, tt.msg) {
+			t.Errorf("ParseQuery(%q) = %q at col %d, want %q at col %d", tt.query, qe.Msg, qe.Pos, tt.msg, tt.pos)
+		}
+	}
+}
+
+func TestQueryNil(t *testing.T) {
+	var q *Query
+	if !q.Match(PortInfo{Port: 1}) {
+		t.Error("nil query should match everything")
+	}
+	if q.String() != "" {
+		t.Errorf("nil query String() = %q", q.String())
+	}
+	q, err := ParseQuery("   ")
+	if err != nil || !q.Match(PortInfo{}) {
+		t.Errorf("blank query: err %v, want a query matching everything", err)
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+	}{
+		{"512", 512},
+		{"1k", 1024},
+		{"64KB", 64 << 10},
+		{"500MB", 500 << 20},
+		{"1.5G", 3 << 29},
+		{"2tb", 2 << 40},
+		{"10b", 10},
+	}
+	for _, tt := range tests {
+		if got, err := parseSize(tt.in); err != nil || got != tt.want {
+			t.Errorf("parseSize(%q) = %d, %v, want %d", tt.in, got, err, tt.want)
+		}
+	}
+	if _, err := parseSize("-1MB"); err == nil {
+		t.Error("parseSize(-1MB) succeeded")
+	}
+}
+
+func TestParseDurationSeconds(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+	}{
+		{"90", 90},
+		{"90s", 90},
+		{"1h30m", 5400},
+		{"2d", 172800},
+		{"1d12h", 129600},
+	}
+	for _, tt := range tests {
+		if got, err := parseDurationSeconds(tt.in); err != nil || got != tt.want {
+			t.Errorf("parseDurationSeconds(%q) = %d, %v, want %d", tt.in, got, err, tt.want)
+		}
+	}
+}
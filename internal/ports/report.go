@@ -0,0 +1,33 @@
+package ports
+
+import (
+	"os"
+	"time"
+)
+
+// SchemaVersion is the version of the JSON format of Report and PortInfo.
+// Fields may be added within a version; renaming or removing one, or
+// changing its type or unit, needs a new version.
+const SchemaVersion = 1
+
+// Report is the JSON document printed by `reap list --json`.
+type Report struct {
+	SchemaVersion int        `json:"schema_version"`
+	ScannedAt     time.Time  `json:"scanned_at"`
+	Host          string     `json:"host"`
+	Ports         []PortInfo `json:"ports"`
+}
+
+// NewReport wraps the results of a scan made at scannedAt.
+func NewReport(items []PortInfo, scannedAt time.Time) Report {
+	host, _ := os.Hostname()
+	if items == nil {
+		items = []PortInfo{}
+	}
+	return Report{
+		SchemaVersion: SchemaVersion,
+		ScannedAt:     scannedAt.UTC().Truncate(time.Second),
+		Host:          host,
+		Ports:         items,
+	}
+}
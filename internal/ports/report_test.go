@@ -0,0 +1,69 @@
+package ports
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewReport(t *testing.T) {
+	at := time.Date(2026, 10, 17, 10, 0, 0, 500, time.FixedZone("CEST", 2*3600))
+	r := NewReport(nil, at)
+	if r.SchemaVersion != SchemaVersion {
+		t.Errorf("schema version = %d, want %d", r.SchemaVersion, SchemaVersion)
+	}
+	if want := time.Date(2026, 10, 17, 8, 0, 0, 0, time.UTC); !r.ScannedAt.Equal(want) || r.ScannedAt.Location() != time.UTC {
+		t.Errorf("scanned at = %v, want %v", r.ScannedAt, want)
+	}
+	if r.Ports == nil {
+		t.Error("ports is nil, want an empty slice")
+	}
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"schema_version":1`, `"scanned_at":"2026-10-17T08:00:00Z"`, `"host":`, `"ports":[]`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("report JSON %s does not contain %s", data, want)
+		}
+	}
+}
+
+func TestPortInfoJSON(t *testing.T) {
+	p := PortInfo{
+		Port: 3000, PID: 42, Process: "node", Protocol: "tcp", Address: "*",
+		Uptime: "2h 15m", UptimeSeconds: 8100,
+		StartTime: time.Date(2026, 10, 17, 7, 45, 0, 0, time.UTC),
+		Memory:    "12.0 MB", RSS: 12 * 1024 * 1024,
+		ContainerInfo: &Container{ID: "abc", Name: "web", HostNetwork: true,
+			Ports: []ContainerPort{{HostIP: "0.0.0.0", HostPort: 3000, ContainerPort: 80, Protocol: "tcp"}}},
+		PortForward: &PortForward{Namespace: "default", Kind: "pod", Name: "api", RemotePort: 80},
+		Connections: []Connection{{RemoteAddr: "127.0.0.1", RemotePort: 51000, State: "ESTABLISHED", LocalPID: 7}},
+	}
+	data, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`"port":3000`, `"pid":42`, `"uptime":"2h 15m"`, `"uptime_seconds":8100`,
+		`"start_time":"2026-10-17T07:45:00Z"`, `"memory":"12.0 MB"`, `"rss_bytes":12582912`,
+		`"container_info":{"id":"abc","name":"web"`, `"host_network":true`,
+		`"ports":[{"host_ip":"0.0.0.0","host_port":3000,"container_port":80,"protocol":"tcp"}]`,
+		`"port_forward":{"namespace":"default","kind":"pod","name":"api","remote_port":80}`,
+		`"connections":[{"remote_addr":"127.0.0.1","remote_port":51000,"state":"ESTABLISHED","local_pid":7}]`,
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("JSON %s does not contain %s", data, want)
+		}
+	}
+
+	// unknown start times are omitted rather than encoded as year 1
+	data, _ = json.Marshal(PortInfo{Port: 53})
+	for _, absent := range []string{"start_time", "uptime_seconds", "container_info", "port_forward", "connections"} {
+		if strings.Contains(string(data), absent) {
+			t.Errorf("JSON %s contains %s", data, absent)
+		}
+	}
+}
@@ -1,7 +1,67 @@
 package ports
 
+import "fmt"
+
+// Socket enumeration backends accepted by NewScannerWithBackend.
+// An empty name selects the platform default.
+const (
+	BackendNetlink = "netlink" // Linux NETLINK_SOCK_DIAG, falls back to procfs
+	BackendProcfs  = "procfs"  // Linux /proc/net text parser
+)
+
 // NewScanner returns a platform-specific scanner.
 // Implemented in scanner_darwin.go, scanner_linux.go, scanner_windows.go.
 func NewScanner() Scanner {
-	return newPlatformScanner()
+	s, _ := newPlatformScanner("")
+	return s
+}
+
+// NewScannerWithBackend returns a platform-specific scanner using the named
+// socket enumeration backend. Returns an error for unknown backends or ones
+// the current platform does not support.
+func NewScannerWithBackend(backend string) (Scanner, error) {
+	return newPlatformScanner(backend)
+}
+
+// portScanner is implemented by scanners that can skip the container
+// runtime queries of Scan. Polls that only need PIDs and ports use it.
+type portScanner interface {
+	scanPorts() ([]PortInfo, error)
+}
+
+// scanPorts scans with s, without container details if s supports that.
+func scanPorts(s Scanner) ([]PortInfo, error) {
+	if ps, ok := s.(portScanner); ok {
+		return ps.scanPorts()
+	}
+	return s.Scan()
+}
+
+// WithLabels wraps s so every scanned port gets its Label from label.
+func WithLabels(s Scanner, label func(p PortInfo) string) Scanner {
+	return labelingScanner{s, label}
+}
+
+type labelingScanner struct {
+	Scanner
+	label func(p PortInfo) string
+}
+
+func (s labelingScanner) Scan() ([]PortInfo, error) {
+	return s.labeled(s.Scanner.Scan())
+}
+
+func (s labelingScanner) scanPorts() ([]PortInfo, error) {
+	return s.labeled(scanPorts(s.Scanner))
+}
+
+func (s labelingScanner) labeled(items []PortInfo, err error) ([]PortInfo, error) {
+	for i := range items {
+		items[i].Label = s.label(items[i])
+	}
+	return items, err
+}
+
+func unsupportedBackend(backend string) error {
+	return fmt.Errorf("scanner backend %q not supported on this platform", backend)
 }
@@ -5,113 +5,58 @@ package ports
 import (
 	"fmt"
 	"os/exec"
-	"strconv"
-	"strings"
+	"syscall"
 )
 
-type darwinScanner struct{}
-
-func newPlatformScanner() Scanner {
-	return &darwinScanner{}
+type darwinScanner struct {
+	containers containerEnricher
 }
 
-func (s *darwinScanner) Scan() ([]PortInfo, error) {
-	out, err := exec.Command("lsof", "-iTCP", "-sTCP:LISTEN", "-P", "-n").Output()
-	if err != nil {
-		return nil, fmt.Errorf("lsof failed: %w", err)
+func newPlatformScanner(backend string) (Scanner, error) {
+	if backend != "" {
+		return nil, unsupportedBackend(backend)
 	}
-	ports := parseLsofOutput(string(out))
-	if len(ports) > 0 {
-		enrichProcessInfo(ports)
-		enrichDockerInfo(ports)
-	}
-	return ports, nil
+	return &darwinScanner{}, nil
 }
 
-// parseLsofOutput parses lsof tabular output into PortInfo entries.
-// Deduplicates by (port, PID), preferring IPv4 over IPv6.
-func parseLsofOutput(output string) []PortInfo {
-	lines := strings.Split(strings.TrimSpace(output), "\n")
-	if len(lines) < 2 {
-		return nil
-	}
-
-	type dedupKey struct {
-		port int
-		pid  int
+// listProcesses reads the process table with ps. ps cannot report session
+// IDs, so they are looked up with getsid(2).
+func listProcesses() ([]Process, error) {
+	out, err := exec.Command("ps", "-axo", "pid=,ppid=,pgid=,comm=").Output()
+	if err != nil {
+		return nil, fmt.Errorf("ps failed: %w", err)
 	}
-	seen := make(map[dedupKey]int) // key -> index in result
-	var result []PortInfo
-
-	for _, line := range lines[1:] { // skip header
-		fields := strings.Fields(line)
-		if len(fields) < 9 {
-			continue
-		}
-
-		process := fields[0]
-		pid, err := strconv.Atoi(fields[1])
-		if err != nil {
-			continue
-		}
-		user := fields[2]
-		protocol := strings.ToLower(fields[7]) // TCP -> tcp
-
-		// NAME field is after NODE (index 8). It may be followed by "(LISTEN)".
-		name := fields[8]
-		addr, portStr := parseNameField(name)
-		port, err := strconv.Atoi(portStr)
-		if err != nil {
-			continue
+	procs := parsePsProcesses(string(out))
+	for i := range procs {
+		if sid, err := syscall.Getsid(procs[i].PID); err == nil {
+			procs[i].SID = sid
 		}
-
-		key := dedupKey{port: port, pid: pid}
-		isIPv4 := !strings.Contains(addr, ":")
-
-		if idx, exists := seen[key]; exists {
-			// prefer IPv4 over IPv6
-			if isIPv4 && strings.Contains(result[idx].Address, ":") {
-				result[idx].Address = addr
-			}
-			continue
-		}
-
-		seen[key] = len(result)
-		result = append(result, PortInfo{
-			Port:     port,
-			PID:      pid,
-			Process:  process,
-			User:     user,
-			Protocol: protocol,
-			Address:  addr,
-		})
 	}
+	return procs, nil
+}
 
-	return result
+func (s *darwinScanner) Scan() ([]PortInfo, error) {
+	return s.scan(true)
 }
 
-// parseNameField splits "addr:port" from lsof NAME column.
-// Handles IPv6 like "[::1]:8080" and IPv4 like "127.0.0.1:8080" or "*:8080".
-func parseNameField(name string) (addr, port string) {
-	// Remove any trailing state info like "(LISTEN)"
-	if idx := strings.Index(name, "("); idx != -1 {
-		name = name[:idx]
-	}
+func (s *darwinScanner) scanPorts() ([]PortInfo, error) {
+	return s.scan(false)
+}
 
-	if strings.HasPrefix(name, "[") {
-		// IPv6: [::1]:8080
-		if closeBracket := strings.LastIndex(name, "]"); closeBracket != -1 {
-			addr = name[:closeBracket+1]
-			if closeBracket+2 < len(name) {
-				port = name[closeBracket+2:] // skip ]:
-			}
-			return addr, port
-		}
+func (s *darwinScanner) scan(containers bool) ([]PortInfo, error) {
+	// -i selections are ORed; the -s state filter only narrows the TCP set.
+	// Connected TCP sockets are kept to list connections per listener.
+	out, err := exec.Command("lsof", "-iTCP", "-sTCP:^TIME_WAIT,^CLOSED", "-iUDP", "-P", "-n").Output()
+	if err != nil {
+		return nil, fmt.Errorf("lsof failed: %w", err)
 	}
-
-	// IPv4 or *: last colon separates addr:port
-	if lastColon := strings.LastIndex(name, ":"); lastColon != -1 {
-		return name[:lastColon], name[lastColon+1:]
+	ports := parseLsofOutput(string(out))
+	attachConnections(ports, parseLsofConnections(string(out)))
+	if len(ports) > 0 {
+		enrichProcessInfo(ports)
+		if containers {
+			s.containers.enrich(ports)
+		}
 	}
-	return name, ""
+	return ports, nil
 }
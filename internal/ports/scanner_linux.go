@@ -2,14 +2,57 @@
 
 package ports
 
-import "fmt"
-
-type linuxScanner struct{}
+type linuxScanner struct {
+	fs         procFS
+	backend    string
+	containers containerEnricher
+}
 
-func newPlatformScanner() Scanner {
-	return &linuxScanner{}
+func newPlatformScanner(backend string) (Scanner, error) {
+	switch backend {
+	case "":
+		backend = BackendNetlink
+	case BackendNetlink, BackendProcfs:
+	default:
+		return nil, unsupportedBackend(backend)
+	}
+	return &linuxScanner{fs: procFS{root: "/proc", cpu: newCPUTracker()}, backend: backend}, nil
 }
 
 func (s *linuxScanner) Scan() ([]PortInfo, error) {
-	return nil, fmt.Errorf("linux scanner not yet implemented")
+	return s.scan(true)
+}
+
+func (s *linuxScanner) scanPorts() ([]PortInfo, error) {
+	return s.scan(false)
+}
+
+func (s *linuxScanner) scan(containers bool) ([]PortInfo, error) {
+	sockets, err := s.listeningSockets()
+	if err != nil {
+		return nil, err
+	}
+	ports := s.fs.ownedPorts(sockets)
+	if len(ports) > 0 {
+		s.fs.enrich(ports)
+		if containers {
+			s.containers.enrich(ports)
+		}
+	}
+	return ports, nil
+}
+
+func listProcesses() ([]Process, error) {
+	return procFS{root: "/proc"}.processes()
+}
+
+// listeningSockets enumerates sockets with the configured backend.
+// The netlink backend falls back to /proc/net when sock_diag is unavailable.
+func (s *linuxScanner) listeningSockets() ([]procSocket, error) {
+	if s.backend == BackendNetlink {
+		if sockets, err := netlinkListeningSockets(); err == nil {
+			return sockets, nil
+		}
+	}
+	return s.fs.listeningSockets()
 }
@@ -1,6 +1,7 @@
 package ports
 
 import (
+	"slices"
 	"testing"
 )
 
@@ -105,66 +106,6 @@ func TestFormatElapsed(t *testing.T) {
 	}
 }
 
-func TestParseDockerPS(t *testing.T) {
-	output := `my-redis	0.0.0.0:6379->6379/tcp, :::6379->6379/tcp
-my-postgres	0.0.0.0:5432->5432/tcp
-web-app	0.0.0.0:3000->3000/tcp, 0.0.0.0:3001->3001/tcp`
-
-	m := parseDockerPS(output)
-
-	tests := []struct {
-		port int
-		want string
-	}{
-		{6379, "my-redis"},
-		{5432, "my-postgres"},
-		{3000, "web-app"},
-		{3001, "web-app"},
-	}
-
-	for _, tt := range tests {
-		got, ok := m[tt.port]
-		if !ok {
-			t.Errorf("port %d not found in docker map", tt.port)
-			continue
-		}
-		if got != tt.want {
-			t.Errorf("port %d: got %q, want %q", tt.port, got, tt.want)
-		}
-	}
-
-	if _, ok := m[9999]; ok {
-		t.Error("unexpected port 9999 in docker map")
-	}
-}
-
-func TestParseDockerPSEmpty(t *testing.T) {
-	m := parseDockerPS("")
-	if len(m) != 0 {
-		t.Errorf("expected empty map, got %d entries", len(m))
-	}
-}
-
-func TestExtractHostPort(t *testing.T) {
-	tests := []struct {
-		input string
-		want  int
-	}{
-		{"0.0.0.0:3000->3000/tcp", 3000},
-		{":::6379->6379/tcp", 6379},
-		{"127.0.0.1:5432->5432/tcp", 5432},
-		{"3000/tcp", 0},
-		{"", 0},
-	}
-
-	for _, tt := range tests {
-		got := extractHostPort(tt.input)
-		if got != tt.want {
-			t.Errorf("extractHostPort(%q) = %d, want %d", tt.input, got, tt.want)
-		}
-	}
-}
-
 func TestParseLsofCWD(t *testing.T) {
 	output := "p1234\nfcwd\nn/Users/me/projects/my-app\np5678\nfcwd\nn/opt/homebrew/var\n"
 	m := parseLsofCWD(output)
@@ -206,3 +147,140 @@ func TestFormatMemory(t *testing.T) {
 		}
 	}
 }
+
+func TestNewScannerWithBackendUnknown(t *testing.T) {
+	if _, err := NewScannerWithBackend("bogus"); err == nil {
+		t.Error("expected error for unknown backend")
+	}
+}
+
+func TestParseLsofOutputUDP(t *testing.T) {
+	output := `COMMAND     PID   USER   FD   TYPE             DEVICE SIZE/OFF NODE NAME
+mDNSRespo   300   root   6u   IPv4 0x1234567895      0t0  UDP *:5353
+node       1234   user   23u  IPv4 0x1234567890      0t0  TCP *:3000 (LISTEN)
+node       1234   user   25u  IPv4 0x1234567896      0t0  UDP *:3000
+node       1234   user   26u  IPv4 0x1234567897      0t0  UDP 127.0.0.1:50000->127.0.0.1:53
+`
+	ports := parseLsofOutput(output)
+
+	if len(ports) != 3 {
+		t.Fatalf("expected 3 ports, got %d: %+v", len(ports), ports)
+	}
+	var tcp, udp int
+	for _, p := range ports {
+		if p.Port == 50000 {
+			t.Error("connected UDP socket should be skipped")
+		}
+		switch p.Protocol {
+		case "tcp":
+			tcp++
+		case "udp":
+			udp++
+		}
+	}
+	if tcp != 1 || udp != 2 {
+		t.Errorf("expected 1 tcp and 2 udp, got %d tcp and %d udp", tcp, udp)
+	}
+}
+
+func TestParseLsofOutputSkipsNonListeningTCP(t *testing.T) {
+	output := `COMMAND     PID   USER   FD   TYPE             DEVICE SIZE/OFF NODE NAME
+node       1234   user   23u  IPv4 0x1234567890      0t0  TCP *:3000 (LISTEN)
+node       1234   user   27u  IPv4 0x1234567898      0t0  TCP 127.0.0.1:3000->127.0.0.1:54321 (ESTABLISHED)
+curl        999   user   5u   IPv4 0x1234567899      0t0  TCP 127.0.0.1:54321->127.0.0.1:3000 (ESTABLISHED)
+`
+	ports := parseLsofOutput(output)
+	if len(ports) != 1 || ports[0].Port != 3000 {
+		t.Fatalf("expected only the listener, got %+v", ports)
+	}
+}
+
+func TestParseLsofConnections(t *testing.T) {
+	output := `COMMAND     PID   USER   FD   TYPE             DEVICE SIZE/OFF NODE NAME
+node       1234   user   23u  IPv4 0x1234567890      0t0  TCP *:3000 (LISTEN)
+node       1234   user   27u  IPv4 0x1234567898      0t0  TCP 127.0.0.1:3000->127.0.0.1:54321 (ESTABLISHED)
+curl        999   user   5u   IPv4 0x1234567899      0t0  TCP 127.0.0.1:54321->127.0.0.1:3000 (ESTABLISHED)
+node       1234   user   28u  IPv6 0x1234567800      0t0  TCP [::1]:3000->[::1]:60000 (CLOSE_WAIT)
+mDNSRespo   300   root   6u   IPv4 0x1234567895      0t0  UDP *:5353
+`
+	conns := parseLsofConnections(output)
+	if len(conns) != 3 {
+		t.Fatalf("expected 3 connected sockets, got %d: %+v", len(conns), conns)
+	}
+	if conns[0].localPort != 3000 || conns[0].remotePort != 54321 || conns[0].state != "ESTABLISHED" || conns[0].pid != 1234 {
+		t.Errorf("conn 0: got %+v", conns[0])
+	}
+	if conns[1].process != "curl" || conns[1].pid != 999 {
+		t.Errorf("conn 1: got %+v", conns[1])
+	}
+	if conns[2].remoteAddr != "[::1]" || conns[2].state != "CLOSE_WAIT" {
+		t.Errorf("conn 2: got %+v", conns[2])
+	}
+
+	ports := parseLsofOutput(output)
+	attachConnections(ports, conns)
+	for _, p := range ports {
+		if p.Port != 3000 {
+			continue
+		}
+		if len(p.Connections) != 2 {
+			t.Fatalf("expected 2 connections on 3000, got %+v", p.Connections)
+		}
+		if p.Connections[0].LocalProcess != "curl" || p.Connections[0].LocalPID != 999 {
+			t.Errorf("expected curl client, got %+v", p.Connections[0])
+		}
+	}
+}
+
+type staticScanner []PortInfo
+
+func (s staticScanner) Scan() ([]PortInfo, error) { return slices.Clone(s), nil }
+
+func TestWithLabels(t *testing.T) {
+	labels := map[int]string{5432: "postgres"}
+	s := WithLabels(staticScanner{{Port: 5432}, {Port: 3000}}, func(p PortInfo) string { return labels[p.Port] })
+
+	items, err := s.Scan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if items[0].Label != "postgres" || items[1].Label != "" {
+		t.Errorf("unexpected labels: %q, %q", items[0].Label, items[1].Label)
+	}
+}
+
+// splitScanner counts full scans and scans without container details.
+type splitScanner struct {
+	ports      []PortInfo
+	full, bare int
+}
+
+func (s *splitScanner) Scan() ([]PortInfo, error) {
+	s.full++
+	return slices.Clone(s.ports), nil
+}
+
+func (s *splitScanner) scanPorts() ([]PortInfo, error) {
+	s.bare++
+	return slices.Clone(s.ports), nil
+}
+
+func TestScanPorts(t *testing.T) {
+	// scanners without the option fall back to a full scan
+	if items, err := scanPorts(staticScanner{{Port: 3000}}); err != nil || len(items) != 1 {
+		t.Errorf("fallback: got %v, %v", items, err)
+	}
+
+	split := &splitScanner{ports: []PortInfo{{Port: 5432}}}
+	s := WithLabels(split, func(p PortInfo) string { return "postgres" })
+	items, err := scanPorts(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if split.bare != 1 || split.full != 0 {
+		t.Errorf("expected WithLabels to pass the option through, got %d full and %d bare scans", split.full, split.bare)
+	}
+	if items[0].Label != "postgres" {
+		t.Errorf("expected labels on bare scans too, got %q", items[0].Label)
+	}
+}
@@ -6,8 +6,15 @@ import "fmt"
 
 type windowsScanner struct{}
 
-func newPlatformScanner() Scanner {
-	return &windowsScanner{}
+func newPlatformScanner(backend string) (Scanner, error) {
+	if backend != "" {
+		return nil, unsupportedBackend(backend)
+	}
+	return &windowsScanner{}, nil
+}
+
+func listProcesses() ([]Process, error) {
+	return nil, fmt.Errorf("process tree not yet implemented on windows")
 }
 
 func (s *windowsScanner) Scan() ([]PortInfo, error) {
@@ -0,0 +1,20 @@
+//go:build !windows
+
+package ports
+
+import "syscall"
+
+// SendSignal sends sig to pid.
+func SendSignal(pid int, sig syscall.Signal) error {
+	return syscall.Kill(pid, sig)
+}
+
+// processAlive reports whether pid exists. EPERM means it exists but
+// belongs to another user. A zombie has exited and counts as gone.
+func processAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	if err != nil && err != syscall.EPERM {
+		return false
+	}
+	return !procFS{root: "/proc"}.zombie(pid)
+}
@@ -0,0 +1,27 @@
+//go:build windows
+
+package ports
+
+import (
+	"os"
+	"syscall"
+)
+
+// SendSignal terminates pid. Windows has no signals, so every signal
+// is a hard kill.
+func SendSignal(pid int, sig syscall.Signal) error {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	return p.Kill()
+}
+
+func processAlive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	p.Release()
+	return true
+}
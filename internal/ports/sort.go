@@ -0,0 +1,104 @@
+package ports
+
+import (
+	"cmp"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SortKey is a field ports can be ordered by.
+type SortKey string
+
+const (
+	SortPort    SortKey = "port"
+	SortPID     SortKey = "pid"
+	SortProcess SortKey = "process"
+	SortUser    SortKey = "user"
+	SortMemory  SortKey = "memory" // resident memory in bytes
+	SortUptime  SortKey = "uptime" // time since the process started
+	SortCPU     SortKey = "cpu"    // CPU usage in percent
+)
+
+// SortKeys lists the keys accepted by ParseSortKey.
+var SortKeys = []SortKey{SortPort, SortPID, SortProcess, SortUser, SortMemory, SortUptime, SortCPU}
+
+var sortAliases = map[string]SortKey{
+	"mem": SortMemory, "rss": SortMemory,
+	"proc": SortProcess, "name": SortProcess,
+	"age": SortUptime,
+}
+
+// ParseSortKey parses a sort key or one of its aliases (mem, rss, proc,
+// name, age).
+func ParseSortKey(s string) (SortKey, error) {
+	s = strings.ToLower(s)
+	for _, k := range SortKeys {
+		if string(k) == s {
+			return k, nil
+		}
+	}
+	if k, ok := sortAliases[s]; ok {
+		return k, nil
+	}
+	names := make([]string, len(SortKeys))
+	for i, k := range SortKeys {
+		names[i] = string(k)
+	}
+	return "", fmt.Errorf("unknown sort key %q (available: %s)", s, strings.Join(names, ", "))
+}
+
+// Sort orders items by key, ascending unless reverse is set. Ascending
+// uptime puts the most recently started processes first. Entries whose
+// memory or start time is unknown go last in either direction, and ties
+// are broken by port and PID.
+func Sort(items []PortInfo, key SortKey, reverse bool) {
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := &items[i], &items[j]
+		knownA, knownB := sortKnown(key, a), sortKnown(key, b)
+		if knownA != knownB {
+			return knownA
+		}
+		if knownA {
+			c := compareBy(key, a, b)
+			if reverse {
+				c = -c
+			}
+			if c != 0 {
+				return c < 0
+			}
+		}
+		if a.Port != b.Port {
+			return a.Port < b.Port
+		}
+		return a.PID < b.PID
+	})
+}
+
+func sortKnown(key SortKey, p *PortInfo) bool {
+	switch key {
+	case SortMemory:
+		return p.RSS > 0
+	case SortUptime:
+		return !p.StartTime.IsZero()
+	}
+	return true
+}
+
+func compareBy(key SortKey, a, b *PortInfo) int {
+	switch key {
+	case SortPID:
+		return cmp.Compare(a.PID, b.PID)
+	case SortProcess:
+		return cmp.Or(cmp.Compare(strings.ToLower(a.Process), strings.ToLower(b.Process)), cmp.Compare(a.Process, b.Process))
+	case SortUser:
+		return cmp.Compare(a.User, b.User)
+	case SortMemory:
+		return cmp.Compare(a.RSS, b.RSS)
+	case SortUptime:
+		return b.StartTime.Compare(a.StartTime) // later start, shorter uptime
+	case SortCPU:
+		return cmp.Compare(a.CPUPercent, b.CPUPercent)
+	}
+	return cmp.Compare(a.Port, b.Port)
+}
@@ -0,0 +1,72 @@
+package ports
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSort(t *testing.T) {
+	base := time.Date(2026, 10, 17, 8, 0, 0, 0, time.UTC)
+	items := func() []PortInfo {
+		return []PortInfo{
+			{Port: 8080, PID: 30, Process: "java", User: "dev", RSS: 2 << 30, StartTime: base, CPUPercent: 12.5},
+			{Port: 3000, PID: 20, Process: "Node", User: "dev", RSS: 50 << 20, StartTime: base.Add(2 * time.Hour), CPUPercent: 99},
+			{Port: 53, PID: 10, Process: "dnsmasq", User: "root"}, // memory and start time unknown
+			{Port: 5432, PID: 40, Process: "postgres", User: "postgres", RSS: 80 << 20, StartTime: base.Add(-24 * time.Hour)},
+		}
+	}
+	tests := []struct {
+		key     SortKey
+		reverse bool
+		want    []int
+	}{
+		{SortPort, false, []int{53, 3000, 5432, 8080}},
+		{SortPort, true, []int{8080, 5432, 3000, 53}},
+		{SortPID, false, []int{53, 3000, 8080, 5432}},
+		{SortProcess, false, []int{53, 8080, 3000, 5432}}, // case-insensitive
+		{SortUser, false, []int{3000, 8080, 5432, 53}},    // ties by port
+		{SortMemory, false, []int{3000, 5432, 8080, 53}},  // unknown last
+		{SortMemory, true, []int{8080, 5432, 3000, 53}},   // unknown still last
+		{SortUptime, false, []int{3000, 8080, 5432, 53}},  // newest first
+		{SortUptime, true, []int{5432, 8080, 3000, 53}},
+		{SortCPU, true, []int{3000, 8080, 53, 5432}}, // idle ties by port
+	}
+	for _, tt := range tests {
+		got := items()
+		Sort(got, tt.key, tt.reverse)
+		for i, p := range got {
+			if p.Port != tt.want[i] {
+				var order []int
+				for _, p := range got {
+					order = append(order, p.Port)
+				}
+				t.Errorf("Sort(%s, reverse=%v) = %v, want %v", tt.key, tt.reverse, order, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestParseSortKey(t *testing.T) {
+	tests := []struct {
+		in   string
+		want SortKey
+	}{
+		{"port", SortPort},
+		{"PID", SortPID},
+		{"mem", SortMemory},
+		{"rss", SortMemory},
+		{"memory", SortMemory},
+		{"age", SortUptime},
+		{"name", SortProcess},
+		{"cpu", SortCPU},
+	}
+	for _, tt := range tests {
+		if got, err := ParseSortKey(tt.in); err != nil || got != tt.want {
+			t.Errorf("ParseSortKey(%q) = %q, %v, want %q", tt.in, got, err, tt.want)
+		}
+	}
+	if _, err := ParseSortKey("colour"); err == nil {
+		t.Error("ParseSortKey(colour) succeeded")
+	}
+}
@@ -0,0 +1,162 @@
+package ports
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// Process is an entry of the system process table.
+type Process struct {
+	PID  int
+	PPID int
+	PGID int // process group
+	SID  int // session
+	Name string
+}
+
+// TreeScope selects which processes related to a root are killed together.
+type TreeScope int
+
+const (
+	ScopeDescendants TreeScope = iota // root and all its descendants
+	ScopeGroup                        // plus root's process group and its descendants
+	ScopeSession                      // plus root's session and its descendants
+)
+
+func (s TreeScope) String() string {
+	switch s {
+	case ScopeGroup:
+		return "process group"
+	case ScopeSession:
+		return "session"
+	default:
+		return "descendants"
+	}
+}
+
+// ListProcesses returns a snapshot of the system process table.
+func ListProcesses() ([]Process, error) {
+	return listProcesses()
+}
+
+// CollectTree returns root and the processes related to it by scope,
+// ordered leaves-first so that children are signalled before the parents
+// that would respawn them. reap itself, its ancestors and PID 1 are never
+// included.
+func CollectTree(procs []Process, root int, scope TreeScope) []Process {
+	return collectTree(procs, root, scope, os.Getpid())
+}
+
+func collectTree(procs []Process, root int, scope TreeScope, self int) []Process {
+	byPID := make(map[int]Process, len(procs))
+	children := make(map[int][]int)
+	for _, p := range procs {
+		byPID[p.PID] = p
+		children[p.PPID] = append(children[p.PPID], p.PID)
+	}
+	rootProc, ok := byPID[root]
+	if !ok {
+		return nil
+	}
+
+	protected := map[int]bool{0: true, 1: true}
+	for pid := self; pid > 1 && !protected[pid]; pid = byPID[pid].PPID {
+		protected[pid] = true
+	}
+
+	selected := make(map[int]bool)
+	var walk func(pid int)
+	walk = func(pid int) {
+		if selected[pid] || protected[pid] {
+			return
+		}
+		selected[pid] = true
+		for _, child := range children[pid] {
+			walk(child)
+		}
+	}
+	walk(root)
+
+	// An ID of 0 means it is unknown (ps on macOS has no session column),
+	// not that every process with 0 belongs with root.
+	for _, p := range procs {
+		switch {
+		case scope == ScopeGroup && rootProc.PGID != 0 && p.PGID == rootProc.PGID,
+			scope == ScopeSession && rootProc.SID != 0 && p.SID == rootProc.SID:
+			walk(p.PID)
+		}
+	}
+
+	depth := make(map[int]int)
+	var depthOf func(pid int, seen int) int
+	depthOf = func(pid int, seen int) int {
+		if d, ok := depth[pid]; ok {
+			return d
+		}
+		p, ok := byPID[pid]
+		if !ok || p.PPID == pid || seen > len(procs) {
+			return 0
+		}
+		d := depthOf(p.PPID, seen+1) + 1
+		depth[pid] = d
+		return d
+	}
+
+	result := make([]Process, 0, len(selected))
+	for pid := range selected {
+		result = append(result, byPID[pid])
+	}
+	sort.Slice(result, func(i, j int) bool {
+		di, dj := depthOf(result[i].PID, 0), depthOf(result[j].PID, 0)
+		if di != dj {
+			return di > dj
+		}
+		return result[i].PID > result[j].PID
+	})
+	return result
+}
+
+// SignalProcesses sends sig to each process in order and returns how many
+// were signalled. Processes that already exited are not errors.
+func SignalProcesses(procs []Process, sig syscall.Signal) (int, error) {
+	var errs []error
+	sent := 0
+	for _, p := range procs {
+		err := SendSignal(p.PID, sig)
+		switch {
+		case err == nil:
+			sent++
+		case errors.Is(err, syscall.ESRCH), errors.Is(err, os.ErrProcessDone):
+		default:
+			errs = append(errs, fmt.Errorf("PID %d: %w", p.PID, err))
+		}
+	}
+	return sent, errors.Join(errs...)
+}
+
+// parsePsProcesses parses `ps -axo pid=,ppid=,pgid=,comm=` output. comm may
+// be a full path containing spaces, so it is everything after the third field.
+func parsePsProcesses(output string) []Process {
+	var procs []Process
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			continue
+		}
+		pid, err1 := strconv.Atoi(fields[0])
+		ppid, err2 := strconv.Atoi(fields[1])
+		pgid, err3 := strconv.Atoi(fields[2])
+		if err1 != nil || err2 != nil || err3 != nil {
+			continue
+		}
+		name := filepath.Base(strings.Join(fields[3:], " "))
+		procs = append(procs, Process{PID: pid, PPID: ppid, PGID: pgid, Name: name})
+	}
+	return procs
+}
@@ -0,0 +1,198 @@
+package ports
+
+import (
+	"os/exec"
+	"syscall"
+	"testing"
+)
+
+// mockProcessTable models `npm run dev` started from a shell:
+//
+//	1 init
+//	└─ 100 bash (session leader)
+//	   └─ 200 npm (group 200)
+//	      ├─ 300 sh (group 200)
+//	      │  └─ 400 node (group 200)
+//	      │     └─ 500 esbuild (group 200)
+//	      └─ 310 node (own process group)
+//	└─ 600 tmux (session 600)
+//	   └─ 700 reap (self)
+var mockProcessTable = []Process{
+	{PID: 1, PPID: 0, PGID: 1, SID: 1, Name: "init"},
+	{PID: 100, PPID: 1, PGID: 100, SID: 100, Name: "bash"},
+	{PID: 200, PPID: 100, PGID: 200, SID: 100, Name: "npm"},
+	{PID: 300, PPID: 200, PGID: 200, SID: 100, Name: "sh"},
+	{PID: 400, PPID: 300, PGID: 200, SID: 100, Name: "node"},
+	{PID: 500, PPID: 400, PGID: 200, SID: 100, Name: "esbuild"},
+	{PID: 310, PPID: 200, PGID: 310, SID: 100, Name: "node"},
+	{PID: 600, PPID: 1, PGID: 600, SID: 600, Name: "tmux"},
+	{PID: 700, PPID: 600, PGID: 700, SID: 600, Name: "reap"},
+}
+
+func treePIDs(procs []Process) []int {
+	pids := make([]int, len(procs))
+	for i, p := range procs {
+		pids[i] = p.PID
+	}
+	return pids
+}
+
+func equalPIDs(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestCollectTree(t *testing.T) {
+	tests := []struct {
+		name  string
+		root  int
+		scope TreeScope
+		want  []int
+	}{
+		{"descendants leaves first", 300, ScopeDescendants, []int{500, 400, 300}},
+		{"leaf only", 500, ScopeDescendants, []int{500}},
+		{"whole npm tree", 200, ScopeDescendants, []int{500, 400, 310, 300, 200}},
+		// The group of node 400 includes npm and sh but not 310, which
+		// moved to its own group.
+		{"process group", 400, ScopeGroup, []int{500, 400, 310, 300, 200}},
+		{"group of detached child", 310, ScopeGroup, []int{310}},
+		// The session includes the shell but never init.
+		{"session", 500, ScopeSession, []int{500, 400, 310, 300, 200, 100}},
+		{"unknown root", 999, ScopeDescendants, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := treePIDs(collectTree(mockProcessTable, tt.root, tt.scope, 700))
+			if !equalPIDs(got, tt.want) {
+				t.Errorf("collectTree(%d, %s) = %v, want %v", tt.root, tt.scope, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCollectTreeProtectsSelfAndAncestors(t *testing.T) {
+	// Killing tmux's tree must not include reap or tmux itself, which is
+	// reap's ancestor.
+	if got := collectTree(mockProcessTable, 600, ScopeDescendants, 700); len(got) != 0 {
+		t.Errorf("expected reap and its ancestors to be protected, got %v", treePIDs(got))
+	}
+	if got := collectTree(mockProcessTable, 1, ScopeDescendants, 700); len(got) != 0 {
+		t.Errorf("expected PID 1 to be protected, got %v", treePIDs(got))
+	}
+}
+
+func TestCollectTreeUnknownIDs(t *testing.T) {
+	// ps on macOS reports no session, and a failed read leaves the group
+	// at 0: unrelated processes must not join the tree through the 0.
+	procs := []Process{
+		{PID: 100, PPID: 1, Name: "bash"},
+		{PID: 200, PPID: 100, Name: "node"},
+		{PID: 300, PPID: 200, Name: "esbuild"},
+		{PID: 400, PPID: 1, Name: "postgres"},
+		{PID: 500, PPID: 1, PGID: 500, Name: "redis"},
+	}
+	for _, scope := range []TreeScope{ScopeGroup, ScopeSession} {
+		got := treePIDs(collectTree(procs, 200, scope, 999))
+		if !equalPIDs(got, []int{300, 200}) {
+			t.Errorf("%s with unknown IDs = %v, want only the descendants", scope, got)
+		}
+	}
+}
+
+func TestCollectTreeCycle(t *testing.T) {
+	// A corrupt snapshot (PID reuse between reads) must not loop forever.
+	procs := []Process{
+		{PID: 10, PPID: 20, Name: "a"},
+		{PID: 20, PPID: 10, Name: "b"},
+	}
+	got := collectTree(procs, 10, ScopeDescendants, 999)
+	if len(got) != 2 {
+		t.Errorf("expected both processes, got %v", treePIDs(got))
+	}
+}
+
+func TestTreeScopeString(t *testing.T) {
+	tests := []struct {
+		scope TreeScope
+		want  string
+	}{
+		{ScopeDescendants, "descendants"},
+		{ScopeGroup, "process group"},
+		{ScopeSession, "session"},
+	}
+	for _, tt := range tests {
+		if got := tt.scope.String(); got != tt.want {
+			t.Errorf("%d.String() = %q, want %q", tt.scope, got, tt.want)
+		}
+	}
+}
+
+func TestParsePsProcesses(t *testing.T) {
+	output := `    1     0     1 /sbin/launchd
+  512     1   512 /usr/libexec/logd
+ 4242  4000  4000 /Applications/Visual Studio Code.app/Contents/MacOS/Electron
+ bogus line
+`
+	procs := parsePsProcesses(output)
+	if len(procs) != 3 {
+		t.Fatalf("expected 3 processes, got %d: %+v", len(procs), procs)
+	}
+	want := Process{PID: 4242, PPID: 4000, PGID: 4000, Name: "Electron"}
+	if procs[2] != want {
+		t.Errorf("got %+v, want %+v", procs[2], want)
+	}
+	if procs[0].Name != "launchd" {
+		t.Errorf("expected base name, got %q", procs[0].Name)
+	}
+}
+
+func TestProcFSProcesses(t *testing.T) {
+	fp := newFakeProc(t)
+	fp.write("100/stat", "100 (npm) S 1 100 100 0 -1 4194560 100 0 0 0 1 1 0 0 20 0 1 0 500 1000000 250\n")
+	fp.write("200/stat", "200 (node server) S 100 100 90 0 -1 4194560 100 0 0 0 1 1 0 0 20 0 1 0 600 1000000 250\n")
+	fp.write("300/stat", "garbage")
+	fp.write("self/stat", "ignored")
+
+	procs, err := procFS{root: fp.root}.processes()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(procs) != 2 {
+		t.Fatalf("expected 2 processes, got %+v", procs)
+	}
+	want := Process{PID: 200, PPID: 100, PGID: 100, SID: 90, Name: "node server"}
+	if procs[1] != want {
+		t.Errorf("got %+v, want %+v", procs[1], want)
+	}
+}
+
+func TestSignalProcesses(t *testing.T) {
+	cmd := exec.Command("sleep", "30")
+	if err := cmd.Start(); err != nil {
+		t.Skipf("cannot start sleep: %v", err)
+	}
+	done := make(chan struct{})
+	go func() {
+		cmd.Wait()
+		close(done)
+	}()
+
+	// The exited PID is skipped without an error.
+	procs := []Process{{PID: 1 << 30, Name: "gone"}, {PID: cmd.Process.Pid, Name: "sleep"}}
+	sent, err := SignalProcesses(procs, syscall.SIGTERM)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sent != 1 {
+		t.Errorf("expected 1 signalled process, got %d", sent)
+	}
+	<-done
+}
@@ -1,19 +1,36 @@
 package ports
 
-// PortInfo represents a listening port and its associated process.
+import "time"
+
+// PortInfo represents a listening port and its associated process. The JSON
+// names are part of the versioned schema, see SchemaVersion.
 type PortInfo struct {
-	Port      int
-	PID       int
-	PPID      int    // parent process ID
-	Process   string
-	User      string
-	Command   string
-	Protocol  string
-	Address   string
-	Uptime    string
-	Memory    string // human-readable, e.g. "12.3 MB"
-	Container string // Docker container name, empty if not in Docker
-	CWD       string // working directory of the process
+	Port          int       `json:"port"`
+	PID           int       `json:"pid"`
+	PPID          int       `json:"ppid"` // parent process ID
+	Process       string    `json:"process"`
+	User          string    `json:"user"`
+	Command       string    `json:"command"`
+	Protocol      string    `json:"protocol"`
+	Address       string    `json:"address"`
+	Uptime        string    `json:"uptime"`                  // human-readable, e.g. "2h 15m"
+	UptimeSeconds int64     `json:"uptime_seconds,omitzero"` // at scan time, 0 if unknown
+	StartTime     time.Time `json:"start_time,omitzero"`     // zero if unknown
+	Memory        string    `json:"memory"`                  // human-readable, e.g. "12.3 MB"
+	RSS           int64     `json:"rss_bytes"`               // resident set size in bytes, 0 if unknown
+	CPUPercent    float64   `json:"cpu_percent"`             // of one core since the previous scan, or since start on the first
+	Threads       int       `json:"threads"`                 // 0 if unknown
+	FDs           int       `json:"fds"`                     // open file descriptors, 0 if unknown
+	ReadBytes     int64     `json:"read_bytes"`              // bytes read from storage since start, 0 if unknown
+	WriteBytes    int64     `json:"write_bytes"`             // bytes written to storage since start, 0 if unknown
+	Container     string    `json:"container"`               // container name or port-forward target, empty if neither
+	CWD           string    `json:"cwd"`                     // working directory of the process
+	Label         string    `json:"label"`                   // service label from the config or the catalog, empty if unknown
+
+	ContainerInfo *Container   `json:"container_info,omitempty"` // details of the owning container, nil if not in a container
+	PortForward   *PortForward `json:"port_forward,omitempty"`   // set for kubectl port-forward processes
+
+	Connections []Connection `json:"connections,omitempty"` // established connections to this listener (TCP only)
 }
 
 // Scanner discovers listening ports on the system.
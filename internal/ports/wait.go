@@ -0,0 +1,89 @@
+package ports
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// WaitCondition is the state Wait waits for.
+type WaitCondition string
+
+const (
+	WaitListening WaitCondition = "listening"
+	WaitFree      WaitCondition = "free"
+)
+
+// ParseWaitCondition validates a user-supplied condition.
+func ParseWaitCondition(s string) (WaitCondition, error) {
+	switch c := WaitCondition(s); c {
+	case WaitListening, WaitFree:
+		return c, nil
+	}
+	return "", fmt.Errorf("unknown condition %q (want listening or free)", s)
+}
+
+// WaitTarget is a port to wait for. An empty protocol matches both.
+type WaitTarget struct {
+	Port     int
+	Protocol string
+}
+
+func (t WaitTarget) String() string {
+	if t.Protocol == "" {
+		return strconv.Itoa(t.Port)
+	}
+	return strconv.Itoa(t.Port) + "/" + t.Protocol
+}
+
+// Wait scans immediately and then every interval until every target meets
+// cond. For WaitListening only ports that pass filter count, and the
+// listeners found are returned. A port is free only when nothing at all
+// holds it, so filter is ignored for WaitFree. Container runtimes are only
+// queried when the filter may look at containers. It returns ctx.Err()
+// when ctx ends first, and stops at the first scan error.
+func Wait(ctx context.Context, scanner Scanner, interval time.Duration, targets []WaitTarget, cond WaitCondition, filter Filter) ([]PortInfo, error) {
+	scan := func() ([]PortInfo, error) { return scanPorts(scanner) }
+	if cond == WaitListening && (filter.Container != "" || filter.Query != nil) {
+		scan = scanner.Scan
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		results, err := scan()
+		if err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		if cond == WaitListening {
+			results = filter.Apply(results)
+		}
+		if found, ok := waitMet(results, targets, cond); ok {
+			return found, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// waitMet reports whether every target meets cond in results, and returns
+// the listeners on the targets.
+func waitMet(results []PortInfo, targets []WaitTarget, cond WaitCondition) ([]PortInfo, bool) {
+	var found []PortInfo
+	for _, t := range targets {
+		var listeners []PortInfo
+		for _, p := range results {
+			if p.Port == t.Port && (t.Protocol == "" || p.Protocol == t.Protocol) {
+				listeners = append(listeners, p)
+			}
+		}
+		if (cond == WaitListening) != (len(listeners) > 0) {
+			return nil, false
+		}
+		found = append(found, listeners...)
+	}
+	return found, true
+}
@@ -0,0 +1,116 @@
+package ports
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWaitListening(t *testing.T) {
+	node := PortInfo{Port: 3000, PID: 10, Process: "node", Protocol: "tcp"}
+	api := PortInfo{Port: 8080, PID: 20, Process: "java", Protocol: "tcp"}
+	scanner := &sequenceScanner{
+		scans: [][]PortInfo{{}, {node}, {node, api}},
+		errs:  []error{nil, nil, nil},
+	}
+	targets := []WaitTarget{{Port: 3000}, {Port: 8080, Protocol: "tcp"}}
+
+	found, err := Wait(context.Background(), scanner, time.Millisecond, targets, WaitListening, Filter{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(found) != 2 || found[0].PID != 10 || found[1].PID != 20 {
+		t.Errorf("unexpected listeners %+v", found)
+	}
+}
+
+func TestWaitFree(t *testing.T) {
+	node := PortInfo{Port: 3000, PID: 10, Process: "node", Protocol: "tcp"}
+	dns := PortInfo{Port: 3000, PID: 30, Process: "dnsmasq", Protocol: "udp"}
+	scanner := &sequenceScanner{
+		scans: [][]PortInfo{{node, dns}, {dns}},
+		errs:  []error{nil, nil},
+	}
+	// the udp socket on the same port does not matter for 3000/tcp
+	_, err := Wait(context.Background(), scanner, time.Millisecond, []WaitTarget{{Port: 3000, Protocol: "tcp"}}, WaitFree, Filter{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestWaitFilter(t *testing.T) {
+	other := PortInfo{Port: 3000, PID: 10, Process: "python3", Protocol: "tcp"}
+	scanner := &sequenceScanner{scans: [][]PortInfo{{other}}, errs: []error{nil}}
+	targets := []WaitTarget{{Port: 3000}}
+
+	// another process on the port does not count as listening...
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := Wait(ctx, scanner, time.Millisecond, targets, WaitListening, Filter{Name: "node"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected timeout, got %v", err)
+	}
+	// ...but still holds the port, so it is not free
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := Wait(ctx, scanner, time.Millisecond, targets, WaitFree, Filter{Name: "node"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected timeout while python3 holds the port, got %v", err)
+	}
+}
+
+func TestWaitSkipsContainers(t *testing.T) {
+	db := PortInfo{Port: 5432, PID: 10, Process: "docker-proxy", Protocol: "tcp", Container: "db"}
+	tests := []struct {
+		name     string
+		cond     WaitCondition
+		filter   Filter
+		wantFull bool
+	}{
+		{"listening", WaitListening, Filter{}, false},
+		{"listening by name", WaitListening, Filter{Name: "docker"}, false},
+		{"listening by container", WaitListening, Filter{Container: "db"}, true},
+		{"listening by query", WaitListening, Filter{Query: &Query{}}, true},
+		{"free ignores the filter", WaitFree, Filter{Container: "db"}, false},
+	}
+	for _, tt := range tests {
+		s := &splitScanner{ports: []PortInfo{db}}
+		target := WaitTarget{Port: 5432}
+		if tt.cond == WaitFree {
+			target.Port = 6379
+		}
+		if _, err := Wait(context.Background(), s, time.Millisecond, []WaitTarget{target}, tt.cond, tt.filter); err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+		}
+		if (s.full > 0) != tt.wantFull || (s.bare > 0) == tt.wantFull {
+			t.Errorf("%s: got %d full and %d bare scans", tt.name, s.full, s.bare)
+		}
+	}
+}
+
+func TestWaitScanError(t *testing.T) {
+	scanner := &sequenceScanner{scans: [][]PortInfo{nil}, errs: []error{errors.New("lsof failed")}}
+	_, err := Wait(context.Background(), scanner, time.Millisecond, []WaitTarget{{Port: 3000}}, WaitListening, Filter{})
+	if err == nil || errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected scan error, got %v", err)
+	}
+}
+
+func TestParseWaitCondition(t *testing.T) {
+	for _, s := range []string{"listening", "free"} {
+		if _, err := ParseWaitCondition(s); err != nil {
+			t.Errorf("ParseWaitCondition(%q): %v", s, err)
+		}
+	}
+	if _, err := ParseWaitCondition("open"); err == nil {
+		t.Error("expected error for unknown condition")
+	}
+}
+
+func TestWaitTargetString(t *testing.T) {
+	if got := (WaitTarget{Port: 53, Protocol: "udp"}).String(); got != "53/udp" {
+		t.Errorf("got %q", got)
+	}
+	if got := (WaitTarget{Port: 3000}).String(); got != "3000" {
+		t.Errorf("got %q", got)
+	}
+}
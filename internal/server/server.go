@@ -0,0 +1,369 @@
+// Package server exposes the port inventory over a local HTTP/JSON API.
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/legostin/reap/internal/ports"
+)
+
+// Options configures a Server.
+type Options struct {
+	Token            string        // required as a bearer token when set; kill requests need one
+	ReadOnly         bool          // reject kill requests
+	AnyHost          bool          // accept any Host header, not just localhost names
+	Interval         time.Duration // how often event streams rescan
+	Grace            time.Duration // grace period of escalating kills
+	ContainerTimeout time.Duration // how long a container gets to stop
+}
+
+// Server serves the API. Every request scans afresh; nothing is cached
+// between requests.
+type Server struct {
+	scanner ports.Scanner
+	opts    Options
+	killer  *ports.Killer
+
+	signal          func(pid int, sig syscall.Signal) error
+	containerAction func(ct *ports.Container, action ports.ContainerAction, timeout time.Duration) error
+}
+
+// New returns a Server that answers from scanner.
+func New(scanner ports.Scanner, opts Options) *Server {
+	if opts.Interval <= 0 {
+		opts.Interval = 2 * time.Second
+	}
+	return &Server{
+		scanner:         scanner,
+		opts:            opts,
+		killer:          ports.NewKiller(scanner, opts.Grace),
+		signal:          ports.SendSignal,
+		containerAction: ports.ApplyContainerAction,
+	}
+}
+
+// Handler returns the API routes:
+//
+//	GET  /api/v1/ports           list ports, filtered by ?port=, ?name=, ?container=, ?proto= and ?q=
+//	GET  /api/v1/ports/{port}    entries on one port
+//	POST /api/v1/pids/{pid}/kill kill a process, or stop its container
+//	GET  /api/v1/events          server-sent events for opened and closed ports
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/ports", s.listPorts)
+	mux.HandleFunc("GET /api/v1/ports/{port}", s.getPort)
+	mux.HandleFunc("POST /api/v1/pids/{pid}/kill", s.kill)
+	mux.HandleFunc("GET /api/v1/events", s.events)
+	return s.checkHost(s.authenticate(mux))
+}
+
+// checkHost rejects requests whose Host header does not name this machine,
+// so a web page cannot reach the API through DNS rebinding.
+func (s *Server) checkHost(next http.Handler) http.Handler {
+	if s.opts.AnyHost {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !localHost(r.Host) {
+			writeError(w, http.StatusForbidden, fmt.Errorf("host %q not allowed", r.Host))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// localHost reports whether host, with or without a port, is localhost or
+// a loopback address.
+func localHost(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.Trim(host, "[]")
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// authenticate checks the bearer token. EventSource clients cannot set
+// headers, so ?token= is accepted as well.
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	if s.opts.Token == "" {
+		return next
+	}
+	want := []byte(s.opts.Token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok {
+			token = r.URL.Query().Get("token")
+		}
+		if subtle.ConstantTimeCompare([]byte(token), want) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, http.StatusUnauthorized, errors.New("missing or invalid token"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) listPorts(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseFilter(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	results, err := s.scanner.Scan()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("scan failed: %w", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, nonNil(filter.Apply(results)))
+}
+
+func (s *Server) getPort(w http.ResponseWriter, r *http.Request) {
+	port, err := strconv.Atoi(r.PathValue("port"))
+	if err != nil || port < 1 || port > 65535 {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid port %q", r.PathValue("port")))
+		return
+	}
+	filter, err := parseFilter(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	filter.Port = port
+	results, err := s.scanner.Scan()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("scan failed: %w", err))
+		return
+	}
+	matched := filter.Apply(results)
+	if len(matched) == 0 {
+		writeError(w, http.StatusNotFound, fmt.Errorf("nothing is listening on port %d", port))
+		return
+	}
+	writeJSON(w, http.StatusOK, matched)
+}
+
+// killRequest is the optional body of a kill request.
+type killRequest struct {
+	Force           bool   `json:"force"`            // SIGKILL instead of SIGTERM
+	Escalate        bool   `json:"escalate"`         // SIGTERM, then SIGKILL after the grace period
+	ContainerAction string `json:"container_action"` // for container ports; default stop
+}
+
+// killResponse reports what was done.
+type killResponse struct {
+	PID       int    `json:"pid"`
+	Process   string `json:"process,omitempty"`
+	Signal    string `json:"signal,omitempty"`
+	Container string `json:"container,omitempty"`
+	Action    string `json:"action,omitempty"`
+	Result    string `json:"result"`
+}
+
+func (s *Server) kill(w http.ResponseWriter, r *http.Request) {
+	if s.opts.ReadOnly {
+		writeError(w, http.StatusForbidden, errors.New("server is read-only"))
+		return
+	}
+	if s.opts.Token == "" {
+		writeError(w, http.StatusForbidden, errors.New("kill requests need a token: start the server with --token or $REAP_TOKEN"))
+		return
+	}
+	// A JSON content type cannot be sent cross-origin without a preflight,
+	// so web pages cannot post kill requests.
+	if mt, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mt != "application/json" {
+		writeError(w, http.StatusUnsupportedMediaType, errors.New("Content-Type must be application/json"))
+		return
+	}
+	pid, err := strconv.Atoi(r.PathValue("pid"))
+	if err != nil || pid < 1 {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid pid %q", r.PathValue("pid")))
+		return
+	}
+	var req killRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid body: %w", err))
+		return
+	}
+	if req.Force && req.Escalate {
+		writeError(w, http.StatusBadRequest, errors.New("force and escalate cannot be combined"))
+		return
+	}
+	action := ports.ContainerStop
+	if req.ContainerAction != "" {
+		if action, err = ports.ParseContainerAction(req.ContainerAction); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+	if pid == 1 || pid == os.Getpid() || pid == os.Getppid() {
+		writeError(w, http.StatusForbidden, fmt.Errorf("refusing to kill PID %d", pid))
+		return
+	}
+
+	// Only processes that own a listening port can be killed through the
+	// API, so it cannot be used to signal arbitrary processes.
+	results, err := s.scanner.Scan()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("scan failed: %w", err))
+		return
+	}
+	var target *ports.PortInfo
+	for i := range results {
+		if results[i].PID == pid {
+			target = &results[i]
+			break
+		}
+	}
+	if target == nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("PID %d does not own a listening port", pid))
+		return
+	}
+
+	resp := killResponse{PID: pid, Process: target.Process}
+	switch {
+	case target.ContainerInfo != nil:
+		// The PID is a runtime proxy; signalling it would break the
+		// runtime, not stop the service.
+		resp.Container, resp.Action = target.Container, string(action)
+		if err := s.containerAction(target.ContainerInfo, action, s.opts.ContainerTimeout); err != nil {
+			writeError(w, http.StatusBadGateway, fmt.Errorf("failed to %s container %s: %w", action, target.Container, err))
+			return
+		}
+		resp.Result = fmt.Sprintf("%s container %s", action.Done(), target.Container)
+	case req.Escalate:
+		res := s.killer.Escalate(*target, nil)
+		resp.Signal = signalName(res.Signal)
+		if res.Err != nil {
+			writeError(w, http.StatusInternalServerError, errors.New(res.Describe()))
+			return
+		}
+		resp.Result = res.Describe()
+	default:
+		sig := syscall.SIGTERM
+		if req.Force {
+			sig = syscall.SIGKILL
+		}
+		resp.Signal = signalName(sig)
+		if err := s.signal(pid, sig); err != nil {
+			writeError(w, http.StatusInternalServerError, fmt.Errorf("failed to kill PID %d: %w", pid, err))
+			return
+		}
+		resp.Result = fmt.Sprintf("sent %s to %s (PID %d)", resp.Signal, target.Process, pid)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// events streams an initial "snapshot" event with the filtered port list,
+// then one event per change named after its kind: "opened", "closed" or
+// "owner". Scan failures are sent as "error" events and do not end the
+// stream.
+func (s *Server) events(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseFilter(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, errors.New("streaming unsupported"))
+		return
+	}
+	results, err := s.scanner.Scan()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("scan failed: %w", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	prev := filter.Apply(results)
+	writeEvent(w, "snapshot", nonNil(prev))
+	flusher.Flush()
+
+	ports.Watch(r.Context(), s.scanner, s.opts.Interval, filter, prev, func(changes []ports.Change, err error) {
+		if err != nil {
+			writeEvent(w, "error", apiError{Error: "scan failed: " + err.Error()})
+		}
+		for _, c := range changes {
+			writeEvent(w, string(c.Kind), c)
+		}
+		flusher.Flush()
+	})
+}
+
+// parseFilter reads ?port=, ?name=, ?container=, ?proto= and ?q=, a query
+// in the syntax of ports.ParseQuery.
+func parseFilter(r *http.Request) (ports.Filter, error) {
+	q := r.URL.Query()
+	f := ports.Filter{Name: q.Get("name"), Container: q.Get("container"), Protocol: strings.ToLower(q.Get("proto"))}
+	if v := q.Get("port"); v != "" {
+		port, err := strconv.Atoi(v)
+		if err != nil {
+			return f, fmt.Errorf("invalid port %q", v)
+		}
+		f.Port = port
+	}
+	if f.Protocol != "" && f.Protocol != "tcp" && f.Protocol != "udp" {
+		return f, fmt.Errorf("unknown protocol %q", f.Protocol)
+	}
+	if v := q.Get("q"); v != "" {
+		query, err := ports.ParseQuery(v)
+		if err != nil {
+			return f, fmt.Errorf("invalid query: %w", err)
+		}
+		f.Query = query
+	}
+	return f, nil
+}
+
+func signalName(sig syscall.Signal) string {
+	if sig == syscall.SIGKILL {
+		return "SIGKILL"
+	}
+	return "SIGTERM"
+}
+
+// nonNil makes an empty result encode as [] rather than null.
+func nonNil(items []ports.PortInfo) []ports.PortInfo {
+	if items == nil {
+		return []ports.PortInfo{}
+	}
+	return items
+}
+
+type apiError struct {
+	Error string `json:"error"`
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, apiError{Error: err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeEvent(w io.Writer, event string, v any) {
+	data, _ := json.Marshal(v)
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+}
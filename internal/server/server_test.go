@@ -0,0 +1,487 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/legostin/reap/internal/ports"
+)
+
+// mockScanner returns ports, which tests may change between scans.
+type mockScanner struct {
+	mu    sync.Mutex
+	ports []ports.PortInfo
+	err   error
+}
+
+func (m *mockScanner) Scan() ([]ports.PortInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]ports.PortInfo(nil), m.ports...), m.err
+}
+
+func (m *mockScanner) set(p []ports.PortInfo) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ports = p
+}
+
+// testPID is above any pid_max, so nothing is signalled by accident.
+const testPID = 1 << 30
+
+func testPorts() []ports.PortInfo {
+	return []ports.PortInfo{
+		{Port: 3000, PID: testPID, Process: "node", Protocol: "tcp", Address: "*"},
+		{Port: 5353, PID: testPID + 1, Process: "mDNSResponder", Protocol: "udp", Address: "*"},
+		{Port: 8080, PID: testPID + 2, Process: "docker-proxy", Protocol: "tcp", Address: "*", Container: "web",
+			ContainerInfo: &ports.Container{ID: "aaa111", Name: "web"}},
+	}
+}
+
+// signalRecorder replaces ports.SendSignal in tests.
+type signalRecorder struct {
+	mu   sync.Mutex
+	sent []string
+	err  error
+}
+
+func (s *signalRecorder) signal(pid int, sig syscall.Signal) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sent = append(s.sent, sig.String())
+	return s.err
+}
+
+func newTestServer(t *testing.T, scanner ports.Scanner, opts Options) (*httptest.Server, *signalRecorder, *[]string) {
+	t.Helper()
+	s := New(scanner, opts)
+	rec := &signalRecorder{}
+	s.signal = rec.signal
+	var actions []string
+	s.containerAction = func(ct *ports.Container, action ports.ContainerAction, _ time.Duration) error {
+		actions = append(actions, string(action)+" "+ct.ID)
+		return nil
+	}
+	srv := httptest.NewServer(s.Handler())
+	t.Cleanup(srv.Close)
+	return srv, rec, &actions
+}
+
+// do sends a request; POST bodies are sent as JSON.
+func do(t *testing.T, method, url, token, body string) (*http.Response, []byte) {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if method == "POST" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return send(t, req)
+}
+
+func send(t *testing.T, req *http.Request) (*http.Response, []byte) {
+	t.Helper()
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	return resp, data
+}
+
+func TestListPorts(t *testing.T) {
+	srv, _, _ := newTestServer(t, &mockScanner{ports: testPorts()}, Options{})
+
+	tests := []struct {
+		query string
+		want  []int
+	}{
+		{"", []int{3000, 5353, 8080}},
+		{"?proto=udp", []int{5353}},
+		{"?name=NODE", []int{3000}},
+		{"?port=8080", []int{8080}},
+		{"?container=WEB", []int{8080}},
+		{"?port=1", []int{}},
+		{"?q=port:3000-9000+-container:*", []int{3000, 5353}},
+		{"?q=proto:udp+OR+port:8080", []int{5353, 8080}},
+	}
+	for _, tt := range tests {
+		resp, body := do(t, "GET", srv.URL+"/api/v1/ports"+tt.query, "", "")
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("%q: status %d: %s", tt.query, resp.StatusCode, body)
+			continue
+		}
+		var got []ports.PortInfo
+		if err := json.Unmarshal(body, &got); err != nil {
+			t.Fatalf("%q: %v", tt.query, err)
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%q: got %d ports, want %v", tt.query, len(got), tt.want)
+			continue
+		}
+		for i, p := range got {
+			if p.Port != tt.want[i] {
+				t.Errorf("%q: got port %d at %d, want %d", tt.query, p.Port, i, tt.want[i])
+			}
+		}
+	}
+
+	// An empty list is [] rather than null
+	if _, body := do(t, "GET", srv.URL+"/api/v1/ports?port=1", "", ""); strings.TrimSpace(string(body)) != "[]" {
+		t.Errorf("expected [], got %s", body)
+	}
+}
+
+func TestListPortsBadRequest(t *testing.T) {
+	srv, _, _ := newTestServer(t, &mockScanner{ports: testPorts()}, Options{})
+	for _, query := range []string{"?port=abc", "?proto=sctp", "?q=colour:red"} {
+		resp, body := do(t, "GET", srv.URL+"/api/v1/ports"+query, "", "")
+		if resp.StatusCode != http.StatusBadRequest || !strings.Contains(string(body), `"error"`) {
+			t.Errorf("%q: got %d %s", query, resp.StatusCode, body)
+		}
+	}
+}
+
+func TestListPortsScanError(t *testing.T) {
+	srv, _, _ := newTestServer(t, &mockScanner{err: errors.New("lsof failed")}, Options{})
+	resp, body := do(t, "GET", srv.URL+"/api/v1/ports", "", "")
+	if resp.StatusCode != http.StatusInternalServerError || !strings.Contains(string(body), "lsof failed") {
+		t.Errorf("got %d %s", resp.StatusCode, body)
+	}
+}
+
+func TestGetPort(t *testing.T) {
+	srv, _, _ := newTestServer(t, &mockScanner{ports: testPorts()}, Options{})
+
+	resp, body := do(t, "GET", srv.URL+"/api/v1/ports/3000", "", "")
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), `"node"`) {
+		t.Errorf("got %d %s", resp.StatusCode, body)
+	}
+	if resp, _ := do(t, "GET", srv.URL+"/api/v1/ports/3000?proto=udp", "", ""); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected 404 for the wrong protocol, got %d", resp.StatusCode)
+	}
+	if resp, _ := do(t, "GET", srv.URL+"/api/v1/ports/4000", "", ""); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", resp.StatusCode)
+	}
+	if resp, _ := do(t, "GET", srv.URL+"/api/v1/ports/70000", "", ""); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", resp.StatusCode)
+	}
+}
+
+func TestKill(t *testing.T) {
+	srv, rec, actions := newTestServer(t, &mockScanner{ports: testPorts()}, Options{Token: "s3cret"})
+
+	resp, body := do(t, "POST", srv.URL+"/api/v1/pids/1073741824/kill", "s3cret", "")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status %d: %s", resp.StatusCode, body)
+	}
+	var got killResponse
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Signal != "SIGTERM" || got.Process != "node" || !strings.Contains(got.Result, "sent SIGTERM") {
+		t.Errorf("unexpected response %+v", got)
+	}
+
+	do(t, "POST", srv.URL+"/api/v1/pids/1073741824/kill", "s3cret", `{"force": true}`)
+	if len(rec.sent) != 2 || rec.sent[0] != syscall.SIGTERM.String() || rec.sent[1] != syscall.SIGKILL.String() {
+		t.Errorf("signals sent: %v", rec.sent)
+	}
+	if len(*actions) != 0 {
+		t.Errorf("unexpected container actions %v", *actions)
+	}
+}
+
+func TestKillContainer(t *testing.T) {
+	srv, rec, actions := newTestServer(t, &mockScanner{ports: testPorts()}, Options{Token: "s3cret"})
+
+	resp, body := do(t, "POST", srv.URL+"/api/v1/pids/1073741826/kill", "s3cret", `{"container_action": "restart"}`)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status %d: %s", resp.StatusCode, body)
+	}
+	if len(*actions) != 1 || (*actions)[0] != "restart aaa111" {
+		t.Errorf("container actions: %v", *actions)
+	}
+	if len(rec.sent) != 0 {
+		t.Errorf("the proxy must not be signalled, sent %v", rec.sent)
+	}
+	if !strings.Contains(string(body), "restarted container web") {
+		t.Errorf("unexpected response %s", body)
+	}
+}
+
+func TestKillRejected(t *testing.T) {
+	srv, rec, _ := newTestServer(t, &mockScanner{ports: testPorts()}, Options{Token: "s3cret"})
+
+	tests := []struct {
+		pid    string
+		body   string
+		status int
+	}{
+		{"abc", "", http.StatusBadRequest},
+		{"1073741824", "{", http.StatusBadRequest},
+		{"1073741824", `{"force": true, "escalate": true}`, http.StatusBadRequest},
+		{"1073741826", `{"container_action": "explode"}`, http.StatusBadRequest},
+		{"1", "", http.StatusForbidden},
+		// not a listener: the API cannot signal arbitrary processes
+		{"1073741900", "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		resp, body := do(t, "POST", srv.URL+"/api/v1/pids/"+tt.pid+"/kill", "s3cret", tt.body)
+		if resp.StatusCode != tt.status {
+			t.Errorf("pid %s body %q: got %d %s, want %d", tt.pid, tt.body, resp.StatusCode, body, tt.status)
+		}
+	}
+	if len(rec.sent) != 0 {
+		t.Errorf("unexpected signals %v", rec.sent)
+	}
+}
+
+func TestKillSignalError(t *testing.T) {
+	srv, rec, _ := newTestServer(t, &mockScanner{ports: testPorts()}, Options{Token: "s3cret"})
+	rec.err = syscall.EPERM
+	resp, body := do(t, "POST", srv.URL+"/api/v1/pids/1073741824/kill", "s3cret", "")
+	if resp.StatusCode != http.StatusInternalServerError || !strings.Contains(string(body), "not permitted") {
+		t.Errorf("got %d %s", resp.StatusCode, body)
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	srv, rec, _ := newTestServer(t, &mockScanner{ports: testPorts()}, Options{Token: "s3cret", ReadOnly: true})
+
+	if resp, _ := do(t, "POST", srv.URL+"/api/v1/pids/1073741824/kill", "s3cret", ""); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected 403, got %d", resp.StatusCode)
+	}
+	if len(rec.sent) != 0 {
+		t.Errorf("unexpected signals %v", rec.sent)
+	}
+	if resp, _ := do(t, "GET", srv.URL+"/api/v1/ports", "s3cret", ""); resp.StatusCode != http.StatusOK {
+		t.Errorf("reads must still work, got %d", resp.StatusCode)
+	}
+}
+
+func TestTokenAuth(t *testing.T) {
+	srv, _, _ := newTestServer(t, &mockScanner{ports: testPorts()}, Options{Token: "s3cret"})
+
+	tests := []struct {
+		url    string
+		token  string
+		status int
+	}{
+		{"/api/v1/ports", "", http.StatusUnauthorized},
+		{"/api/v1/ports", "wrong", http.StatusUnauthorized},
+		{"/api/v1/ports", "s3cret", http.StatusOK},
+		{"/api/v1/ports?token=s3cret", "", http.StatusOK},
+		{"/api/v1/ports?token=wrong", "", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		resp, _ := do(t, "GET", srv.URL+tt.url, tt.token, "")
+		if resp.StatusCode != tt.status {
+			t.Errorf("%s with token %q: got %d, want %d", tt.url, tt.token, resp.StatusCode, tt.status)
+		}
+		if resp.StatusCode == http.StatusUnauthorized && resp.Header.Get("WWW-Authenticate") != "Bearer" {
+			t.Error("expected a WWW-Authenticate challenge")
+		}
+	}
+}
+
+func TestKillNeedsToken(t *testing.T) {
+	srv, rec, _ := newTestServer(t, &mockScanner{ports: testPorts()}, Options{})
+
+	resp, body := do(t, "POST", srv.URL+"/api/v1/pids/1073741824/kill", "", "")
+	if resp.StatusCode != http.StatusForbidden || !strings.Contains(string(body), "token") {
+		t.Errorf("expected 403 without a token, got %d %s", resp.StatusCode, body)
+	}
+	if len(rec.sent) != 0 {
+		t.Errorf("unexpected signals %v", rec.sent)
+	}
+	if resp, _ := do(t, "GET", srv.URL+"/api/v1/ports", "", ""); resp.StatusCode != http.StatusOK {
+		t.Errorf("reads must work without a token, got %d", resp.StatusCode)
+	}
+}
+
+func TestKillContentType(t *testing.T) {
+	srv, rec, _ := newTestServer(t, &mockScanner{ports: testPorts()}, Options{Token: "s3cret"})
+
+	tests := []struct {
+		contentType string
+		status      int
+	}{
+		{"", http.StatusUnsupportedMediaType},
+		{"text/plain", http.StatusUnsupportedMediaType},
+		{"application/x-www-form-urlencoded", http.StatusUnsupportedMediaType},
+		{"application/json; charset=utf-8", http.StatusOK},
+		{"Application/JSON", http.StatusOK},
+	}
+	for _, tt := range tests {
+		req, err := http.NewRequest("POST", srv.URL+"/api/v1/pids/1073741824/kill", strings.NewReader("{}"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "Bearer s3cret")
+		if tt.contentType != "" {
+			req.Header.Set("Content-Type", tt.contentType)
+		}
+		if resp, body := send(t, req); resp.StatusCode != tt.status {
+			t.Errorf("Content-Type %q: got %d %s, want %d", tt.contentType, resp.StatusCode, body, tt.status)
+		}
+	}
+	if len(rec.sent) != 2 {
+		t.Errorf("expected only the JSON requests to signal, sent %v", rec.sent)
+	}
+}
+
+func TestHostCheck(t *testing.T) {
+	tests := []struct {
+		host    string
+		anyHost bool
+		status  int
+	}{
+		{"localhost", false, http.StatusOK},
+		{"localhost:7878", false, http.StatusOK},
+		{"LOCALHOST:7878", false, http.StatusOK},
+		{"127.0.0.1:7878", false, http.StatusOK},
+		{"[::1]:7878", false, http.StatusOK},
+		{"evil.example", false, http.StatusForbidden},
+		{"evil.example:7878", false, http.StatusForbidden},
+		{"localhost.evil.example", false, http.StatusForbidden},
+		{"192.168.1.10:7878", false, http.StatusForbidden},
+		{"evil.example", true, http.StatusOK},
+	}
+	for _, tt := range tests {
+		srv, _, _ := newTestServer(t, &mockScanner{ports: testPorts()}, Options{AnyHost: tt.anyHost})
+		req, err := http.NewRequest("GET", srv.URL+"/api/v1/ports", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Host = tt.host
+		if resp, body := send(t, req); resp.StatusCode != tt.status {
+			t.Errorf("Host %q (any host %v): got %d %s, want %d", tt.host, tt.anyHost, resp.StatusCode, body, tt.status)
+		}
+	}
+}
+
+// readEvent reads one server-sent event.
+func readEvent(t *testing.T, r *bufio.Reader) (event, data string) {
+	t.Helper()
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("reading event: %v", err)
+		}
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case line == "":
+			return event, data
+		case strings.HasPrefix(line, "event: "):
+			event = line[len("event: "):]
+		case strings.HasPrefix(line, "data: "):
+			data = line[len("data: "):]
+		}
+	}
+}
+
+func TestEvents(t *testing.T) {
+	scanner := &mockScanner{ports: testPorts()[:1]}
+	srv, _, _ := newTestServer(t, scanner, Options{Interval: 10 * time.Millisecond})
+
+	resp, err := http.Get(srv.URL + "/api/v1/events?proto=tcp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	r := bufio.NewReader(resp.Body)
+
+	event, data := readEvent(t, r)
+	if event != "snapshot" || !strings.Contains(data, `"node"`) {
+		t.Fatalf("expected snapshot, got %s %s", event, data)
+	}
+
+	// 3000 closes, 8080 (tcp) and 5353 (udp, filtered out) open
+	scanner.set(testPorts()[1:])
+	seen := map[string]string{}
+	for len(seen) < 2 {
+		event, data := readEvent(t, r)
+		seen[event] = data
+	}
+	if !strings.Contains(seen["opened"], `"port":8080`) {
+		t.Errorf("opened: %s", seen["opened"])
+	}
+	if !strings.Contains(seen["closed"], `"port":3000`) {
+		t.Errorf("closed: %s", seen["closed"])
+	}
+	if strings.Contains(seen["opened"], "5353") {
+		t.Errorf("filtered port was streamed: %s", seen["opened"])
+	}
+}
+
+func TestListenUnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reap.sock")
+	l, err := Listen("unix://" + path)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0o600 {
+		t.Errorf("socket mode = %v, want 0600", fi.Mode().Perm())
+	}
+	l.Close()
+
+	// a stale socket file is replaced; other files are left alone
+	if l, err := Listen("unix://" + path); err != nil {
+		t.Errorf("expected stale socket to be replaced: %v", err)
+	} else {
+		l.Close()
+	}
+	regular := filepath.Join(t.TempDir(), "file")
+	os.WriteFile(regular, nil, 0o600)
+	if _, err := Listen("unix://" + regular); err == nil {
+		t.Error("expected error for a regular file")
+	}
+	if _, err := Listen("unix://"); err == nil {
+		t.Error("expected error for a missing path")
+	}
+}
+
+func TestIsLocal(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"127.0.0.1:7878", true},
+		{"[::1]:7878", true},
+		{"localhost:7878", true},
+		{"unix:///tmp/reap.sock", true},
+		{":7878", false},
+		{"0.0.0.0:7878", false},
+		{"192.168.1.10:7878", false},
+		{"garbage", false},
+	}
+	for _, tt := range tests {
+		if got := IsLocal(tt.addr); got != tt.want {
+			t.Errorf("IsLocal(%q) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+}
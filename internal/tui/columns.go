@@ -0,0 +1,182 @@
+package tui
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/legostin/reap/internal/config"
+	"github.com/legostin/reap/internal/ports"
+)
+
+type column struct {
+	name     string // config name, the same as in list --columns
+	title    string
+	minWidth int
+	maxWidth int // -1 takes all free space
+	priority int // narrow terminals drop higher values first
+	align    lipgloss.Position
+	sort     sortColumn                    // sort key that shows an arrow in this header
+	value    func(p ports.PortInfo) string // nil for columns drawn from the table state
+
+	width int // laid out for the terminal width
+}
+
+// tableColumns are all columns the table can show. Widths include one cell
+// of spacing.
+var tableColumns = []column{
+	{name: "port", title: "PORT", minWidth: 8, maxWidth: 8, priority: 0, sort: sortByPort,
+		value: func(p ports.PortInfo) string { return strconv.Itoa(p.Port) }},
+	{name: "proto", title: "PROTO", minWidth: 7, maxWidth: 7, priority: 4, sort: noSort,
+		value: func(p ports.PortInfo) string { return p.Protocol }},
+	{name: "pid", title: "PID", minWidth: 8, maxWidth: 8, priority: 2, sort: sortByPID,
+		value: func(p ports.PortInfo) string { return strconv.Itoa(p.PID) }},
+	{name: "process", title: "PROCESS", minWidth: 12, maxWidth: 30, priority: 1, sort: sortByProcess,
+		value: func(p ports.PortInfo) string { return p.Process }},
+	{name: "user", title: "USER", minWidth: 8, maxWidth: 16, priority: 5, sort: sortByUser,
+		value: func(p ports.PortInfo) string { return p.User }},
+	{name: "memory", title: "MEMORY", minWidth: 10, maxWidth: 10, priority: 3, sort: sortByMemory,
+		value: func(p ports.PortInfo) string { return p.Memory }},
+	{name: "uptime", title: "UPTIME", minWidth: 10, maxWidth: 10, priority: 6, sort: sortByUptime,
+		value: func(p ports.PortInfo) string { return p.Uptime }},
+	{name: "conns", title: "CONNS", minWidth: 7, maxWidth: 7, priority: 7, sort: noSort,
+		value: connCount},
+	{name: "label", title: "LABEL", minWidth: 8, maxWidth: 20, priority: 4, sort: noSort,
+		value: func(p ports.PortInfo) string { return cmp.Or(p.Label, "-") }},
+	{name: "container", title: "CONTAINER", minWidth: 11, maxWidth: 24, priority: 6, sort: noSort,
+		value: func(p ports.PortInfo) string { return cmp.Or(p.Container, "-") }},
+	{name: "dir", title: "DIR", minWidth: 8, maxWidth: 24, priority: 8, sort: noSort,
+		value: func(p ports.PortInfo) string { return shortDir(p.CWD) }},
+	{name: "address", title: "ADDRESS", minWidth: 10, maxWidth: 41, priority: 7, sort: noSort,
+		value: func(p ports.PortInfo) string { return p.Address }},
+	{name: "command", title: "COMMAND", minWidth: 20, maxWidth: -1, priority: 9, sort: noSort,
+		value: func(p ports.PortInfo) string { return p.Command }},
+	{name: "cpu", title: "CPU%", minWidth: 7, maxWidth: 7, priority: 5, sort: noSort,
+		value: func(p ports.PortInfo) string { return fmt.Sprintf("%.1f", p.CPUPercent) }},
+	{name: "threads", title: "THREADS", minWidth: 9, maxWidth: 9, priority: 8, sort: noSort,
+		value: func(p ports.PortInfo) string { return knownCount(p.Threads) }},
+	{name: "fds", title: "FDS", minWidth: 7, maxWidth: 7, priority: 8, sort: noSort,
+		value: func(p ports.PortInfo) string { return knownCount(p.FDs) }},
+	{name: "read", title: "READ", minWidth: 10, maxWidth: 10, priority: 8, sort: noSort,
+		value: func(p ports.PortInfo) string { return ports.FormatBytes(p.ReadBytes) }},
+	{name: "write", title: "WRITE", minWidth: 10, maxWidth: 10, priority: 8, sort: noSort,
+		value: func(p ports.PortInfo) string { return ports.FormatBytes(p.WriteBytes) }},
+	{name: "mem_trend", title: "MEM TREND", minWidth: 12, maxWidth: 12, priority: 8, sort: noSort},
+	{name: "cpu_trend", title: "CPU TREND", minWidth: 12, maxWidth: 12, priority: 8, sort: noSort},
+}
+
+// defaultTableColumns are shown when the config does not name any.
+var defaultTableColumns = []string{"port", "proto", "pid", "process", "label", "user", "memory", "uptime", "conns"}
+
+// columnAliases are alternative config names for columns.
+var columnAliases = map[string]string{"protocol": "proto"}
+
+func findTableColumn(name string) (column, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if alias, ok := columnAliases[name]; ok {
+		name = alias
+	}
+	for _, c := range tableColumns {
+		if c.name == name {
+			return c, true
+		}
+	}
+	return column{}, false
+}
+
+// selectTableColumns returns the named columns in order with their layout
+// overrides applied. Unknown and repeated names are skipped; if none are
+// left the defaults are used.
+func selectTableColumns(names []string, layout map[string]config.ColumnLayout) []column {
+	var cols []column
+	for _, name := range names {
+		c, ok := findTableColumn(name)
+		if !ok || slices.ContainsFunc(cols, func(o column) bool { return o.name == c.name }) {
+			continue
+		}
+		if l, ok := layout[c.name]; ok {
+			c = c.withLayout(l)
+		}
+		cols = append(cols, c)
+	}
+	if len(cols) == 0 {
+		return selectTableColumns(defaultTableColumns, layout)
+	}
+	return cols
+}
+
+// withLayout applies the non-zero settings of l.
+func (c column) withLayout(l config.ColumnLayout) column {
+	if l.MinWidth > 0 {
+		c.minWidth = l.MinWidth
+	}
+	if l.MaxWidth != 0 {
+		c.maxWidth = max(l.MaxWidth, -1)
+	}
+	if c.maxWidth >= 0 && c.maxWidth < c.minWidth {
+		c.maxWidth = c.minWidth
+	}
+	switch strings.ToLower(l.Align) {
+	case "left":
+		c.align = lipgloss.Left
+	case "right":
+		c.align = lipgloss.Right
+	case "center":
+		c.align = lipgloss.Center
+	}
+	return c
+}
+
+// layoutColumns fits cols into width terminal cells. While the minimum
+// widths do not fit, the column with the highest priority value is dropped,
+// the rightmost on a tie. Free space then goes to the columns that can grow,
+// one cell at a time. With an unknown width every column gets its maximum.
+func layoutColumns(cols []column, width int) []column {
+	visible := slices.Clone(cols)
+	if width <= 0 {
+		for i := range visible {
+			visible[i].width = max(visible[i].minWidth, visible[i].maxWidth)
+		}
+		return visible
+	}
+
+	avail := width - prefixWidth
+	minTotal := func() int {
+		n := 0
+		for _, c := range visible {
+			n += c.minWidth
+		}
+		return n
+	}
+	for len(visible) > 1 && minTotal() > avail {
+		drop := 0
+		for i, c := range visible {
+			if c.priority >= visible[drop].priority {
+				drop = i
+			}
+		}
+		visible = slices.Delete(visible, drop, drop+1)
+	}
+
+	for i := range visible {
+		visible[i].width = visible[i].minWidth
+	}
+	for extra := avail - minTotal(); extra > 0; {
+		grew := false
+		for i := range visible {
+			c := &visible[i]
+			if extra > 0 && (c.maxWidth < 0 || c.width < c.maxWidth) {
+				c.width++
+				extra--
+				grew = true
+			}
+		}
+		if !grew {
+			break
+		}
+	}
+	return visible
+}
@@ -0,0 +1,162 @@
+package tui
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/legostin/reap/internal/config"
+	"github.com/legostin/reap/internal/ports"
+)
+
+func TestSelectTableColumns(t *testing.T) {
+	cols := selectTableColumns([]string{"PORT", " protocol", "bogus", "port", "command"}, nil)
+	var names []string
+	for _, c := range cols {
+		names = append(names, c.name)
+	}
+	if got := strings.Join(names, ","); got != "port,proto,command" {
+		t.Errorf("got %s, want port,proto,command", got)
+	}
+
+	if got := len(selectTableColumns(nil, nil)); got != len(defaultTableColumns) {
+		t.Errorf("expected the defaults for no names, got %d columns", got)
+	}
+}
+
+func TestSelectTableColumnsLayout(t *testing.T) {
+	layout := map[string]config.ColumnLayout{
+		"process": {MinWidth: 20, MaxWidth: 10},
+		"pid":     {Align: "Right"},
+		"dir":     {MaxWidth: -1},
+		"user":    {Align: "diagonal"},
+	}
+	cols := selectTableColumns([]string{"process", "pid", "dir", "user"}, layout)
+
+	if c := cols[0]; c.minWidth != 20 || c.maxWidth != 20 {
+		t.Errorf("process: a max below the min is raised to it, got %d-%d", c.minWidth, c.maxWidth)
+	}
+	if cols[1].align != lipgloss.Right {
+		t.Errorf("pid: expected right alignment, got %v", cols[1].align)
+	}
+	if cols[2].maxWidth != -1 {
+		t.Errorf("dir: expected unbounded width, got %d", cols[2].maxWidth)
+	}
+	if cols[3].align != lipgloss.Left {
+		t.Errorf("user: an unknown alignment keeps the default, got %v", cols[3].align)
+	}
+}
+
+func TestLayoutColumns(t *testing.T) {
+	cols := selectTableColumns([]string{"port", "process", "pid", "command"}, nil)
+	tests := []struct {
+		width int
+		want  string
+	}{
+		// minimum widths 8+12+8+20 plus the prefix
+		{50, "port:8 process:12 pid:8 command:20"},
+		// free space is shared until process reaches its maximum
+		{60, "port:8 process:17 pid:8 command:25"},
+		{100, "port:8 process:30 pid:8 command:52"},
+		// command has the highest priority value and goes first
+		{49, "port:8 process:30 pid:8"},
+		{30, "port:8 process:12 pid:8"},
+		{29, "port:8 process:19"},
+		{5, "port:8"},
+		// unknown width: every column at its maximum
+		{0, "port:8 process:30 pid:8 command:20"},
+	}
+	for _, tt := range tests {
+		var parts []string
+		for _, c := range layoutColumns(cols, tt.width) {
+			parts = append(parts, c.name+":"+strconv.Itoa(c.width))
+		}
+		if got := strings.Join(parts, " "); got != tt.want {
+			t.Errorf("layoutColumns(width=%d) = %s, want %s", tt.width, got, tt.want)
+		}
+	}
+}
+
+func TestLayoutColumnsPriorityTie(t *testing.T) {
+	// threads and fds share a priority; the rightmost goes first
+	cols := selectTableColumns([]string{"port", "threads", "fds"}, nil)
+	got := layoutColumns(cols, prefixWidth+8+9)
+	if len(got) != 2 || got[1].name != "threads" {
+		t.Errorf("expected fds to be dropped, got %v", got)
+	}
+}
+
+func TestPortTableNarrowTerminal(t *testing.T) {
+	pt := newPortTable(config.Default())
+	pt.treeMode = false
+	pt.setHeight(5)
+	pt.setRows([]ports.PortInfo{{Port: 3000, PID: 100, Process: "node", User: "dev", Protocol: "tcp"}})
+
+	pt.setWidth(120)
+	if len(pt.visible) != len(pt.columns) {
+		t.Fatalf("expected every column at 120 cells, got %d of %d", len(pt.visible), len(pt.columns))
+	}
+
+	pt.setWidth(50)
+	view := pt.view()
+	for _, want := range []string{"PORT", "PROCESS", "PID", "MEMORY"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("narrow view should keep %s:\n%s", want, view)
+		}
+	}
+	for _, dropped := range []string{"CONNS", "UPTIME", "USER"} {
+		if strings.Contains(view, dropped) {
+			t.Errorf("narrow view should drop %s:\n%s", dropped, view)
+		}
+	}
+	for _, line := range strings.Split(view, "\n") {
+		if w := lipgloss.Width(line); w > 50 {
+			t.Errorf("line is %d cells wide, want at most 50: %q", w, line)
+		}
+	}
+	if len(pt.columns) != len(defaultTableColumns) {
+		t.Error("dropping columns should not change the configured set")
+	}
+}
+
+func TestPortTableExtraColumns(t *testing.T) {
+	cfg := config.Default()
+	cfg.Columns = []string{"port", "label", "container", "dir", "address", "protocol", "command"}
+	pt := newPortTable(cfg)
+	pt.treeMode = false
+	pt.setWidth(160)
+	pt.setHeight(5)
+	pt.setRows([]ports.PortInfo{
+		{Port: 3000, PID: 100, Protocol: "tcp", Address: "127.0.0.1", Container: "web-1", Label: "frontend",
+			CWD: "/home/dev/shop", Command: "node server.js"},
+		{Port: 5432, PID: 200, Protocol: "tcp", Address: "*"},
+	})
+
+	view := pt.view()
+	for _, want := range []string{"LABEL", "CONTAINER", "DIR", "ADDRESS", "PROTO", "COMMAND",
+		"frontend", "web-1", "shop", "127.0.0.1", "tcp", "node server.js"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view should contain %q:\n%s", want, view)
+		}
+	}
+	if row := pt.renderRow(1); strings.Count(row, "-") < 3 {
+		t.Errorf("empty label, container and dir should show -: %q", row)
+	}
+}
+
+func TestPortTableAlignment(t *testing.T) {
+	cfg := config.Default()
+	cfg.Columns = []string{"pid", "process"}
+	cfg.ColumnLayout = map[string]config.ColumnLayout{"pid": {Align: "right"}}
+	pt := newPortTable(cfg)
+	pt.treeMode = false
+	pt.setHeight(5)
+	pt.setRows([]ports.PortInfo{{Port: 3000, PID: 7, Process: "node"}})
+
+	row := pt.renderRow(0)
+	// prefix, then the PID right-aligned in 7 cells and a gap
+	if !strings.Contains(row, "      7 node") {
+		t.Errorf("expected a right-aligned PID: %q", row)
+	}
+}
@@ -2,6 +2,7 @@ package tui
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/legostin/reap/internal/ports"
@@ -12,13 +13,85 @@ type confirmDialog struct {
 	target     ports.PortInfo
 	force      bool
 	killParent bool
+	graceful   bool // SIGTERM, wait, then SIGKILL
+
+	// Process tree kill: procs is the process table snapshot the targets
+	// are collected from for the current scope.
+	tree  bool
+	procs []ports.Process
+	scope ports.TreeScope
+
+	// bulk holds every marked port for a multi-select kill.
+	bulk []ports.PortInfo
+
+	// container offers a container action instead of signalling the proxy
+	// process that owns a published port.
+	container bool
+	action    ports.ContainerAction
 }
 
+// maxDialogLines limits how many processes the tree and bulk dialogs list.
+const maxDialogLines = 12
+
 func (d *confirmDialog) show(target ports.PortInfo, force bool, killParent bool) {
 	d.visible = true
 	d.target = target
 	d.force = force
 	d.killParent = killParent
+	d.graceful = false
+	d.tree = false
+	d.procs = nil
+	d.bulk = nil
+	d.container = false
+}
+
+func (d *confirmDialog) showGraceful(target ports.PortInfo) {
+	d.show(target, false, false)
+	d.graceful = true
+}
+
+func (d *confirmDialog) showTree(target ports.PortInfo, procs []ports.Process) {
+	d.show(target, false, false)
+	d.tree = true
+	d.procs = procs
+	d.scope = ports.ScopeDescendants
+}
+
+func (d *confirmDialog) showBulk(targets []ports.PortInfo, force bool) {
+	d.show(ports.PortInfo{}, force, false)
+	d.bulk = targets
+}
+
+func (d *confirmDialog) showContainer(target ports.PortInfo) {
+	d.show(target, false, false)
+	d.container = true
+	d.action = ports.ContainerStop
+}
+
+// selectAction picks the container action for key s, r or p. p pauses a
+// running container and unpauses a paused one.
+func (d *confirmDialog) selectAction(k string) {
+	switch k {
+	case "s":
+		d.action = ports.ContainerStop
+	case "r":
+		d.action = ports.ContainerRestart
+	case "p":
+		d.action = ports.ContainerPause
+		if d.target.ContainerInfo != nil && d.target.ContainerInfo.State == "paused" {
+			d.action = ports.ContainerUnpause
+		}
+	}
+}
+
+// cycleScope switches between descendants, process group and session.
+func (d *confirmDialog) cycleScope() {
+	d.scope = (d.scope + 1) % (ports.ScopeSession + 1)
+}
+
+// treeTargets returns the processes a tree kill signals, leaves-first.
+func (d *confirmDialog) treeTargets() []ports.Process {
+	return ports.CollectTree(d.procs, d.target.PID, d.scope)
 }
 
 func (d *confirmDialog) hide() {
@@ -33,11 +106,22 @@ func (d *confirmDialog) view() string {
 	signal := "SIGTERM"
 	if d.force {
 		signal = "SIGKILL"
+	} else if d.graceful {
+		signal = "SIGTERM → SIGKILL"
 	}
 
 	var title, body string
 
-	if d.killParent {
+	if d.container {
+		title = dialogTitleStyle.Render(fmt.Sprintf("%s container?", capitalize(string(d.action))))
+		body = d.containerBody()
+	} else if len(d.bulk) > 0 {
+		title = dialogTitleStyle.Render(bulkTitle(d.bulk, signal))
+		body = d.bulkBody()
+	} else if d.tree {
+		title = dialogTitleStyle.Render(fmt.Sprintf("Kill process tree? (%s)", signal))
+		body = d.treeBody()
+	} else if d.killParent {
 		title = dialogTitleStyle.Render(fmt.Sprintf("Kill PARENT process? (%s)", signal))
 		body = fmt.Sprintf(
 			"\n  Target:  %s (PID %d, port %d)"+
@@ -55,6 +139,121 @@ func (d *confirmDialog) view() string {
 
 	prompt := "\n  " + lipgloss.NewStyle().Bold(true).Render("y") + " confirm  " +
 		lipgloss.NewStyle().Bold(true).Render("n/esc") + " cancel"
+	if d.tree {
+		prompt += "  " + lipgloss.NewStyle().Bold(true).Render("tab") + " scope"
+	}
+	if d.container {
+		prompt += "  " + lipgloss.NewStyle().Bold(true).Render("s/r/p") + " action"
+	}
 
 	return dialogStyle.Render(title + body + prompt)
 }
+
+// treeBody lists the processes a tree kill will signal, in signal order.
+func (d *confirmDialog) treeBody() string {
+	targets := d.treeTargets()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n  Root:    %s (PID %d, port %d)", d.target.Process, d.target.PID, d.target.Port)
+	fmt.Fprintf(&b, "\n  Scope:   %s\n", d.scope)
+	fmt.Fprintf(&b, "\n  %d processes, signalled leaves-first:\n", len(targets))
+	for i, p := range targets {
+		if i == maxDialogLines {
+			fmt.Fprintf(&b, "  … %d more\n", len(targets)-maxDialogLines)
+			break
+		}
+		fmt.Fprintf(&b, "  %7d  %s\n", p.PID, p.Name)
+	}
+	return b.String()
+}
+
+// bulkBody lists every marked PID and port.
+func (d *confirmDialog) bulkBody() string {
+	var b strings.Builder
+	b.WriteString("\n")
+	for i, p := range d.bulk {
+		if i == maxDialogLines {
+			fmt.Fprintf(&b, "  … %d more\n", len(d.bulk)-maxDialogLines)
+			break
+		}
+		if p.ContainerInfo != nil {
+			fmt.Fprintf(&b, "  %7s  %5d/%-3s  %s\n", "stop", p.Port, p.Protocol, p.Container)
+			continue
+		}
+		fmt.Fprintf(&b, "  %7d  %5d/%-3s  %s\n", p.PID, p.Port, p.Protocol, p.Process)
+	}
+	return b.String()
+}
+
+// bulkTitle summarises a bulk dialog, mentioning containers only when some
+// marked rows belong to one.
+func bulkTitle(targets []ports.PortInfo, signal string) string {
+	pids, containers := bulkPIDs(targets), bulkContainers(targets)
+	if len(containers) == 0 {
+		return fmt.Sprintf("Kill %d marked processes? (%s)", len(pids), signal)
+	}
+	return fmt.Sprintf("Kill %d processes (%s), stop %d containers?", len(pids), signal, len(containers))
+}
+
+// bulkPIDs returns the distinct PIDs of targets in order. A process
+// listening on several marked ports is killed once. Rows owned by a
+// container are left to bulkContainers.
+func bulkPIDs(targets []ports.PortInfo) []int {
+	seen := make(map[int]bool)
+	var pids []int
+	for _, p := range targets {
+		if p.ContainerInfo == nil && !seen[p.PID] {
+			seen[p.PID] = true
+			pids = append(pids, p.PID)
+		}
+	}
+	return pids
+}
+
+// bulkContainers returns the distinct containers owning targets, in order.
+func bulkContainers(targets []ports.PortInfo) []*ports.Container {
+	seen := make(map[string]bool)
+	var containers []*ports.Container
+	for _, p := range targets {
+		if ci := p.ContainerInfo; ci != nil && !seen[ci.ID] {
+			seen[ci.ID] = true
+			containers = append(containers, ci)
+		}
+	}
+	return containers
+}
+
+// containerBody describes the container and the available actions, with
+// the selected one highlighted.
+func (d *confirmDialog) containerBody() string {
+	ci := d.target.ContainerInfo
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n  Container: %s", formatContainer(d.target))
+	if ci != nil && ci.Image != "" {
+		fmt.Fprintf(&b, "\n  Image:     %s", ci.Image)
+	}
+	fmt.Fprintf(&b, "\n  Port:      %d/%s (%s, PID %d)\n\n  ", d.target.Port, d.target.Protocol, d.target.Process, d.target.PID)
+
+	pause := ports.ContainerPause
+	if ci != nil && ci.State == "paused" {
+		pause = ports.ContainerUnpause
+	}
+	for _, a := range []ports.ContainerAction{ports.ContainerStop, ports.ContainerRestart, pause} {
+		label := string(a)
+		if a == d.action {
+			label = lipgloss.NewStyle().Bold(true).Reverse(true).Render(" " + label + " ")
+		} else {
+			label = " " + label + " "
+		}
+		b.WriteString(label + " ")
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
@@ -280,3 +280,191 @@ func TestConfirmDialogForceKillParent(t *testing.T) {
 		t.Error("should indicate killing parent")
 	}
 }
+
+func TestConfirmDialogViewGraceful(t *testing.T) {
+	d := confirmDialog{}
+	d.showGraceful(ports.PortInfo{Port: 3000, PID: 1234, Process: "node"})
+
+	if !d.graceful || d.force || d.killParent {
+		t.Errorf("unexpected flags: %+v", d)
+	}
+	if view := d.view(); !strings.Contains(view, "SIGTERM → SIGKILL") {
+		t.Errorf("expected escalation in title, got:\n%s", view)
+	}
+}
+
+var testProcessTable = []ports.Process{
+	{PID: 100, PPID: 1, PGID: 100, SID: 100, Name: "npm"},
+	{PID: 200, PPID: 100, PGID: 100, SID: 100, Name: "node"},
+	{PID: 300, PPID: 200, PGID: 100, SID: 100, Name: "esbuild"},
+}
+
+func TestConfirmDialogShowTree(t *testing.T) {
+	d := confirmDialog{}
+	d.showTree(ports.PortInfo{Port: 3000, PID: 200, Process: "node"}, testProcessTable)
+
+	if !d.visible || !d.tree || d.scope != ports.ScopeDescendants {
+		t.Fatalf("unexpected dialog state: %+v", d)
+	}
+	targets := d.treeTargets()
+	if len(targets) != 2 || targets[0].PID != 300 || targets[1].PID != 200 {
+		t.Errorf("expected leaves-first [300 200], got %+v", targets)
+	}
+
+	d.cycleScope()
+	if d.scope != ports.ScopeGroup {
+		t.Errorf("expected process group scope, got %s", d.scope)
+	}
+	if targets := d.treeTargets(); len(targets) != 3 || targets[2].PID != 100 {
+		t.Errorf("expected group to include npm last, got %+v", targets)
+	}
+
+	d.cycleScope()
+	d.cycleScope()
+	if d.scope != ports.ScopeDescendants {
+		t.Errorf("expected scope to wrap around, got %s", d.scope)
+	}
+
+	// A regular kill afterwards must not reuse the tree state
+	d.show(ports.PortInfo{Port: 3000, PID: 200}, false, false)
+	if d.tree || d.procs != nil {
+		t.Error("expected tree state to reset")
+	}
+}
+
+func TestConfirmDialogViewTree(t *testing.T) {
+	d := confirmDialog{}
+	d.showTree(ports.PortInfo{Port: 3000, PID: 200, Process: "node"}, testProcessTable)
+	view := d.view()
+
+	for _, want := range []string{"Kill process tree?", "descendants", "2 processes", "esbuild", "tab"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in view:\n%s", want, view)
+		}
+	}
+	if strings.Index(view, "300  esbuild") > strings.Index(view, "200  node") {
+		t.Errorf("expected leaves listed first:\n%s", view)
+	}
+}
+
+func TestConfirmDialogViewTreeTruncated(t *testing.T) {
+	procs := []ports.Process{{PID: 100, PPID: 1, Name: "supervisor"}}
+	for i := 0; i < maxDialogLines+3; i++ {
+		procs = append(procs, ports.Process{PID: 200 + i, PPID: 100, Name: "worker"})
+	}
+	d := confirmDialog{}
+	d.showTree(ports.PortInfo{Port: 8000, PID: 100, Process: "supervisor"}, procs)
+
+	if view := d.view(); !strings.Contains(view, "… 4 more") {
+		t.Errorf("expected truncation line, got:\n%s", view)
+	}
+}
+
+func TestConfirmDialogViewBulk(t *testing.T) {
+	d := confirmDialog{}
+	d.showBulk([]ports.PortInfo{
+		{Port: 3000, PID: 100, Protocol: "tcp", Process: "node"},
+		{Port: 3001, PID: 100, Protocol: "tcp", Process: "node"},
+		{Port: 5432, PID: 200, Protocol: "tcp", Process: "postgres"},
+	}, true)
+
+	view := d.view()
+	for _, want := range []string{"Kill 2 marked processes? (SIGKILL)", "3001/tcp", "postgres"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in view:\n%s", want, view)
+		}
+	}
+
+	d.show(ports.PortInfo{Port: 3000, PID: 100}, false, false)
+	if d.bulk != nil {
+		t.Error("expected bulk targets to reset")
+	}
+}
+
+func TestBulkPIDs(t *testing.T) {
+	pids := bulkPIDs([]ports.PortInfo{
+		{Port: 3000, PID: 100},
+		{Port: 5432, PID: 200},
+		{Port: 3001, PID: 100},
+	})
+	if len(pids) != 2 || pids[0] != 100 || pids[1] != 200 {
+		t.Errorf("expected [100 200], got %v", pids)
+	}
+}
+
+func containerTarget(state string) ports.PortInfo {
+	return ports.PortInfo{
+		Port: 8080, PID: 900, Protocol: "tcp", Process: "docker-proxy", Container: "app-web-1",
+		ContainerInfo: &ports.Container{ID: "aaa111", Name: "app-web-1", Image: "nginx:1.25", State: state},
+	}
+}
+
+func TestConfirmDialogContainer(t *testing.T) {
+	d := confirmDialog{}
+	d.showContainer(containerTarget("running"))
+
+	if !d.container || d.action != ports.ContainerStop {
+		t.Fatalf("expected stop to be preselected, got %+v", d)
+	}
+	view := d.view()
+	for _, want := range []string{"Stop container?", "app-web-1", "nginx:1.25", "restart", "pause", "s/r/p"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in view:\n%s", want, view)
+		}
+	}
+
+	tests := []struct {
+		key  string
+		want ports.ContainerAction
+	}{
+		{"r", ports.ContainerRestart},
+		{"p", ports.ContainerPause},
+		{"s", ports.ContainerStop},
+		{"x", ports.ContainerStop}, // unknown keys keep the selection
+	}
+	for _, tt := range tests {
+		d.selectAction(tt.key)
+		if d.action != tt.want {
+			t.Errorf("key %q: action = %s, want %s", tt.key, d.action, tt.want)
+		}
+	}
+
+	d.show(containerTarget("running"), false, false)
+	if d.container {
+		t.Error("expected container flag to reset")
+	}
+}
+
+func TestConfirmDialogContainerPaused(t *testing.T) {
+	d := confirmDialog{}
+	d.showContainer(containerTarget("paused"))
+	d.selectAction("p")
+
+	if d.action != ports.ContainerUnpause {
+		t.Errorf("expected unpause for a paused container, got %s", d.action)
+	}
+	if view := d.view(); !strings.Contains(view, "Unpause container?") {
+		t.Errorf("unexpected view:\n%s", view)
+	}
+}
+
+func TestConfirmDialogBulkWithContainers(t *testing.T) {
+	d := confirmDialog{}
+	d.showBulk([]ports.PortInfo{
+		{Port: 3000, PID: 100, Protocol: "tcp", Process: "node"},
+		containerTarget("running"),
+		{Port: 8443, PID: 901, Protocol: "tcp", Process: "docker-proxy", Container: "app-web-1",
+			ContainerInfo: &ports.Container{ID: "aaa111", Name: "app-web-1"}},
+	}, false)
+
+	view := d.view()
+	if !strings.Contains(view, "Kill 1 processes (SIGTERM), stop 1 containers?") {
+		t.Errorf("unexpected title:\n%s", view)
+	}
+	if !strings.Contains(view, "stop   8080/tcp  app-web-1") {
+		t.Errorf("expected container row:\n%s", view)
+	}
+	if strings.Contains(view, "docker-proxy") {
+		t.Errorf("proxy PIDs must not be listed as kill targets:\n%s", view)
+	}
+}
@@ -1,23 +1,28 @@
 package tui
 
 import (
-	"strconv"
-	"strings"
-
 	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/legostin/reap/internal/ports"
 )
 
+// filterInput is the / filter bar. Its value is a query in the syntax of
+// ports.ParseQuery; a plain word keeps matching any field.
 type filterInput struct {
 	input  textinput.Model
 	active bool
+
+	src   string       // value query and err were compiled from
+	query *ports.Query // last valid query
+	err   error        // syntax error in the current value, if any
 }
 
 func newFilterInput() filterInput {
 	ti := textinput.New()
 	ti.Prompt = "/ "
 	ti.PromptStyle = filterPromptStyle
-	ti.CharLimit = 64
+	ti.PlaceholderStyle = expandLabelStyle
+	ti.Placeholder = "node  port:3000-3999  user:dev  mem>500MB  -container:*"
+	ti.CharLimit = 256
 	return filterInput{input: ti}
 }
 
@@ -40,15 +45,32 @@ func (f *filterInput) value() string {
 	return f.input.Value()
 }
 
+// compile parses the value if it changed since the last call. While the
+// value has a syntax error, the last valid query stays in effect so the
+// table does not empty out halfway through typing.
+func (f *filterInput) compile() {
+	v := f.input.Value()
+	if v == f.src && (f.query != nil || f.err != nil) {
+		return
+	}
+	f.src = v
+	q, err := ports.ParseQuery(v)
+	f.err = err
+	if err == nil {
+		f.query = q
+	}
+}
+
 func (f *filterInput) matches(p ports.PortInfo) bool {
-	q := strings.ToLower(f.input.Value())
-	if q == "" {
-		return true
+	f.compile()
+	return f.query.Match(p)
+}
+
+// view renders the input followed by the syntax error, if any.
+func (f *filterInput) view() string {
+	f.compile()
+	if f.err != nil {
+		return f.input.View() + "  " + errorStyle.Render(f.err.Error())
 	}
-	return strings.Contains(strings.ToLower(p.Process), q) ||
-		strings.Contains(strconv.Itoa(p.Port), q) ||
-		strings.Contains(strconv.Itoa(p.PID), q) ||
-		strings.Contains(strings.ToLower(p.User), q) ||
-		strings.Contains(strings.ToLower(p.Container), q) ||
-		strings.Contains(strings.ToLower(p.CWD), q)
+	return f.input.View()
 }
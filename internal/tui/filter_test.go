@@ -1,6 +1,7 @@
 package tui
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/legostin/reap/internal/ports"
@@ -397,3 +398,72 @@ func TestFilterMatchesNumericInText(t *testing.T) {
 		}
 	}
 }
+
+func TestFilterMatchesProtocol(t *testing.T) {
+	f := newFilterInput()
+	f.input.SetValue("udp")
+
+	if !f.matches(ports.PortInfo{Port: 53, Protocol: "udp"}) {
+		t.Error("expected udp entry to match")
+	}
+	if f.matches(ports.PortInfo{Port: 3000, Protocol: "tcp"}) {
+		t.Error("expected tcp entry not to match")
+	}
+}
+
+func TestFilterMatchesQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		port  ports.PortInfo
+		match bool
+	}{
+		{"port:80", ports.PortInfo{Port: 80}, true},
+		{"port:80", ports.PortInfo{Port: 8080}, false},
+		{"port:3000-3999", ports.PortInfo{Port: 3500}, true},
+		{"user:dev", ports.PortInfo{User: "devops"}, false},
+		{"proc:~^node", ports.PortInfo{Process: "nodejs"}, true},
+		{"container:*", ports.PortInfo{Container: ""}, false},
+		{"-container:*", ports.PortInfo{Container: ""}, true},
+		{"mem>500MB", ports.PortInfo{RSS: 600 << 20}, true},
+		{"user:root OR port:22", ports.PortInfo{Port: 22, User: "dev"}, true},
+	}
+	for _, tt := range tests {
+		f := newFilterInput()
+		f.input.SetValue(tt.query)
+		if got := f.matches(tt.port); got != tt.match {
+			t.Errorf("%q: matches(%+v) = %v, want %v", tt.query, tt.port, got, tt.match)
+		}
+	}
+}
+
+func TestFilterKeepsLastValidQuery(t *testing.T) {
+	f := newFilterInput()
+	f.input.SetValue("port:80")
+	if !f.matches(ports.PortInfo{Port: 80}) {
+		t.Fatal("port:80 should match port 80")
+	}
+
+	f.input.SetValue("port:80 (")
+	if !f.matches(ports.PortInfo{Port: 80}) || f.matches(ports.PortInfo{Port: 443}) {
+		t.Error("an invalid query should keep the last valid one in effect")
+	}
+	if f.err == nil {
+		t.Fatal("expected a syntax error")
+	}
+	if view := f.view(); !strings.Contains(view, "expected a term") {
+		t.Errorf("view should show the error, got %q", view)
+	}
+
+	f.input.SetValue("port:443")
+	if view := f.view(); f.err != nil || strings.Contains(view, "col ") {
+		t.Errorf("error should clear once the query is valid, got %v in %q", f.err, view)
+	}
+	if !f.matches(ports.PortInfo{Port: 443}) {
+		t.Error("port:443 should match port 443")
+	}
+
+	f.clear()
+	if !f.matches(ports.PortInfo{Port: 1}) || f.err != nil {
+		t.Error("a cleared filter should match everything")
+	}
+}
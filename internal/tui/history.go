@@ -0,0 +1,136 @@
+package tui
+
+import (
+	"time"
+
+	"github.com/legostin/reap/internal/ports"
+)
+
+// historySize is the number of scans kept per process, two minutes at the
+// default refresh interval.
+const historySize = 60
+
+// resourceSample is the resource usage of a process at one scan.
+type resourceSample struct {
+	rss int64 // bytes, 0 if unknown
+	cpu float64
+}
+
+// processHistory is the usage of one process over recent scans, oldest
+// first. start tells a reused PID apart from the process seen before.
+type processHistory struct {
+	start   time.Time
+	samples []resourceSample
+}
+
+// resourceHistory keeps a bounded per-PID history of memory and CPU across
+// scans, so trends show up in sparklines.
+type resourceHistory struct {
+	procs map[int]*processHistory
+}
+
+func newResourceHistory() *resourceHistory {
+	return &resourceHistory{procs: make(map[int]*processHistory)}
+}
+
+// record appends one sample per process in items and forgets processes
+// that are gone.
+func (h *resourceHistory) record(items []ports.PortInfo) {
+	seen := make(map[int]bool, len(items))
+	for _, p := range items {
+		if seen[p.PID] {
+			continue
+		}
+		seen[p.PID] = true
+		ph := h.procs[p.PID]
+		if ph == nil || !ph.start.Equal(p.StartTime) {
+			ph = &processHistory{start: p.StartTime}
+			h.procs[p.PID] = ph
+		}
+		ph.samples = append(ph.samples, resourceSample{rss: p.RSS, cpu: p.CPUPercent})
+		if n := len(ph.samples); n > historySize {
+			ph.samples = append(ph.samples[:0], ph.samples[n-historySize:]...)
+		}
+	}
+	for pid := range h.procs {
+		if !seen[pid] {
+			delete(h.procs, pid)
+		}
+	}
+}
+
+// samples returns the recorded samples of pid, oldest first.
+func (h *resourceHistory) samples(pid int) []resourceSample {
+	if h == nil || h.procs[pid] == nil {
+		return nil
+	}
+	return h.procs[pid].samples
+}
+
+// memorySparkline renders the last width RSS samples of pid, scaled
+// between their minimum and maximum so slow growth stands out. It is empty
+// until there are two samples with a known RSS.
+func (h *resourceHistory) memorySparkline(pid, width int) string {
+	var values []float64
+	for _, s := range lastSamples(h.samples(pid), width) {
+		if s.rss > 0 {
+			values = append(values, float64(s.rss))
+		}
+	}
+	if len(values) < 2 {
+		return ""
+	}
+	lo, hi := minMax(values)
+	// changes under 1% are noise
+	if hi-lo < hi/100 {
+		lo = hi
+	}
+	return sparkline(values, lo, hi)
+}
+
+// cpuSparkline renders the last width CPU samples of pid, scaled from
+// zero to their maximum. Usage under 1% draws a flat line.
+func (h *resourceHistory) cpuSparkline(pid, width int) string {
+	samples := lastSamples(h.samples(pid), width)
+	if len(samples) < 2 {
+		return ""
+	}
+	values := make([]float64, len(samples))
+	for i, s := range samples {
+		values[i] = s.cpu
+	}
+	_, hi := minMax(values)
+	return sparkline(values, 0, max(hi, 1))
+}
+
+func lastSamples(samples []resourceSample, n int) []resourceSample {
+	if n >= 0 && len(samples) > n {
+		return samples[len(samples)-n:]
+	}
+	return samples
+}
+
+func minMax(values []float64) (lo, hi float64) {
+	lo, hi = values[0], values[0]
+	for _, v := range values[1:] {
+		lo, hi = min(lo, v), max(hi, v)
+	}
+	return lo, hi
+}
+
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline draws one block per value, from ▁ at lo to █ at hi. When lo
+// and hi are equal every value is drawn at the bottom.
+func sparkline(values []float64, lo, hi float64) string {
+	out := make([]rune, len(values))
+	for i, v := range values {
+		level := 0
+		if hi > lo {
+			f := (min(max(v, lo), hi) - lo) / (hi - lo)
+			level = int(f*float64(len(sparkBlocks)-1) + 0.5)
+		}
+		out[i] = sparkBlocks[level]
+	}
+	return string(out)
+}
@@ -0,0 +1,167 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/legostin/reap/internal/config"
+	"github.com/legostin/reap/internal/ports"
+)
+
+func TestSparkline(t *testing.T) {
+	tests := []struct {
+		values []float64
+		lo, hi float64
+		want   string
+	}{
+		{[]float64{0, 1, 2, 3, 4, 5, 6, 7}, 0, 7, "▁▂▃▄▅▆▇█"},
+		{[]float64{5, 5, 5}, 5, 5, "▁▁▁"},
+		{[]float64{-1, 50, 200}, 0, 100, "▁▅█"}, // clamped to the bounds
+		{nil, 0, 1, ""},
+	}
+	for _, tt := range tests {
+		if got := sparkline(tt.values, tt.lo, tt.hi); got != tt.want {
+			t.Errorf("sparkline(%v, %v, %v) = %q, want %q", tt.values, tt.lo, tt.hi, got, tt.want)
+		}
+	}
+}
+
+func TestResourceHistoryRecord(t *testing.T) {
+	h := newResourceHistory()
+	started := time.Date(2026, 10, 17, 8, 0, 0, 0, time.UTC)
+
+	for i := range historySize + 5 {
+		h.record([]ports.PortInfo{
+			{Port: 3000, PID: 100, StartTime: started, RSS: int64(i+1) << 20, CPUPercent: float64(i)},
+			{Port: 3001, PID: 100, StartTime: started}, // same process, one sample per scan
+		})
+	}
+	samples := h.samples(100)
+	if len(samples) != historySize {
+		t.Fatalf("expected %d samples, got %d", historySize, len(samples))
+	}
+	if samples[0].rss != 6<<20 || samples[historySize-1].rss != int64(historySize+5)<<20 {
+		t.Errorf("expected the newest samples, got %d..%d", samples[0].rss, samples[historySize-1].rss)
+	}
+
+	// a reused PID starts a new history
+	h.record([]ports.PortInfo{{Port: 3000, PID: 100, StartTime: started.Add(time.Hour), RSS: 1 << 20}})
+	if got := len(h.samples(100)); got != 1 {
+		t.Errorf("expected a fresh history for a reused PID, got %d samples", got)
+	}
+
+	// processes that are gone are forgotten
+	h.record([]ports.PortInfo{{Port: 5432, PID: 200}})
+	if h.samples(100) != nil {
+		t.Error("expected the history of PID 100 to be dropped")
+	}
+	if got := len(h.samples(200)); got != 1 {
+		t.Errorf("expected 1 sample for PID 200, got %d", got)
+	}
+}
+
+func TestResourceHistoryNil(t *testing.T) {
+	var h *resourceHistory
+	if h.samples(1) != nil || h.memorySparkline(1, 10) != "" || h.cpuSparkline(1, 10) != "" {
+		t.Error("a nil history should have no samples")
+	}
+}
+
+func TestMemorySparkline(t *testing.T) {
+	h := newResourceHistory()
+	for _, mb := range []int64{100, 0, 110, 120, 130, 140} { // 0 = unknown, skipped
+		h.record([]ports.PortInfo{{PID: 100, RSS: mb << 20}})
+	}
+	if got := h.memorySparkline(100, 10); got != "▁▃▅▆█" {
+		t.Errorf("growing memory: got %q", got)
+	}
+	if got := h.memorySparkline(100, 2); got != "▁█" {
+		t.Errorf("last two samples: got %q", got)
+	}
+
+	h = newResourceHistory()
+	for _, kb := range []int64{100000, 100200, 100100} {
+		h.record([]ports.PortInfo{{PID: 100, RSS: kb << 10}})
+	}
+	if got := h.memorySparkline(100, 10); got != "▁▁▁" {
+		t.Errorf("changes under 1%% should be flat, got %q", got)
+	}
+
+	h = newResourceHistory()
+	h.record([]ports.PortInfo{{PID: 100, RSS: 1 << 20}})
+	if got := h.memorySparkline(100, 10); got != "" {
+		t.Errorf("one sample should draw nothing, got %q", got)
+	}
+}
+
+func TestCPUSparkline(t *testing.T) {
+	h := newResourceHistory()
+	for _, cpu := range []float64{0, 50, 100} {
+		h.record([]ports.PortInfo{{PID: 100, CPUPercent: cpu}})
+	}
+	if got := h.cpuSparkline(100, 10); got != "▁▅█" {
+		t.Errorf("got %q", got)
+	}
+
+	h = newResourceHistory()
+	for _, cpu := range []float64{0.1, 0.2, 0.1} {
+		h.record([]ports.PortInfo{{PID: 100, CPUPercent: cpu}})
+	}
+	if got := h.cpuSparkline(100, 10); got != "▂▂▂" {
+		t.Errorf("idle process: got %q", got)
+	}
+}
+
+func TestPortTableTrendColumns(t *testing.T) {
+	cfg := config.Default()
+	cfg.Columns = []string{"port", "mem_trend", "cpu_trend"}
+	pt := newPortTable(cfg)
+	pt.treeMode = false
+	pt.setWidth(120)
+	pt.setHeight(5)
+
+	p := ports.PortInfo{Port: 3000, PID: 100, Process: "node"}
+	pt.history.record([]ports.PortInfo{p})
+	pt.setRows([]ports.PortInfo{p})
+	if view := pt.view(); !strings.Contains(view, "MEM TREND") || !strings.Contains(view, "CPU TREND") {
+		t.Errorf("view should contain the trend headers:\n%s", view)
+	}
+
+	for _, mb := range []int64{10, 20} {
+		p.RSS, p.CPUPercent = mb<<20, float64(mb)
+		pt.history.record([]ports.PortInfo{p})
+	}
+	pt.setRows([]ports.PortInfo{p})
+	if row := pt.renderRow(0); !strings.Contains(row, "▁█") {
+		t.Errorf("row should contain a sparkline: %q", row)
+	}
+}
+
+func TestPortTableExpandedTrends(t *testing.T) {
+	cfg := config.Default()
+	pt := newPortTable(cfg)
+	pt.treeMode = false
+	pt.setHeight(20)
+
+	p := ports.PortInfo{Port: 3000, PID: 100, Process: "node", Command: "node"}
+	if got := len(pt.trendLines(p)); got != 0 {
+		t.Errorf("expected no trends without history, got %d", got)
+	}
+	for _, mb := range []int64{40, 50, 45} {
+		p.RSS, p.CPUPercent = mb<<20, float64(mb)/10
+		pt.history.record([]ports.PortInfo{p})
+	}
+	pt.setRows([]ports.PortInfo{p})
+	pt.toggleExpand()
+
+	out := pt.renderExpanded(p)
+	for _, want := range []string{"Memory", "45.0 MB (min 40.0 MB, max 50.0 MB)", "CPU", "4.5% (max 5.0%)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expanded view should contain %q:\n%s", want, out)
+		}
+	}
+	if got, want := strings.Count(out, "\n"), pt.rowLines(0)-1; got != want {
+		t.Errorf("rendered %d lines, rowLines says %d", got, want)
+	}
+}
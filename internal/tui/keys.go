@@ -9,11 +9,16 @@ type keyMap struct {
 	Kill       key.Binding
 	ForceK     key.Binding
 	KillParent key.Binding
+	Graceful   key.Binding
+	KillTree   key.Binding
+	Mark       key.Binding
+	MarkAll    key.Binding
 	Filter     key.Binding
 	Sort       key.Binding
 	SortRev    key.Binding
 	System     key.Binding
 	Tree       key.Binding
+	Columns    key.Binding
 	Refresh    key.Binding
 	Help       key.Binding
 	Quit       key.Binding
@@ -45,6 +50,22 @@ var keys = keyMap{
 		key.WithKeys("p"),
 		key.WithHelp("p", "kill parent"),
 	),
+	Graceful: key.NewBinding(
+		key.WithKeys("g"),
+		key.WithHelp("g", "graceful kill (SIGTERM, then SIGKILL)"),
+	),
+	KillTree: key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "kill process tree"),
+	),
+	Mark: key.NewBinding(
+		key.WithKeys(" "),
+		key.WithHelp("space", "mark"),
+	),
+	MarkAll: key.NewBinding(
+		key.WithKeys("V"),
+		key.WithHelp("V", "mark all visible"),
+	),
 	Filter: key.NewBinding(
 		key.WithKeys("/"),
 		key.WithHelp("/", "filter"),
@@ -65,6 +86,10 @@ var keys = keyMap{
 		key.WithKeys("t"),
 		key.WithHelp("t", "toggle tree"),
 	),
+	Columns: key.NewBinding(
+		key.WithKeys("c"),
+		key.WithHelp("c", "columns"),
+	),
 	Refresh: key.NewBinding(
 		key.WithKeys("r"),
 		key.WithHelp("r", "refresh"),
@@ -19,11 +19,16 @@ func TestKeysDefinition(t *testing.T) {
 		{"Kill", keys.Kill, []string{"k"}},
 		{"ForceK", keys.ForceK, []string{"K"}},
 		{"KillParent", keys.KillParent, []string{"p"}},
+		{"Graceful", keys.Graceful, []string{"g"}},
+		{"KillTree", keys.KillTree, []string{"x"}},
+		{"Mark", keys.Mark, []string{" "}},
+		{"MarkAll", keys.MarkAll, []string{"V"}},
 		{"Filter", keys.Filter, []string{"/"}},
 		{"Sort", keys.Sort, []string{"s"}},
 		{"SortRev", keys.SortRev, []string{"S"}},
 		{"System", keys.System, []string{"a"}},
 		{"Tree", keys.Tree, []string{"t"}},
+		{"Columns", keys.Columns, []string{"c"}},
 		{"Refresh", keys.Refresh, []string{"r"}},
 		{"Help", keys.Help, []string{"?"}},
 		{"Quit", keys.Quit, []string{"q", "ctrl+c"}},
@@ -92,6 +97,10 @@ func TestKeyMapComplete(t *testing.T) {
 		{"Kill", km.Kill},
 		{"ForceK", km.ForceK},
 		{"KillParent", km.KillParent},
+		{"Graceful", km.Graceful},
+		{"KillTree", km.KillTree},
+		{"Mark", km.Mark},
+		{"MarkAll", km.MarkAll},
 		{"Filter", km.Filter},
 		{"Sort", km.Sort},
 		{"SortRev", km.SortRev},
@@ -2,6 +2,8 @@ package tui
 
 import (
 	"fmt"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -21,25 +23,71 @@ type killResultMsg struct {
 	err error
 }
 
+// killProgressMsg and killDoneMsg come from a graceful kill running in
+// the background; ch delivers the next message.
+type killProgressMsg struct {
+	progress ports.KillProgress
+	ch       <-chan tea.Msg
+}
+type killDoneMsg struct{ result ports.KillResult }
+
+// treeLoadedMsg carries the process table for a tree kill of target.
+type treeLoadedMsg struct {
+	target ports.PortInfo
+	procs  []ports.Process
+	err    error
+}
+type treeKillMsg struct {
+	root int
+	sent int
+	err  error
+}
+
+// bulkKillMsg reports the outcome of killing every marked process and
+// stopping every marked container.
+type bulkKillMsg struct{ results []bulkResult }
+
+type bulkResult struct {
+	pid       int    // 0 for containers
+	container string // container name
+	err       error
+}
+
+type containerActionMsg struct {
+	name   string
+	action ports.ContainerAction
+	err    error
+}
+
 type Model struct {
 	scanner  ports.Scanner
+	killer   *ports.Killer
 	cfg      config.Config
 	allPorts []ports.PortInfo
 	filtered []ports.PortInfo
 	table    portTable
 	filter   filterInput
 	confirm  confirmDialog
+	picker   columnPicker
 	width    int
 	height   int
 	scanning bool
 	err      error
-	status   string
+	status   string // port count, replaced by every scan
+	notice   string // outcome of the last action, kept until the next key press
+	// escalating is set while a graceful kill runs; its progress stays in
+	// notice until it ends
+	escalating bool
 	showHelp bool
 }
 
 func New(scanner ports.Scanner, cfg config.Config) Model {
+	// Before the program starts: detecting the background queries the
+	// terminal.
+	applyTheme(selectTheme(cfg))
 	return Model{
 		scanner: scanner,
+		killer:  ports.NewKiller(scanner, time.Duration(cfg.KillGrace)*time.Second),
 		cfg:     cfg,
 		table:   newPortTable(cfg),
 		filter:  newFilterInput(),
@@ -62,6 +110,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case portsUpdatedMsg:
 		m.scanning = false
 		m.allPorts = msg.ports
+		m.table.history.record(msg.ports)
+		m.table.pruneMarks(msg.ports)
 		m.applyFilter()
 		m.status = fmt.Sprintf("%d ports", len(m.filtered))
 		return m, nil
@@ -81,9 +131,60 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case killResultMsg:
 		if msg.err != nil {
-			m.status = errorStyle.Render(fmt.Sprintf("kill failed: %s", msg.err))
+			m.notice = errorStyle.Render(fmt.Sprintf("kill failed: %s", msg.err))
+		} else {
+			m.notice = successStyle.Render(fmt.Sprintf("killed PID %d", msg.pid))
+		}
+		m.scanning = true
+		return m, scanCmd(m.scanner)
+
+	case killProgressMsg:
+		m.notice = formatKillProgress(msg.progress, m.killer.Grace())
+		return m, waitKillCmd(msg.ch)
+
+	case killDoneMsg:
+		m.escalating = false
+		if msg.result.Err != nil {
+			m.notice = errorStyle.Render(msg.result.Describe())
+		} else {
+			m.notice = successStyle.Render(msg.result.Describe())
+		}
+		m.scanning = true
+		return m, scanCmd(m.scanner)
+
+	case containerActionMsg:
+		if msg.err != nil {
+			m.notice = errorStyle.Render(fmt.Sprintf("%s %s failed: %s", msg.action, msg.name, msg.err))
+		} else {
+			m.notice = successStyle.Render(fmt.Sprintf("%s container %s", msg.action.Done(), msg.name))
+		}
+		m.scanning = true
+		return m, scanCmd(m.scanner)
+
+	case bulkKillMsg:
+		summary, failed := formatBulkSummary(msg.results)
+		if failed {
+			m.notice = errorStyle.Render(summary)
+		} else {
+			m.notice = successStyle.Render(summary)
+		}
+		m.table.clearMarks()
+		m.scanning = true
+		return m, scanCmd(m.scanner)
+
+	case treeLoadedMsg:
+		if msg.err != nil {
+			m.notice = errorStyle.Render(fmt.Sprintf("process tree: %s", msg.err))
+			return m, nil
+		}
+		m.confirm.showTree(msg.target, msg.procs)
+		return m, nil
+
+	case treeKillMsg:
+		if msg.err != nil {
+			m.notice = errorStyle.Render(fmt.Sprintf("killed %d processes in tree of PID %d: %s", msg.sent, msg.root, msg.err))
 		} else {
-			m.status = successStyle.Render(fmt.Sprintf("killed PID %d", msg.pid))
+			m.notice = successStyle.Render(fmt.Sprintf("killed %d processes in tree of PID %d", msg.sent, msg.root))
 		}
 		m.scanning = true
 		return m, scanCmd(m.scanner)
@@ -104,6 +205,10 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if !m.escalating {
+		m.notice = ""
+	}
+
 	// Confirm dialog has highest priority
 	if m.confirm.visible {
 		switch {
@@ -114,15 +219,49 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			if m.confirm.killParent {
 				pid = target.PPID
 			}
+			graceful := m.confirm.graceful
+			tree := m.confirm.tree
+			targets := m.confirm.treeTargets()
+			bulk := m.confirm.bulk
+			container, action := m.confirm.container, m.confirm.action
 			m.confirm.hide()
+			if container {
+				return m, containerActionCmd(target, action, m.containerTimeout())
+			}
+			if len(bulk) > 0 {
+				return m, bulkKillCmd(bulk, force, m.containerTimeout())
+			}
+			if tree {
+				// the root exited, or it is reap or one of its ancestors
+				if len(targets) == 0 {
+					m.notice = fmt.Sprintf("nothing to kill in the tree of PID %d", target.PID)
+					return m, nil
+				}
+				return m, treeKillCmd(target.PID, targets)
+			}
+			if graceful {
+				m.notice = fmt.Sprintf("sending SIGTERM to PID %d...", target.PID)
+				m.escalating = true
+				return m, escalateCmd(m.killer, target)
+			}
 			return m, killCmd(pid, force)
 		case key.Matches(msg, key.NewBinding(key.WithKeys("n", "N", "esc"))):
 			m.confirm.hide()
 			return m, nil
+		case m.confirm.tree && key.Matches(msg, key.NewBinding(key.WithKeys("tab"))):
+			m.confirm.cycleScope()
+			return m, nil
+		case m.confirm.container && key.Matches(msg, key.NewBinding(key.WithKeys("s", "r", "p"))):
+			m.confirm.selectAction(msg.String())
+			return m, nil
 		}
 		return m, nil
 	}
 
+	if m.picker.visible {
+		return m.handlePickerKey(msg)
+	}
+
 	// Filter input
 	if m.filter.active {
 		switch {
@@ -147,27 +286,50 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case key.Matches(msg, keys.Filter):
 		m.filter.activate()
 		return m, m.filter.input.Focus()
-	case key.Matches(msg, keys.Kill):
-		if target, ok := m.selectedPort(); ok {
-			m.confirm.show(target, false, false)
+	case key.Matches(msg, keys.Kill), key.Matches(msg, keys.ForceK):
+		force := key.Matches(msg, keys.ForceK)
+		if marked := m.markedPorts(); len(marked) > 0 {
+			m.confirm.showBulk(marked, force)
+		} else if target, ok := m.selectedPort(); ok {
+			// Signalling a runtime proxy would break the runtime; act on the
+			// container instead.
+			if target.ContainerInfo != nil {
+				m.confirm.showContainer(target)
+			} else {
+				m.confirm.show(target, force, false)
+			}
 		}
 		return m, nil
-	case key.Matches(msg, keys.ForceK):
-		if target, ok := m.selectedPort(); ok {
-			m.confirm.show(target, true, false)
-		}
+	case key.Matches(msg, keys.Mark):
+		m.table.toggleMark()
+		m.table.moveDown()
 		return m, nil
-	case key.Matches(msg, keys.KillParent):
-		if target, ok := m.selectedPort(); ok {
-			if target.PPID > 1 {
-				m.confirm.show(target, false, true)
-			}
+	case key.Matches(msg, keys.MarkAll):
+		m.table.markAllVisible()
+		return m, nil
+	case key.Matches(msg, keys.Graceful), key.Matches(msg, keys.KillTree), key.Matches(msg, keys.KillParent):
+		target, ok := m.selectedPort()
+		switch {
+		case !ok:
+		case target.ContainerInfo != nil:
+			// The proxy, its tree and its parent (the runtime daemon) must
+			// not be signalled; offer the container actions instead.
+			m.confirm.showContainer(target)
+		case key.Matches(msg, keys.Graceful):
+			m.confirm.showGraceful(target)
+		case key.Matches(msg, keys.KillTree):
+			return m, loadTreeCmd(target)
+		case target.PPID > 1:
+			m.confirm.show(target, false, true)
 		}
 		return m, nil
 	case key.Matches(msg, keys.Enter):
 		m.table.toggleExpand()
 		return m, nil
 	case key.Matches(msg, keys.Escape):
+		if m.table.expanded == -1 {
+			m.table.clearMarks()
+		}
 		m.table.expanded = -1
 		return m, nil
 	case key.Matches(msg, keys.Sort):
@@ -186,6 +348,9 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.table.toggleTree()
 		m.table.setRows(m.filtered)
 		return m, nil
+	case key.Matches(msg, keys.Columns):
+		m.picker.show(m.table.columnNames())
+		return m, nil
 	case key.Matches(msg, keys.Refresh):
 		m.scanning = true
 		return m, scanCmd(m.scanner)
@@ -203,6 +368,32 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// handlePickerKey edits the columns while the picker is open. Changes
+// apply to the table at once.
+func (m Model) handlePickerKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "up", "k":
+		m.picker.moveUp()
+	case "down", "j":
+		m.picker.moveDown()
+	case " ", "x":
+		m.picker.toggle()
+	case "K", "shift+up":
+		m.picker.shift(-1)
+	case "J", "shift+down":
+		m.picker.shift(1)
+	case "esc", "enter", "c", "q":
+		m.picker.hide()
+		return m, nil
+	case "ctrl+c":
+		return m, tea.Quit
+	default:
+		return m, nil
+	}
+	m.table.setColumns(m.picker.names())
+	return m, nil
+}
+
 func (m Model) View() string {
 	if m.width == 0 {
 		return "loading..."
@@ -216,7 +407,7 @@ func (m Model) View() string {
 
 	// Filter bar
 	if m.filter.active || m.filter.value() != "" {
-		sections = append(sections, m.filter.input.View())
+		sections = append(sections, m.filter.view())
 	}
 
 	// Table
@@ -230,12 +421,18 @@ func (m Model) View() string {
 	if m.err != nil {
 		status = errorStyle.Render(m.err.Error())
 	}
+	if m.notice != "" {
+		status = m.notice + "  " + status
+	}
+	if n := len(m.table.marked); n > 0 {
+		status = fmt.Sprintf("%d marked  %s", n, status)
+	}
 
-	helpText := "↑↓ navigate  enter expand  k kill  K force  p kill parent  / filter  s/S sort  t tree  ? help  q quit"
+	helpText := "↑↓ navigate  enter expand  space mark  k kill  K force  g graceful  x tree  p kill parent  / filter  s/S sort  t tree  c columns  ? help  q quit"
 	if m.showHelp {
-		helpText = "↑/↓ navigate  j down  enter expand/collapse  k kill (SIGTERM)  K kill (SIGKILL)  " +
-			"p kill parent  / filter  s sort column  S reverse  t toggle tree  a toggle system  r refresh  esc collapse  q quit\n" +
-			"  " + colorLegend()
+		helpText = "↑/↓ navigate  j down  enter expand/collapse  space mark  V mark all visible  k kill (SIGTERM, marked rows if any)  K kill (SIGKILL)  " +
+			"g graceful kill (SIGTERM, wait, SIGKILL)  x kill process tree  p kill parent  / filter  s sort column  S reverse  t toggle tree  c pick columns  a toggle system  r refresh  esc collapse  q quit\n" +
+			"  " + colorLegend(m.table.cfg, m.table.displayed)
 	}
 
 	bar := statusBarStyle.Width(m.width).Render(
@@ -245,6 +442,15 @@ func (m Model) View() string {
 
 	view := lipgloss.JoinVertical(lipgloss.Left, sections...)
 
+	// Overlay column picker
+	if m.picker.visible {
+		return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center,
+			m.picker.view(),
+			lipgloss.WithWhitespaceChars(" "),
+			lipgloss.WithWhitespaceForeground(lipgloss.Color("0")),
+		)
+	}
+
 	// Overlay confirm dialog
 	if m.confirm.visible {
 		dialog := m.confirm.view()
@@ -279,6 +485,27 @@ func (m Model) selectedPort() (ports.PortInfo, bool) {
 	return m.table.displayed[idx], true
 }
 
+func (m Model) containerTimeout() time.Duration {
+	return time.Duration(m.cfg.ContainerTimeout) * time.Second
+}
+
+// markedPorts returns the marked ports in display order, followed by marked
+// ports hidden by the current filter.
+func (m Model) markedPorts() []ports.PortInfo {
+	var marked []ports.PortInfo
+	seen := make(map[portKey]bool)
+	for _, list := range [][]ports.PortInfo{m.table.displayed, m.allPorts} {
+		for _, p := range list {
+			k := keyOf(p)
+			if m.table.marked[k] && !seen[k] {
+				seen[k] = true
+				marked = append(marked, p)
+			}
+		}
+	}
+	return marked
+}
+
 func isSystemProcess(p ports.PortInfo) bool {
 	system := []string{"launchd", "mDNSResponder", "bluetoothd", "rapportd",
 		"sharingd", "ControlCenter", "SystemUIServer"}
@@ -308,11 +535,130 @@ func killCmd(pid int, force bool) tea.Cmd {
 		if force {
 			sig = syscall.SIGKILL
 		}
-		err := syscall.Kill(pid, sig)
+		err := ports.SendSignal(pid, sig)
 		return killResultMsg{pid: pid, err: err}
 	}
 }
 
+func containerActionCmd(target ports.PortInfo, action ports.ContainerAction, timeout time.Duration) tea.Cmd {
+	return func() tea.Msg {
+		err := ports.ApplyContainerAction(target.ContainerInfo, action, timeout)
+		return containerActionMsg{name: target.Container, action: action, err: err}
+	}
+}
+
+// bulkKillCmd signals every marked PID and stops every marked container
+// concurrently, and reports all outcomes at once.
+func bulkKillCmd(targets []ports.PortInfo, force bool, timeout time.Duration) tea.Cmd {
+	return func() tea.Msg {
+		sig := syscall.SIGTERM
+		if force {
+			sig = syscall.SIGKILL
+		}
+		pids, containers := bulkPIDs(targets), bulkContainers(targets)
+		results := make([]bulkResult, len(pids)+len(containers))
+		var wg sync.WaitGroup
+		for i, pid := range pids {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				results[i] = bulkResult{pid: pid, err: ports.SendSignal(pid, sig)}
+			}()
+		}
+		for i, ct := range containers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				err := ports.ApplyContainerAction(ct, ports.ContainerStop, timeout)
+				results[len(pids)+i] = bulkResult{container: ct.Name, err: err}
+			}()
+		}
+		wg.Wait()
+		return bulkKillMsg{results: results}
+	}
+}
+
+// formatBulkSummary aggregates bulk kill results into one status line.
+func formatBulkSummary(results []bulkResult) (string, bool) {
+	var failures []string
+	killed, stopped := 0, 0
+	for _, r := range results {
+		switch {
+		case r.err != nil && r.container != "":
+			failures = append(failures, fmt.Sprintf("container %s (%s)", r.container, r.err))
+		case r.err != nil:
+			failures = append(failures, fmt.Sprintf("PID %d (%s)", r.pid, r.err))
+		case r.container != "":
+			stopped++
+		default:
+			killed++
+		}
+	}
+
+	summary := fmt.Sprintf("killed %d processes", killed)
+	if stopped > 0 {
+		summary += fmt.Sprintf(", stopped %d containers", stopped)
+	}
+	if len(failures) == 0 {
+		return summary, false
+	}
+	return fmt.Sprintf("%s (%d/%d done), failed: %s", summary, killed+stopped, len(results), strings.Join(failures, ", ")), true
+}
+
+func loadTreeCmd(target ports.PortInfo) tea.Cmd {
+	return func() tea.Msg {
+		procs, err := ports.ListProcesses()
+		return treeLoadedMsg{target: target, procs: procs, err: err}
+	}
+}
+
+// treeKillCmd sends SIGTERM to targets, which are ordered leaves-first.
+func treeKillCmd(root int, targets []ports.Process) tea.Cmd {
+	return func() tea.Msg {
+		sent, err := ports.SignalProcesses(targets, syscall.SIGTERM)
+		return treeKillMsg{root: root, sent: sent, err: err}
+	}
+}
+
+// escalateCmd runs a graceful kill in the background and streams its
+// progress as killProgressMsg, ending with killDoneMsg.
+func escalateCmd(killer *ports.Killer, target ports.PortInfo) tea.Cmd {
+	ch := make(chan tea.Msg, 16)
+	go func() {
+		defer close(ch)
+		result := killer.Escalate(target, func(p ports.KillProgress) {
+			select {
+			case ch <- killProgressMsg{progress: p, ch: ch}:
+			default: // drop progress updates if the UI falls behind
+			}
+		})
+		ch <- killDoneMsg{result: result}
+	}()
+	return waitKillCmd(ch)
+}
+
+func waitKillCmd(ch <-chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		msg, ok := <-ch
+		if !ok {
+			return nil
+		}
+		return msg
+	}
+}
+
+func formatKillProgress(p ports.KillProgress, grace time.Duration) string {
+	elapsed := p.Elapsed.Round(100 * time.Millisecond)
+	switch p.Stage {
+	case ports.StageKill:
+		return fmt.Sprintf("PID %d ignored SIGTERM, sent SIGKILL (%s)", p.PID, elapsed)
+	case ports.StageRelease:
+		return fmt.Sprintf("PID %d exited, checking port %d (%s)", p.PID, p.Port, elapsed)
+	default:
+		return fmt.Sprintf("sent SIGTERM to PID %d, waiting %s/%s before SIGKILL", p.PID, elapsed, grace)
+	}
+}
+
 func tickCmd(intervalSec int) tea.Cmd {
 	return tea.Tick(time.Duration(intervalSec)*time.Second, func(t time.Time) tea.Msg {
 		return tickMsg(t)
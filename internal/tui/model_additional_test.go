@@ -355,7 +355,7 @@ func TestModelKillResult(t *testing.T) {
 	updated, cmd := m.Update(killResultMsg{pid: 1234, err: nil})
 	model := updated.(Model)
 
-	if model.status == "" {
+	if model.notice == "" {
 		t.Error("status should be updated after kill")
 	}
 	if !model.scanning {
@@ -372,7 +372,7 @@ func TestModelKillResultError(t *testing.T) {
 	updated, _ := m.Update(killResultMsg{pid: 1234, err: testErr})
 	model := updated.(Model)
 
-	if model.status == "" {
+	if model.notice == "" {
 		t.Error("status should be updated after kill error")
 	}
 }
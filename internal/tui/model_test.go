@@ -1,7 +1,14 @@
 package tui
 
 import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/legostin/reap/internal/config"
@@ -58,6 +65,9 @@ func TestPortsUpdated(t *testing.T) {
 	if len(model.filtered) != 2 {
 		t.Errorf("expected 2 filtered ports, got %d", len(model.filtered))
 	}
+	if got := len(model.table.history.samples(100)); got != 1 {
+		t.Errorf("expected the scan to be recorded in the history, got %d samples", got)
+	}
 }
 
 func TestQuitKey(t *testing.T) {
@@ -302,3 +312,420 @@ func TestSystemToggle(t *testing.T) {
 		t.Error("expected ShowSystem to toggle after pressing a")
 	}
 }
+
+func TestGracefulKillDialog(t *testing.T) {
+	m := testModel()
+	updated, _ := m.Update(portsUpdatedMsg{
+		ports: []ports.PortInfo{
+			{Port: 3000, PID: 100, Process: "node", User: "user"},
+		},
+	})
+	m = updated.(Model)
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("g")})
+	m = updated.(Model)
+
+	if !m.confirm.visible || !m.confirm.graceful {
+		t.Error("expected graceful confirm dialog")
+	}
+	if m.confirm.force {
+		t.Error("graceful kill should not start with SIGKILL")
+	}
+
+	// A regular kill afterwards must not stay graceful
+	m.confirm.hide()
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("k")})
+	m = updated.(Model)
+	if m.confirm.graceful {
+		t.Error("expected graceful flag to reset for k")
+	}
+}
+
+func TestKillProgressUpdatesStatus(t *testing.T) {
+	m := testModel()
+	ch := make(chan tea.Msg)
+	updated, cmd := m.Update(killProgressMsg{
+		progress: ports.KillProgress{PID: 100, Port: 3000, Stage: ports.StageKill},
+		ch:       ch,
+	})
+	model := updated.(Model)
+
+	if !strings.Contains(model.notice, "SIGKILL") {
+		t.Errorf("expected SIGKILL in status, got %q", model.notice)
+	}
+	if cmd == nil {
+		t.Error("expected command waiting for the next progress message")
+	}
+}
+
+func TestKillProgressSurvivesRescan(t *testing.T) {
+	m := testModel()
+	m.width = 400 // keep the status bar on one line
+	m.escalating = true
+	ch := make(chan tea.Msg)
+
+	updated, _ := m.Update(killProgressMsg{progress: ports.KillProgress{PID: 100, Port: 3000}, ch: ch})
+	m = updated.(Model)
+	progress := m.notice
+	updated, _ = m.Update(portsUpdatedMsg{ports: []ports.PortInfo{{Port: 3000, PID: 100, Process: "node"}}})
+	m = updated.(Model)
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	m = updated.(Model)
+	if !strings.Contains(m.View(), progress) {
+		t.Errorf("expected progress %q to survive refreshes and keys, got notice %q", progress, m.notice)
+	}
+
+	updated, _ = m.Update(killDoneMsg{result: ports.KillResult{PID: 100, Port: 3000, Exited: true, Freed: true}})
+	m = updated.(Model)
+	updated, _ = m.Update(portsUpdatedMsg{})
+	m = updated.(Model)
+	if m.escalating || !strings.Contains(m.View(), "port 3000 freed") {
+		t.Errorf("expected the outcome after the rescan, got notice %q", m.notice)
+	}
+}
+
+func TestKillDoneRescans(t *testing.T) {
+	m := testModel()
+	updated, cmd := m.Update(killDoneMsg{result: ports.KillResult{PID: 100, Port: 3000, Exited: true, Freed: true}})
+	model := updated.(Model)
+
+	if !strings.Contains(model.notice, "port 3000 freed") {
+		t.Errorf("expected outcome in status, got %q", model.notice)
+	}
+	if !model.scanning || cmd == nil {
+		t.Error("expected rescan after graceful kill")
+	}
+}
+
+func TestEscalateCmdStreamsResult(t *testing.T) {
+	// The PID is above any pid_max, so SIGTERM fails immediately and the
+	// stream ends with a killDoneMsg.
+	target := ports.PortInfo{Port: 3000, PID: 1 << 30}
+	killer := ports.NewKiller(&mockScanner{}, 0)
+
+	msg := escalateCmd(killer, target)()
+	for {
+		if p, ok := msg.(killProgressMsg); ok {
+			msg = waitKillCmd(p.ch)()
+			continue
+		}
+		break
+	}
+	if _, ok := msg.(killDoneMsg); !ok {
+		t.Fatalf("expected killDoneMsg, got %T", msg)
+	}
+}
+
+func TestKillTreeDialog(t *testing.T) {
+	m := testModel()
+	target := ports.PortInfo{Port: 3000, PID: 200, Process: "node"}
+	procs := []ports.Process{
+		{PID: 100, PPID: 1, PGID: 100, Name: "npm"},
+		{PID: 200, PPID: 100, PGID: 100, Name: "node"},
+	}
+
+	updated, _ := m.Update(treeLoadedMsg{target: target, procs: procs})
+	m = updated.(Model)
+	if !m.confirm.visible || !m.confirm.tree {
+		t.Fatal("expected tree confirm dialog")
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	m = updated.(Model)
+	if m.confirm.scope != ports.ScopeGroup {
+		t.Errorf("expected tab to switch scope, got %s", m.confirm.scope)
+	}
+	if !m.confirm.visible {
+		t.Error("tab should keep the dialog open")
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = updated.(Model)
+	if m.confirm.visible {
+		t.Error("expected esc to cancel tree kill")
+	}
+}
+
+func TestKillTreeEmpty(t *testing.T) {
+	m := testModel()
+	// the root is gone from the process table, so the tree is empty
+	target := ports.PortInfo{Port: 3000, PID: 1 << 30, Process: "node"}
+	procs := []ports.Process{{PID: 100, PPID: 1, PGID: 100, Name: "npm"}}
+
+	updated, _ := m.Update(treeLoadedMsg{target: target, procs: procs})
+	m = updated.(Model)
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
+	m = updated.(Model)
+
+	if cmd != nil {
+		t.Error("an empty tree must not be signalled")
+	}
+	if m.confirm.visible {
+		t.Error("expected the dialog to close")
+	}
+	if !strings.Contains(m.notice, "nothing to kill") {
+		t.Errorf("unexpected status %q", m.notice)
+	}
+}
+
+func TestKillTreeLoadError(t *testing.T) {
+	m := testModel()
+	updated, _ := m.Update(treeLoadedMsg{err: errors.New("ps failed")})
+	model := updated.(Model)
+
+	if model.confirm.visible {
+		t.Error("dialog should not open without a process table")
+	}
+	if !strings.Contains(model.notice, "ps failed") {
+		t.Errorf("expected error in status, got %q", model.notice)
+	}
+}
+
+func TestTreeKillMsgRescans(t *testing.T) {
+	m := testModel()
+	updated, cmd := m.Update(treeKillMsg{root: 200, sent: 3})
+	model := updated.(Model)
+
+	if !strings.Contains(model.notice, "killed 3 processes in tree of PID 200") {
+		t.Errorf("unexpected status %q", model.notice)
+	}
+	if !model.scanning || cmd == nil {
+		t.Error("expected rescan after tree kill")
+	}
+}
+
+func TestMarkAndBulkKillDialog(t *testing.T) {
+	m := testModel()
+	updated, _ := m.Update(portsUpdatedMsg{ports: m.scanner.(*mockScanner).ports})
+	m = updated.(Model)
+
+	space := tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")}
+	updated, _ = m.Update(space)
+	m = updated.(Model)
+	if m.table.cursor != 1 {
+		t.Errorf("expected space to move the cursor down, got %d", m.table.cursor)
+	}
+	updated, _ = m.Update(space)
+	m = updated.(Model)
+
+	if !strings.Contains(m.View(), "2 marked") {
+		t.Error("expected marked count in status bar")
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("k")})
+	m = updated.(Model)
+	if !m.confirm.visible || len(m.confirm.bulk) != 2 {
+		t.Fatalf("expected bulk dialog with 2 targets, got %+v", m.confirm)
+	}
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+	m = updated.(Model)
+	if m.confirm.visible || cmd != nil {
+		t.Error("expected cancel to close the dialog without killing")
+	}
+	if len(m.table.marked) != 2 {
+		t.Error("cancel should keep the marks")
+	}
+}
+
+func TestMarkAllVisibleRespectsFilter(t *testing.T) {
+	m := testModel()
+	updated, _ := m.Update(portsUpdatedMsg{ports: m.scanner.(*mockScanner).ports})
+	m = updated.(Model)
+
+	m.filter.input.SetValue("java")
+	m.applyFilter()
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("V")})
+	m = updated.(Model)
+
+	marked := m.markedPorts()
+	if len(marked) != 1 || marked[0].Process != "java" {
+		t.Errorf("expected only the visible java row to be marked, got %+v", marked)
+	}
+}
+
+func TestEscapeClearsMarks(t *testing.T) {
+	m := testModel()
+	updated, _ := m.Update(portsUpdatedMsg{ports: m.scanner.(*mockScanner).ports})
+	m = updated.(Model)
+	m.table.markAllVisible()
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = updated.(Model)
+	if len(m.table.marked) != 0 {
+		t.Errorf("expected esc to clear marks, got %v", m.table.marked)
+	}
+}
+
+func TestNoticeSurvivesRescan(t *testing.T) {
+	m := testModel()
+	m.width = 400 // keep the status bar on one line
+
+	updated, _ := m.Update(bulkKillMsg{results: []bulkResult{{pid: 100}, {pid: 200}}})
+	m = updated.(Model)
+	summary := m.notice
+	if summary == "" {
+		t.Fatal("expected a summary")
+	}
+	if !strings.Contains(m.View(), summary) {
+		t.Error("expected the summary while the rescan is running")
+	}
+
+	updated, _ = m.Update(portsUpdatedMsg{ports: []ports.PortInfo{{Port: 8080, PID: 300, Process: "java"}}})
+	m = updated.(Model)
+	view := m.View()
+	if !strings.Contains(view, summary) || !strings.Contains(view, "1 ports") {
+		t.Errorf("expected the summary ahead of the port count after a rescan, got status %q", m.status)
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	m = updated.(Model)
+	if m.notice != "" || strings.Contains(m.View(), summary) {
+		t.Error("expected a key press to clear the summary")
+	}
+}
+
+func TestBulkKillMsgSummary(t *testing.T) {
+	m := testModel()
+	m.table.marked[portKey{pid: 100, port: 3000}] = true
+
+	updated, cmd := m.Update(bulkKillMsg{results: []bulkResult{
+		{pid: 100},
+		{pid: 200, err: errors.New("operation not permitted")},
+	}})
+	model := updated.(Model)
+
+	if !strings.Contains(model.notice, "killed 1 processes (1/2 done), failed: PID 200 (operation not permitted)") {
+		t.Errorf("unexpected status %q", model.notice)
+	}
+	if len(model.table.marked) != 0 {
+		t.Error("expected marks to be cleared after a bulk kill")
+	}
+	if !model.scanning || cmd == nil {
+		t.Error("expected rescan after bulk kill")
+	}
+}
+
+func TestFormatBulkSummary(t *testing.T) {
+	tests := []struct {
+		results []bulkResult
+		want    string
+		failed  bool
+	}{
+		{[]bulkResult{{pid: 1}, {pid: 2}}, "killed 2 processes", false},
+		{[]bulkResult{{pid: 1}, {container: "web"}}, "killed 1 processes, stopped 1 containers", false},
+		{
+			[]bulkResult{{pid: 1}, {container: "web", err: errors.New("no such container")}},
+			"killed 1 processes (1/2 done), failed: container web (no such container)",
+			true,
+		},
+	}
+	for _, tt := range tests {
+		summary, failed := formatBulkSummary(tt.results)
+		if summary != tt.want || failed != tt.failed {
+			t.Errorf("got (%q, %v), want (%q, %v)", summary, failed, tt.want, tt.failed)
+		}
+	}
+}
+
+func TestBulkKillCmdReportsEveryPID(t *testing.T) {
+	// PIDs above any pid_max fail without signalling anything real.
+	pids := []int{1 << 30, 1<<30 + 1, 1<<30 + 2}
+	var targets []ports.PortInfo
+	for i, pid := range pids {
+		targets = append(targets, ports.PortInfo{Port: 3000 + i, PID: pid})
+	}
+	msg := bulkKillCmd(targets, false, 0)().(bulkKillMsg)
+
+	if len(msg.results) != len(pids) {
+		t.Fatalf("expected %d results, got %d", len(pids), len(msg.results))
+	}
+	for i, r := range msg.results {
+		if r.pid != pids[i] || r.err == nil {
+			t.Errorf("result %d: got %+v", i, r)
+		}
+	}
+}
+
+func TestKillOnContainerRowOffersContainerActions(t *testing.T) {
+	m := testModel()
+	updated, _ := m.Update(portsUpdatedMsg{ports: []ports.PortInfo{containerTarget("running")}})
+	m = updated.(Model)
+
+	for _, k := range []string{"k", "K"} {
+		updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+		model := updated.(Model)
+		if !model.confirm.container {
+			t.Errorf("%s: expected container dialog instead of a signal", k)
+		}
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("k")})
+	m = updated.(Model)
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+	m = updated.(Model)
+	if m.confirm.action != ports.ContainerRestart || !m.confirm.visible {
+		t.Errorf("expected restart selected in open dialog, got %s", m.confirm.action)
+	}
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+	m = updated.(Model)
+	if m.confirm.visible || cmd == nil {
+		t.Error("expected confirm to run the container action")
+	}
+}
+
+func TestSignalKeysOnContainerRowOfferContainerActions(t *testing.T) {
+	target := containerTarget("running")
+	target.PPID = 1234 // dockerd
+	m := testModel()
+	updated, _ := m.Update(portsUpdatedMsg{ports: []ports.PortInfo{target}})
+	m = updated.(Model)
+
+	for _, k := range []string{"p", "g", "x"} {
+		updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+		model := updated.(Model)
+		if cmd != nil {
+			t.Errorf("%s: expected no command for a container row", k)
+		}
+		if !model.confirm.container || model.confirm.killParent || model.confirm.graceful || model.confirm.tree {
+			t.Errorf("%s: expected the container dialog, got %+v", k, model.confirm)
+		}
+	}
+}
+
+func TestContainerActionCmd(t *testing.T) {
+	var requested string
+	socket := filepath.Join(t.TempDir(), "docker.sock")
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = r.Method + " " + r.URL.RequestURI()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	srv.Listener = l
+	srv.Start()
+	defer srv.Close()
+	t.Setenv("DOCKER_HOST", "unix://"+socket)
+
+	msg := containerActionCmd(containerTarget("running"), ports.ContainerStop, 7*time.Second)().(containerActionMsg)
+	if msg.err != nil {
+		t.Fatalf("unexpected error: %v", msg.err)
+	}
+	if requested != "POST /containers/aaa111/stop?t=7" {
+		t.Errorf("unexpected request %q", requested)
+	}
+
+	m := testModel()
+	updated, cmd := m.Update(msg)
+	model := updated.(Model)
+	if !strings.Contains(model.notice, "stopped container app-web-1") {
+		t.Errorf("unexpected status %q", model.notice)
+	}
+	if cmd == nil {
+		t.Error("expected rescan after container action")
+	}
+}
@@ -0,0 +1,124 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// columnPicker is an overlay that shows, hides and reorders table columns.
+type columnPicker struct {
+	visible bool
+	items   []pickerItem // shown columns in table order, then the hidden ones
+	cursor  int
+}
+
+type pickerItem struct {
+	name  string
+	title string
+	shown bool
+}
+
+// show opens the picker for the columns currently named.
+func (cp *columnPicker) show(current []string) {
+	cp.visible = true
+	cp.cursor = 0
+	cp.items = nil
+	for _, name := range current {
+		if c, ok := findTableColumn(name); ok {
+			cp.items = append(cp.items, pickerItem{c.name, c.title, true})
+		}
+	}
+	for _, c := range tableColumns {
+		if !cp.has(c.name) {
+			cp.items = append(cp.items, pickerItem{c.name, c.title, false})
+		}
+	}
+}
+
+func (cp *columnPicker) has(name string) bool {
+	for _, it := range cp.items {
+		if it.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (cp *columnPicker) hide() { cp.visible = false }
+
+func (cp *columnPicker) moveUp() {
+	if cp.cursor > 0 {
+		cp.cursor--
+	}
+}
+
+func (cp *columnPicker) moveDown() {
+	if cp.cursor < len(cp.items)-1 {
+		cp.cursor++
+	}
+}
+
+// toggle shows or hides the column under the cursor. The last shown column
+// cannot be hidden.
+func (cp *columnPicker) toggle() {
+	if cp.cursor >= len(cp.items) {
+		return
+	}
+	it := &cp.items[cp.cursor]
+	if it.shown && len(cp.names()) == 1 {
+		return
+	}
+	it.shown = !it.shown
+}
+
+// shift moves the column under the cursor by delta places, taking the
+// cursor along.
+func (cp *columnPicker) shift(delta int) {
+	to := cp.cursor + delta
+	if to < 0 || to >= len(cp.items) {
+		return
+	}
+	cp.items[cp.cursor], cp.items[to] = cp.items[to], cp.items[cp.cursor]
+	cp.cursor = to
+}
+
+// names returns the shown columns in order.
+func (cp *columnPicker) names() []string {
+	var names []string
+	for _, it := range cp.items {
+		if it.shown {
+			names = append(names, it.name)
+		}
+	}
+	return names
+}
+
+func (cp *columnPicker) view() string {
+	if !cp.visible {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(pickerTitleStyle.Render("Columns"))
+	b.WriteString("\n\n")
+	for i, it := range cp.items {
+		cursor := "  "
+		if i == cp.cursor {
+			cursor = "▸ "
+		}
+		check := "[ ]"
+		if it.shown {
+			check = "[x]"
+		}
+		line := fmt.Sprintf("%s%s %-10s %s", cursor, check, it.title, expandLabelStyle.Render(it.name))
+		if i == cp.cursor {
+			line = lipgloss.NewStyle().Bold(true).Render(line)
+		}
+		b.WriteString(line + "\n")
+	}
+	bold := lipgloss.NewStyle().Bold(true)
+	b.WriteString("\n" + bold.Render("space") + " show/hide  " + bold.Render("J/K") + " move  " +
+		bold.Render("esc") + " close")
+	return pickerStyle.Render(b.String())
+}
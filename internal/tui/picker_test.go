@@ -0,0 +1,99 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestColumnPickerShow(t *testing.T) {
+	var cp columnPicker
+	cp.show([]string{"process", "port"})
+
+	if !cp.visible {
+		t.Fatal("picker should be visible")
+	}
+	if len(cp.items) != len(tableColumns) {
+		t.Errorf("expected every column listed, got %d", len(cp.items))
+	}
+	if cp.items[0].name != "process" || cp.items[1].name != "port" || cp.items[2].shown {
+		t.Errorf("expected the shown columns first, in order: %+v", cp.items[:3])
+	}
+	if got := strings.Join(cp.names(), ","); got != "process,port" {
+		t.Errorf("names: got %s", got)
+	}
+}
+
+func TestColumnPickerToggleAndShift(t *testing.T) {
+	var cp columnPicker
+	cp.show([]string{"port", "pid"})
+
+	cp.moveDown()
+	cp.moveDown() // first hidden column
+	cp.toggle()
+	if got := strings.Join(cp.names(), ","); got != "port,pid,proto" {
+		t.Errorf("after showing proto: got %s", got)
+	}
+
+	cp.shift(-1)
+	cp.shift(-1)
+	if cp.cursor != 0 {
+		t.Errorf("cursor should follow the moved column, got %d", cp.cursor)
+	}
+	cp.shift(-1) // already first
+	if got := strings.Join(cp.names(), ","); got != "proto,port,pid" {
+		t.Errorf("after moving proto first: got %s", got)
+	}
+
+	cp.toggle()
+	cp.moveDown()
+	cp.toggle()
+	cp.moveDown()
+	cp.toggle() // the last shown column stays
+	if got := strings.Join(cp.names(), ","); got != "pid" {
+		t.Errorf("expected pid to stay shown, got %s", got)
+	}
+}
+
+func TestColumnPickerView(t *testing.T) {
+	var cp columnPicker
+	if cp.view() != "" {
+		t.Error("hidden picker should render nothing")
+	}
+	cp.show([]string{"port"})
+	view := cp.view()
+	for _, want := range []string{"Columns", "[x] PORT", "[ ] PROTO", "space"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view should contain %q:\n%s", want, view)
+		}
+	}
+}
+
+func TestModelColumnPicker(t *testing.T) {
+	m := testModel()
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("c")})
+	m = updated.(Model)
+	if !m.picker.visible {
+		t.Fatal("c should open the column picker")
+	}
+	if !strings.Contains(m.View(), "Columns") {
+		t.Error("view should show the picker")
+	}
+
+	// hide PORT; the change applies at once
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")})
+	m = updated.(Model)
+	if m.table.columns[0].name != "proto" {
+		t.Errorf("expected PORT to be hidden, first column is %s", m.table.columns[0].name)
+	}
+	// q closes the picker instead of quitting
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")})
+	m = updated.(Model)
+	if m.picker.visible {
+		t.Error("q should close the picker")
+	}
+	if len(m.table.columns) != len(defaultTableColumns)-1 {
+		t.Errorf("expected %d columns after closing, got %d", len(defaultTableColumns)-1, len(m.table.columns))
+	}
+}
@@ -4,102 +4,153 @@ import (
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/legostin/reap/internal/config"
+	"github.com/legostin/reap/internal/ports"
 )
 
+// The styles are built from the active theme by applyTheme.
 var (
+	headerStyle       lipgloss.Style
+	statusBarStyle    lipgloss.Style
+	selectedRowStyle  lipgloss.Style
+	dialogStyle       lipgloss.Style
+	dialogTitleStyle  lipgloss.Style
+	errorStyle        lipgloss.Style
+	successStyle      lipgloss.Style
+	filterPromptStyle lipgloss.Style
+	tableHeaderStyle  lipgloss.Style
+	sortedHeaderStyle lipgloss.Style
+	cellStyle         lipgloss.Style
+	markStyle         lipgloss.Style
+	childCellStyle    lipgloss.Style
+	expandLabelStyle  lipgloss.Style
+	expandValueStyle  lipgloss.Style
+	parentStyle       lipgloss.Style
+	sparklineStyle    lipgloss.Style
+	pickerStyle       lipgloss.Style
+	pickerTitleStyle  lipgloss.Style
+
+	portColorMap map[string]lipgloss.TerminalColor
+	monoPorts    bool // ignore colors that the theme does not name
+)
+
+func init() {
+	applyTheme(darkTheme)
+}
+
+// applyTheme rebuilds the styles from t.
+func applyTheme(t theme) {
+	color := func(c lipgloss.TerminalColor) lipgloss.TerminalColor {
+		if c == nil {
+			return lipgloss.NoColor{}
+		}
+		return c
+	}
+
 	headerStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(lipgloss.Color("15")).
-			Background(lipgloss.Color("62")).
-			Padding(0, 1)
+		Bold(true).
+		Foreground(color(t.header)).
+		Background(color(t.headerBg)).
+		Padding(0, 1)
 
 	statusBarStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("241")).
-			Padding(0, 1)
+		Foreground(color(t.status)).
+		Padding(0, 1)
 
 	selectedRowStyle = lipgloss.NewStyle().
-				Bold(true).
-				Foreground(lipgloss.Color("15")).
-				Background(lipgloss.Color("57"))
+		Bold(true).
+		Foreground(color(t.selected)).
+		Background(color(t.selectedBg))
 
 	dialogStyle = lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(lipgloss.Color("196")).
-			Padding(1, 2).
-			Width(50)
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(color(t.dialog)).
+		Padding(1, 2).
+		Width(50)
 
 	dialogTitleStyle = lipgloss.NewStyle().
-				Bold(true).
-				Foreground(lipgloss.Color("196"))
+		Bold(true).
+		Foreground(color(t.dialog))
 
 	errorStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("196"))
+		Foreground(color(t.error))
 
 	successStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("82"))
+		Foreground(color(t.success))
 
 	filterPromptStyle = lipgloss.NewStyle().
-				Foreground(lipgloss.Color("205"))
+		Foreground(color(t.accent))
 
 	tableHeaderStyle = lipgloss.NewStyle().
-				Bold(true).
-				Foreground(lipgloss.Color("252")).
-				Padding(0, 1)
+		Bold(true).
+		Foreground(color(t.tableHeader)).
+		Padding(0, 1)
+
+	sortedHeaderStyle = tableHeaderStyle.
+		Foreground(color(t.accent))
 
 	cellStyle = lipgloss.NewStyle().
-			Padding(0, 1)
+		Padding(0, 1)
+
+	markStyle = lipgloss.NewStyle().
+		Bold(true).
+		Foreground(color(t.mark))
 
 	childCellStyle = lipgloss.NewStyle().
-			Padding(0, 1).
-			Foreground(lipgloss.Color("243"))
+		Padding(0, 1).
+		Foreground(color(t.muted))
 
 	expandLabelStyle = lipgloss.NewStyle().
-				Foreground(lipgloss.Color("243"))
+		Foreground(color(t.muted))
 
 	expandValueStyle = lipgloss.NewStyle().
-				Foreground(lipgloss.Color("252"))
+		Foreground(color(t.text))
 
 	parentStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("214"))
-)
+		Foreground(color(t.parent))
+
+	sparklineStyle = lipgloss.NewStyle().
+		Foreground(color(t.sparkline))
+
+	pickerStyle = lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(color(t.picker)).
+		Padding(1, 2)
+
+	pickerTitleStyle = lipgloss.NewStyle().
+		Bold(true).
+		Foreground(color(t.picker))
 
-var portColorMap = map[string]lipgloss.Color{
-	"green":   lipgloss.Color("82"),
-	"yellow":  lipgloss.Color("220"),
-	"cyan":    lipgloss.Color("87"),
-	"magenta": lipgloss.Color("213"),
-	"red":     lipgloss.Color("196"),
-	"blue":    lipgloss.Color("75"),
-	"white":   lipgloss.Color("15"),
-	"dim":     lipgloss.Color("241"),
+	monoPorts = t.mono
+	portColorMap = make(map[string]lipgloss.TerminalColor, len(t.ports))
+	for name, c := range t.ports {
+		portColorMap[name] = c
+	}
+	for _, name := range []string{"green", "yellow", "cyan", "magenta", "red", "blue", "white", "dim"} {
+		if _, ok := portColorMap[name]; !ok {
+			portColorMap[name] = lipgloss.NoColor{}
+		}
+	}
 }
 
+// portStyle colors a port by its color name. A hex or ANSI color that the
+// theme does not name is used as is; unknown names are dim.
 func portStyle(colorName string) lipgloss.Style {
-	c, ok := portColorMap[colorName]
+	c, ok := portColorMap[strings.ToLower(colorName)]
 	if !ok {
-		c = portColorMap["dim"]
+		if c, ok = parseColor(colorName); !ok || monoPorts {
+			c = portColorMap["dim"]
+		}
 	}
 	return lipgloss.NewStyle().Foreground(c)
 }
 
-func colorLegend() string {
-	items := []struct {
-		color string
-		label string
-	}{
-		{"green", "frontend"},
-		{"yellow", "backend"},
-		{"cyan", "flask/vite"},
-		{"magenta", "postgres"},
-		{"red", "redis"},
-		{"blue", "mysql/mongo"},
-		{"white", "http/s"},
-		{"dim", "other"},
-	}
+// colorLegend explains the colors of items by the rules that chose them.
+func colorLegend(cfg config.Config, items []ports.PortInfo) string {
 	var parts []string
-	for _, it := range items {
-		dot := portStyle(it.color).Render("●")
-		parts = append(parts, dot+" "+it.label)
+	for _, e := range cfg.Legend(items) {
+		dot := portStyle(e.Color).Render("●")
+		parts = append(parts, dot+" "+strings.Join(e.Names, "/"))
 	}
 	return strings.Join(parts, "  ")
 }
@@ -3,6 +3,9 @@ package tui
 import (
 	"strings"
 	"testing"
+
+	"github.com/legostin/reap/internal/config"
+	"github.com/legostin/reap/internal/ports"
 )
 
 func TestPortStyle(t *testing.T) {
@@ -42,8 +45,14 @@ func TestPortStyleEmpty(t *testing.T) {
 	}
 }
 
+// legendPorts has one port of every built-in color.
+var legendPorts = []ports.PortInfo{
+	{Port: 3000}, {Port: 4000}, {Port: 5001}, {Port: 5432},
+	{Port: 6379}, {Port: 3306}, {Port: 443}, {Port: 12345},
+}
+
 func TestColorLegend(t *testing.T) {
-	legend := colorLegend()
+	legend := colorLegend(config.Default(), legendPorts)
 
 	// Should contain all category labels
 	expectedLabels := []string{
@@ -65,7 +74,7 @@ func TestColorLegend(t *testing.T) {
 }
 
 func TestColorLegendFormat(t *testing.T) {
-	legend := colorLegend()
+	legend := colorLegend(config.Default(), legendPorts)
 
 	// Should contain dot characters (●)
 	if !strings.Contains(legend, "●") {
@@ -78,6 +87,28 @@ func TestColorLegendFormat(t *testing.T) {
 	}
 }
 
+func TestColorLegendFromRules(t *testing.T) {
+	cfg := config.Default()
+	cfg.PortColors["7000-7099"] = "yellow"
+	cfg.Rules = []config.Rule{{Process: "^orders", Color: "magenta", Label: "orders"}}
+
+	legend := colorLegend(cfg, []ports.PortInfo{
+		{Port: 7001, Process: "billing"},
+		{Port: 7002, Process: "orders-api"},
+		{Port: 3000},
+	})
+	for _, want := range []string{"7000-7099", "orders", "frontend"} {
+		if !strings.Contains(legend, want) {
+			t.Errorf("legend should contain %q: %s", want, legend)
+		}
+	}
+	for _, unused := range []string{"redis", "other"} {
+		if strings.Contains(legend, unused) {
+			t.Errorf("legend should not list unused %q: %s", unused, legend)
+		}
+	}
+}
+
 func TestPortColorMapCompleteness(t *testing.T) {
 	// Verify all expected colors exist in the map
 	expectedColors := []string{"green", "yellow", "cyan", "magenta", "red", "blue", "white", "dim"}
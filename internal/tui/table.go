@@ -1,9 +1,9 @@
 package tui
 
 import (
+	"cmp"
 	"fmt"
 	"path/filepath"
-	"sort"
 	"strconv"
 	"strings"
 
@@ -24,15 +24,23 @@ const (
 	sortColumnCount
 )
 
+// sortKeys maps each sort column to the key it orders by.
+var sortKeys = [sortColumnCount]ports.SortKey{
+	sortByPort:    ports.SortPort,
+	sortByPID:     ports.SortPID,
+	sortByProcess: ports.SortProcess,
+	sortByUser:    ports.SortUser,
+	sortByMemory:  ports.SortMemory,
+	sortByUptime:  ports.SortUptime,
+}
+
 type sortState struct {
 	column sortColumn
 	asc    bool
 }
 
-type column struct {
-	title string
-	width int
-}
+// noSort marks a column that has no sort order of its own.
+const noSort sortColumn = -1
 
 // rowMeta holds per-row display metadata for tree rendering.
 type rowMeta struct {
@@ -40,14 +48,28 @@ type rowMeta struct {
 	isChild    bool
 }
 
+// portKey identifies a row across rescans and re-sorts.
+type portKey struct {
+	pid      int
+	port     int
+	protocol string
+}
+
+func keyOf(p ports.PortInfo) portKey {
+	return portKey{pid: p.PID, port: p.Port, protocol: p.Protocol}
+}
+
 type portTable struct {
-	columns   []column
+	columns   []column // configured, in order
+	visible   []column // columns that fit the width, laid out
 	sort      sortState
 	cfg       config.Config
+	history   *resourceHistory
 	displayed []ports.PortInfo
 	meta      []rowMeta // parallel to displayed
 	treeMode  bool
 	expanded  int // index of expanded row, -1 = none
+	marked    map[portKey]bool
 	cursor    int
 	offset    int
 	height    int
@@ -57,20 +79,92 @@ type portTable struct {
 const prefixWidth = 2
 
 func newPortTable(cfg config.Config) portTable {
-	return portTable{
-		columns: []column{
-			{"PORT", 8},
-			{"PID", 8},
-			{"PROCESS", 20},
-			{"USER", 12},
-			{"MEMORY", 10},
-			{"UPTIME", 10},
-		},
+	pt := portTable{
+		columns:  selectTableColumns(cfg.Columns, cfg.ColumnLayout),
 		sort:     sortState{column: sortByPort, asc: true},
 		cfg:      cfg,
+		history:  newResourceHistory(),
 		expanded: -1,
+		marked:   make(map[portKey]bool),
 		treeMode: true,
 	}
+	pt.relayout()
+	return pt
+}
+
+// setColumns shows the named columns in order.
+func (pt *portTable) setColumns(names []string) {
+	pt.columns = selectTableColumns(names, pt.cfg.ColumnLayout)
+	pt.relayout()
+}
+
+// columnNames returns the names of the configured columns in order.
+func (pt *portTable) columnNames() []string {
+	names := make([]string, len(pt.columns))
+	for i, c := range pt.columns {
+		names[i] = c.name
+	}
+	return names
+}
+
+func (pt *portTable) setWidth(w int) {
+	pt.width = w
+	pt.relayout()
+}
+
+func (pt *portTable) relayout() { pt.visible = layoutColumns(pt.columns, pt.width) }
+
+// toggleMark marks or unmarks the row under the cursor.
+func (pt *portTable) toggleMark() {
+	if pt.cursor >= len(pt.displayed) {
+		return
+	}
+	if pt.marked == nil {
+		pt.marked = make(map[portKey]bool)
+	}
+	k := keyOf(pt.displayed[pt.cursor])
+	if pt.marked[k] {
+		delete(pt.marked, k)
+	} else {
+		pt.marked[k] = true
+	}
+}
+
+// markAllVisible marks every displayed row, or unmarks them all if they
+// are already marked. Rows hidden by the filter are left untouched.
+func (pt *portTable) markAllVisible() {
+	if pt.marked == nil {
+		pt.marked = make(map[portKey]bool)
+	}
+	all := true
+	for _, p := range pt.displayed {
+		if !pt.marked[keyOf(p)] {
+			all = false
+			break
+		}
+	}
+	for _, p := range pt.displayed {
+		if all {
+			delete(pt.marked, keyOf(p))
+		} else {
+			pt.marked[keyOf(p)] = true
+		}
+	}
+}
+
+func (pt *portTable) clearMarks() { clear(pt.marked) }
+
+// pruneMarks drops marks for ports that no longer exist.
+func (pt *portTable) pruneMarks(all []ports.PortInfo) {
+	live := make(map[portKey]bool, len(all))
+	for _, p := range all {
+		live[keyOf(p)] = true
+	}
+	for k := range pt.marked {
+		if !live[k] {
+			delete(pt.marked, k)
+		}
+	}
 }
 
 func (pt *portTable) setRows(items []ports.PortInfo) {
@@ -231,7 +325,7 @@ func (pt *portTable) toggleTree() {
 
 func (pt *portTable) rowLines(r int) int {
 	if r == pt.expanded {
-		return 1 + expandedLineCount(pt.displayed[r])
+		return 1 + expandedLineCount(pt.displayed[r]) + len(pt.trendLines(pt.displayed[r]))
 	}
 	return 1
 }
@@ -241,16 +335,18 @@ func (pt *portTable) view() string {
 
 	// Header
 	headerParts := []string{lipgloss.NewStyle().Width(prefixWidth).Render("")}
-	for i, col := range pt.columns {
-		title := col.title
-		if sortColumn(i) == pt.sort.column {
+	for _, col := range pt.visible {
+		title, style := col.title, tableHeaderStyle
+		if col.sort == pt.sort.column {
 			arrow := "▲"
 			if !pt.sort.asc {
 				arrow = "▼"
 			}
 			title += " " + arrow
+			style = sortedHeaderStyle
 		}
-		headerParts = append(headerParts, tableHeaderStyle.Width(col.width).MaxWidth(col.width).Render(title))
+		title = truncate(title, col.width-2)
+		headerParts = append(headerParts, style.Width(col.width).MaxWidth(col.width).Align(col.align).Render(title))
 	}
 	b.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, headerParts...))
 	b.WriteString("\n")
@@ -287,20 +383,19 @@ func (pt *portTable) view() string {
 func (pt *portTable) renderRow(r int) string {
 	p := pt.displayed[r]
 	m := pt.meta[r]
-	colorName := pt.cfg.PortColor(p.Port)
+	colorName := pt.cfg.Color(p)
 	pStyle := portStyle(colorName)
 
-	prefix := "  "
+	prefix := " "
 	if r == pt.expanded {
-		prefix = "▼ "
+		prefix = "▼"
 	} else if r == pt.cursor {
-		prefix = "▸ "
+		prefix = "▸"
 	}
-
-	// Tree-prefixed process name
-	procName := p.Process
-	if m.treePrefix != "" {
-		procName = m.treePrefix + procName
+	if pt.marked[keyOf(p)] {
+		prefix += markStyle.Render("●")
+	} else {
+		prefix += " "
 	}
 
 	// Dim style for child rows
@@ -309,14 +404,27 @@ func (pt *portTable) renderRow(r int) string {
 		cStyle = childCellStyle
 	}
 
-	cells := []string{
-		lipgloss.NewStyle().Width(prefixWidth).Render(prefix),
-		pStyle.Width(pt.columns[0].width).MaxWidth(pt.columns[0].width).Inline(true).Render(strconv.Itoa(p.Port)),
-		cStyle.Width(pt.columns[1].width).MaxWidth(pt.columns[1].width).Inline(true).Render(strconv.Itoa(p.PID)),
-		cStyle.Width(pt.columns[2].width).MaxWidth(pt.columns[2].width).Inline(true).Render(truncate(procName, pt.columns[2].width)),
-		cStyle.Width(pt.columns[3].width).MaxWidth(pt.columns[3].width).Inline(true).Render(truncate(p.User, pt.columns[3].width)),
-		cStyle.Width(pt.columns[4].width).MaxWidth(pt.columns[4].width).Inline(true).Render(p.Memory),
-		cStyle.Width(pt.columns[5].width).MaxWidth(pt.columns[5].width).Inline(true).Render(p.Uptime),
+	cells := []string{lipgloss.NewStyle().Width(prefixWidth).Render(prefix)}
+	for _, col := range pt.visible {
+		// Inline drops the padding, so keep a gap to the next column
+		w := col.width - 1
+		style, value := cStyle, ""
+		if col.value != nil {
+			value = col.value(p)
+		}
+		switch col.name {
+		case "port":
+			style = pStyle
+		case "process":
+			// Tree-prefixed process name
+			value = m.treePrefix + value
+		case "mem_trend":
+			value = cmp.Or(pt.history.memorySparkline(p.PID, w-1), "-")
+		case "cpu_trend":
+			value = cmp.Or(pt.history.cpuSparkline(p.PID, w-1), "-")
+		}
+		cell := style.Width(w).MaxWidth(w).Align(col.align).Inline(true).Render(truncate(value, w))
+		cells = append(cells, cell+style.Inline(true).Render(" "))
 	}
 
 	row := lipgloss.JoinHorizontal(lipgloss.Top, cells...)
@@ -342,10 +450,23 @@ func (pt *portTable) renderExpanded(p ports.PortInfo) string {
 	}
 
 	add("Address", fmt.Sprintf("%s:%d", p.Address, p.Port))
+	add("Service", p.Label)
 	add("Command", p.Command)
 	add("Directory", p.CWD)
-	if p.Container != "" {
-		add("Container", p.Container)
+	if f := p.PortForward; f != nil {
+		add("Forward", formatPortForward(f))
+	} else if p.Container != "" {
+		add("Container", formatContainer(p))
+	}
+	if ci := p.ContainerInfo; ci != nil {
+		add("Image", ci.Image)
+		if ci.Project != "" {
+			add("Compose", ci.Project+"/"+ci.Service)
+		}
+	}
+	for _, t := range pt.trendLines(p) {
+		lines = append(lines, pad+expandLabelStyle.Width(labelW).Render(t.label)+sparklineStyle.Render(t.spark)+
+			expandValueStyle.Render("  "+t.summary))
 	}
 	if p.PPID > 1 {
 		l := expandLabelStyle.Width(labelW).Render("Parent PID")
@@ -353,21 +474,152 @@ func (pt *portTable) renderExpanded(p ports.PortInfo) string {
 		hint := expandLabelStyle.Render("  [p kill parent]")
 		lines = append(lines, pad+l+v+hint)
 	}
+	if len(p.Connections) > 0 {
+		add("Connections", strconv.Itoa(len(p.Connections)))
+		indent := pad + strings.Repeat(" ", labelW)
+		for i, c := range p.Connections {
+			if i == maxExpandedConns {
+				more := fmt.Sprintf("… %d more", len(p.Connections)-maxExpandedConns)
+				lines = append(lines, indent+expandLabelStyle.Render(more))
+				break
+			}
+			lines = append(lines, indent+expandValueStyle.Render(formatConnection(c)))
+		}
+	}
 
 	return "\n" + strings.Join(lines, "\n")
 }
 
+// expandedSparkWidth caps the sparklines of the expanded view.
+const expandedSparkWidth = 40
+
+// trendLine is a sparkline of the expanded view.
+type trendLine struct {
+	label   string
+	spark   string
+	summary string
+}
+
+// trendLines returns the memory and CPU sparklines of p once there are
+// enough scans to draw them.
+func (pt *portTable) trendLines(p ports.PortInfo) []trendLine {
+	var lines []trendLine
+	samples := lastSamples(pt.history.samples(p.PID), expandedSparkWidth)
+	if spark := pt.history.memorySparkline(p.PID, expandedSparkWidth); spark != "" {
+		var lo, hi int64
+		for _, s := range samples {
+			if s.rss > 0 && (lo == 0 || s.rss < lo) {
+				lo = s.rss
+			}
+			hi = max(hi, s.rss)
+		}
+		lines = append(lines, trendLine{"Memory", spark,
+			fmt.Sprintf("%s (min %s, max %s)", ports.FormatBytes(p.RSS), ports.FormatBytes(lo), ports.FormatBytes(hi))})
+	}
+	if spark := pt.history.cpuSparkline(p.PID, expandedSparkWidth); spark != "" {
+		var hi float64
+		for _, s := range samples {
+			hi = max(hi, s.cpu)
+		}
+		lines = append(lines, trendLine{"CPU", spark, fmt.Sprintf("%.1f%% (max %.1f%%)", p.CPUPercent, hi)})
+	}
+	return lines
+}
+
+// maxExpandedConns caps connection lines in the expanded view.
+const maxExpandedConns = 5
+
+// formatConnection renders one connection as "addr:port  STATE  client".
+func formatConnection(c ports.Connection) string {
+	s := fmt.Sprintf("%s:%d  %s", c.RemoteAddr, c.RemotePort, c.State)
+	if c.LocalPID > 0 {
+		name := c.LocalProcess
+		if name == "" {
+			name = "?"
+		}
+		s += fmt.Sprintf("  %s (PID %d)", name, c.LocalPID)
+	}
+	return s
+}
+
+// formatContainer renders the container name with its short ID, state and,
+// unless it is Docker, the runtime.
+func formatContainer(p ports.PortInfo) string {
+	ci := p.ContainerInfo
+	if ci == nil || ci.ID == "" {
+		return p.Container
+	}
+	details := []string{ci.ID}
+	if len(ci.ID) > 12 {
+		details[0] = ci.ID[:12]
+	}
+	if ci.State != "" {
+		details = append(details, ci.State)
+	}
+	if ci.Runtime != "" && ci.Runtime != "docker" {
+		details = append(details, ci.Runtime)
+	}
+	return fmt.Sprintf("%s (%s)", p.Container, strings.Join(details, ", "))
+}
+
+// formatPortForward renders a kubectl port-forward target as
+// "service/api:80 in web (context dev)".
+func formatPortForward(f *ports.PortForward) string {
+	s := f.Target()
+	if f.RemotePort > 0 {
+		s += ":" + strconv.Itoa(f.RemotePort)
+	}
+	s += " in " + f.Namespace
+	if f.Context != "" {
+		s += " (context " + f.Context + ")"
+	}
+	return s
+}
+
+// connCount renders the CONNS cell; UDP sockets have no connections.
+func connCount(p ports.PortInfo) string {
+	if p.Protocol == "udp" {
+		return "-"
+	}
+	return strconv.Itoa(len(p.Connections))
+}
+
+// knownCount renders a thread or FD count; zero means it could not be read.
+func knownCount(n int) string {
+	if n == 0 {
+		return "-"
+	}
+	return strconv.Itoa(n)
+}
+
 func expandedLineCount(p ports.PortInfo) int {
 	n := 2 // Address + Command
+	if p.Label != "" {
+		n++
+	}
 	if p.CWD != "" {
 		n++
 	}
 	if p.Container != "" {
 		n++
 	}
+	if ci := p.ContainerInfo; ci != nil {
+		if ci.Image != "" {
+			n++
+		}
+		if ci.Project != "" {
+			n++
+		}
+	}
 	if p.PPID > 1 {
 		n++
 	}
+	if c := len(p.Connections); c > 0 {
+		n += 1 + min(c, maxExpandedConns)
+		if c > maxExpandedConns {
+			n++
+		}
+	}
 	return n
 }
 
@@ -402,33 +654,20 @@ func (pt *portTable) clampScroll() {
 	}
 }
 
+// sortItems orders items by the sort column on raw values: memory by RSS
+// bytes and uptime by start time.
 func (pt *portTable) sortItems(items []ports.PortInfo) {
-	sort.Slice(items, func(i, j int) bool {
-		var less bool
-		switch pt.sort.column {
-		case sortByPort:
-			less = items[i].Port < items[j].Port
-		case sortByPID:
-			less = items[i].PID < items[j].PID
-		case sortByProcess:
-			less = items[i].Process < items[j].Process
-		case sortByUser:
-			less = items[i].User < items[j].User
-		default:
-			less = items[i].Port < items[j].Port
-		}
-		if !pt.sort.asc {
-			return !less
-		}
-		return less
-	})
+	key := ports.SortPort
+	if pt.sort.column >= 0 && pt.sort.column < sortColumnCount {
+		key = sortKeys[pt.sort.column]
+	}
+	ports.Sort(items, key, !pt.sort.asc)
 }
 
 func (pt *portTable) nextSort()    { pt.sort.column = (pt.sort.column + 1) % sortColumnCount; pt.sort.asc = true }
 func (pt *portTable) reverseSort() { pt.sort.asc = !pt.sort.asc }
 
 func (pt *portTable) setHeight(h int)  { pt.height = h }
-func (pt *portTable) setWidth(w int)   { pt.width = w }
 func (pt *portTable) selectedIndex() int { return pt.cursor }
 
 func truncate(s string, w int) string {
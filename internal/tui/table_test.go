@@ -3,6 +3,7 @@ package tui
 import (
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/legostin/reap/internal/config"
 	"github.com/legostin/reap/internal/ports"
@@ -12,11 +13,11 @@ func TestNewPortTable(t *testing.T) {
 	cfg := config.Default()
 	pt := newPortTable(cfg)
 
-	if len(pt.columns) != 6 {
-		t.Errorf("expected 6 columns, got %d", len(pt.columns))
+	if len(pt.columns) != 9 {
+		t.Errorf("expected 9 columns, got %d", len(pt.columns))
 	}
 
-	expectedColumns := []string{"PORT", "PID", "PROCESS", "USER", "MEMORY", "UPTIME"}
+	expectedColumns := []string{"PORT", "PROTO", "PID", "PROCESS", "LABEL", "USER", "MEMORY", "UPTIME", "CONNS"}
 	for i, col := range pt.columns {
 		if col.title != expectedColumns[i] {
 			t.Errorf("column %d: expected %q, got %q", i, expectedColumns[i], col.title)
@@ -145,6 +146,71 @@ func TestPortTableSortByUser(t *testing.T) {
 	}
 }
 
+func TestPortTableSortByMemory(t *testing.T) {
+	cfg := config.Default()
+	pt := newPortTable(cfg)
+	pt.treeMode = false
+	pt.sort.column = sortByMemory
+	pt.sort.asc = false
+
+	// "999.0 KB" sorts after "2.0 GB" as a string; RSS must be used
+	items := []ports.PortInfo{
+		{Port: 3000, Memory: "999.0 KB", RSS: 999 << 10},
+		{Port: 5432, Memory: "-"},
+		{Port: 8000, Memory: "2.0 GB", RSS: 2 << 30},
+	}
+
+	pt.sortItems(items)
+
+	want := []int{8000, 3000, 5432}
+	for i, p := range items {
+		if p.Port != want[i] {
+			t.Errorf("position %d: got port %d, want %d", i, p.Port, want[i])
+		}
+	}
+}
+
+func TestPortTableSortByUptime(t *testing.T) {
+	cfg := config.Default()
+	pt := newPortTable(cfg)
+	pt.treeMode = false
+	pt.sort.column = sortByUptime
+	pt.sort.asc = true
+
+	now := time.Now()
+	items := []ports.PortInfo{
+		{Port: 3000, Uptime: "2d 1h", StartTime: now.Add(-49 * time.Hour)},
+		{Port: 5432, Uptime: "5m 0s", StartTime: now.Add(-5 * time.Minute)},
+		{Port: 8000, Uptime: "10h 0m", StartTime: now.Add(-10 * time.Hour)},
+	}
+
+	pt.sortItems(items)
+
+	want := []int{5432, 8000, 3000}
+	for i, p := range items {
+		if p.Port != want[i] {
+			t.Errorf("position %d: got port %d, want %d", i, p.Port, want[i])
+		}
+	}
+}
+
+func TestPortTableSortedHeader(t *testing.T) {
+	cfg := config.Default()
+	pt := newPortTable(cfg)
+	pt.setWidth(120)
+	pt.setHeight(20)
+	pt.sort.column = sortByMemory
+	pt.sort.asc = false
+
+	view := pt.view()
+	if !strings.Contains(view, "MEMORY \u25bc") {
+		t.Errorf("header should mark MEMORY as sorted descending:\n%s", view)
+	}
+	if strings.Contains(view, "PORT \u25b2") {
+		t.Error("only the sorted column should carry an arrow")
+	}
+}
+
 func TestPortTableSortDescending(t *testing.T) {
 	cfg := config.Default()
 	pt := newPortTable(cfg)
@@ -171,6 +237,45 @@ func TestPortTableSortDescending(t *testing.T) {
 	}
 }
 
+func TestPortTableConfiguredColumns(t *testing.T) {
+	cfg := config.Default()
+	cfg.Columns = []string{"port", "Process", "bogus", "cpu", "threads", "fds", "read", "write"}
+	pt := newPortTable(cfg)
+	pt.treeMode = false
+	pt.setWidth(120)
+	pt.setHeight(5)
+
+	var titles []string
+	for _, col := range pt.columns {
+		titles = append(titles, col.title)
+	}
+	if got := strings.Join(titles, " "); got != "PORT PROCESS CPU% THREADS FDS READ WRITE" {
+		t.Errorf("columns: got %q", got)
+	}
+
+	pt.setRows([]ports.PortInfo{{Port: 3000, PID: 100, Process: "node", CPUPercent: 12.34,
+		Threads: 7, ReadBytes: 4096, WriteBytes: 5 << 20}})
+	view := pt.view()
+	for _, want := range []string{"CPU%", "12.3", "7", "4 KB", "5.0 MB"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view should contain %q:\n%s", want, view)
+		}
+	}
+	if strings.Contains(view, "USER") {
+		t.Error("view should not contain the hidden USER column")
+	}
+}
+
+func TestPortTableUnknownColumns(t *testing.T) {
+	cfg := config.Default()
+	cfg.Columns = []string{"colour"}
+	pt := newPortTable(cfg)
+
+	if len(pt.columns) != len(defaultTableColumns) {
+		t.Errorf("expected the default columns, got %d", len(pt.columns))
+	}
+}
+
 func TestPortTableNextSort(t *testing.T) {
 	cfg := config.Default()
 	pt := newPortTable(cfg)
@@ -535,6 +640,11 @@ func TestExpandedLineCount(t *testing.T) {
 			min:  3,
 			desc: "with PPID",
 		},
+		{
+			port: ports.PortInfo{Command: "node", Label: "vite"},
+			min:  3,
+			desc: "with label",
+		},
 		{
 			port: ports.PortInfo{Command: "node", CWD: "/app", Container: "my-container", PPID: 50},
 			min:  5,
@@ -734,3 +844,246 @@ func TestPortTableClampScrollBasic(t *testing.T) {
 		t.Errorf("offset should be 0, got %d", pt.offset)
 	}
 }
+
+func TestPortTableViewProtocolColumn(t *testing.T) {
+	cfg := config.Default()
+	pt := newPortTable(cfg)
+	pt.treeMode = false
+	pt.setWidth(120)
+	pt.setHeight(20)
+
+	pt.setRows([]ports.PortInfo{
+		{Port: 53, PID: 100, Process: "dnsmasq", Protocol: "udp"},
+		{Port: 53, PID: 100, Process: "dnsmasq", Protocol: "tcp"},
+	})
+
+	view := pt.view()
+	if !strings.Contains(view, "PROTO") {
+		t.Error("view should contain PROTO header")
+	}
+	if !strings.Contains(view, "udp") || !strings.Contains(view, "tcp") {
+		t.Error("view should show both protocols")
+	}
+}
+
+func TestRenderExpandedConnections(t *testing.T) {
+	pt := newPortTable(config.Default())
+	p := ports.PortInfo{
+		Port: 3000, PID: 100, Protocol: "tcp", Address: "*", Command: "node server.js",
+		Connections: []ports.Connection{
+			{RemoteAddr: "127.0.0.1", RemotePort: 54321, State: "ESTABLISHED", LocalPID: 999, LocalProcess: "curl"},
+			{RemoteAddr: "10.0.0.5", RemotePort: 50000, State: "CLOSE_WAIT"},
+		},
+	}
+
+	out := pt.renderExpanded(p)
+
+	for _, want := range []string{"Connections", "127.0.0.1:54321", "ESTABLISHED", "curl (PID 999)", "10.0.0.5:50000", "CLOSE_WAIT"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expanded view missing %q", want)
+		}
+	}
+	// leading newline + one line per counted entry
+	if got, want := strings.Count(out, "\n"), expandedLineCount(p); got != want {
+		t.Errorf("rendered %d lines, expandedLineCount says %d", got, want)
+	}
+}
+
+func TestRenderExpandedConnectionsTruncated(t *testing.T) {
+	pt := newPortTable(config.Default())
+	p := ports.PortInfo{Port: 3000, PID: 100, Protocol: "tcp", Address: "*", Command: "node"}
+	for i := 0; i < maxExpandedConns+3; i++ {
+		p.Connections = append(p.Connections, ports.Connection{RemoteAddr: "10.0.0.1", RemotePort: 40000 + i, State: "ESTABLISHED"})
+	}
+
+	out := pt.renderExpanded(p)
+
+	if !strings.Contains(out, "… 3 more") {
+		t.Errorf("expected overflow line, got:\n%s", out)
+	}
+	if got, want := strings.Count(out, "\n"), expandedLineCount(p); got != want {
+		t.Errorf("rendered %d lines, expandedLineCount says %d", got, want)
+	}
+}
+
+func TestConnCount(t *testing.T) {
+	tcp := ports.PortInfo{Protocol: "tcp", Connections: make([]ports.Connection, 4)}
+	if got := connCount(tcp); got != "4" {
+		t.Errorf("tcp: got %q", got)
+	}
+	if got := connCount(ports.PortInfo{Protocol: "udp"}); got != "-" {
+		t.Errorf("udp: got %q", got)
+	}
+}
+
+func TestPortTableToggleMark(t *testing.T) {
+	pt := newPortTable(config.Default())
+	pt.setRows([]ports.PortInfo{
+		{Port: 3000, PID: 100, Protocol: "tcp"},
+		{Port: 3000, PID: 100, Protocol: "udp"},
+	})
+
+	pt.toggleMark()
+	if !pt.marked[portKey{pid: 100, port: 3000, protocol: "tcp"}] {
+		t.Fatal("expected tcp row to be marked")
+	}
+	if pt.marked[portKey{pid: 100, port: 3000, protocol: "udp"}] {
+		t.Error("udp row on the same port should not be marked")
+	}
+
+	pt.toggleMark()
+	if len(pt.marked) != 0 {
+		t.Errorf("expected mark to be toggled off, got %v", pt.marked)
+	}
+}
+
+func TestPortTableToggleMarkEmpty(t *testing.T) {
+	pt := portTable{}
+	pt.toggleMark() // must not panic on an empty table
+	if len(pt.marked) != 0 {
+		t.Errorf("expected no marks, got %v", pt.marked)
+	}
+}
+
+func TestPortTableMarkAllVisible(t *testing.T) {
+	pt := newPortTable(config.Default())
+	hidden := portKey{pid: 900, port: 9000, protocol: "tcp"}
+	pt.marked[hidden] = true
+	pt.setRows([]ports.PortInfo{
+		{Port: 3000, PID: 100, Protocol: "tcp"},
+		{Port: 5432, PID: 200, Protocol: "tcp"},
+	})
+
+	pt.markAllVisible()
+	if len(pt.marked) != 3 {
+		t.Fatalf("expected 2 visible rows plus the hidden mark, got %v", pt.marked)
+	}
+
+	// Second press clears the visible rows but keeps marks hidden by the filter
+	pt.markAllVisible()
+	if len(pt.marked) != 1 || !pt.marked[hidden] {
+		t.Errorf("expected only the hidden mark to remain, got %v", pt.marked)
+	}
+}
+
+func TestPortTablePruneMarks(t *testing.T) {
+	pt := newPortTable(config.Default())
+	pt.marked[portKey{pid: 100, port: 3000, protocol: "tcp"}] = true
+	pt.marked[portKey{pid: 200, port: 5432, protocol: "tcp"}] = true
+
+	pt.pruneMarks([]ports.PortInfo{{Port: 3000, PID: 100, Protocol: "tcp"}})
+	if len(pt.marked) != 1 {
+		t.Errorf("expected the exited process to be unmarked, got %v", pt.marked)
+	}
+}
+
+func TestPortTableViewMarkedRow(t *testing.T) {
+	pt := newPortTable(config.Default())
+	pt.setHeight(10)
+	pt.setWidth(100)
+	pt.setRows([]ports.PortInfo{
+		{Port: 3000, PID: 100, Protocol: "tcp", Process: "node"},
+		{Port: 5432, PID: 200, Protocol: "tcp", Process: "postgres"},
+	})
+	pt.moveDown()
+	pt.toggleMark()
+
+	lines := strings.Split(pt.view(), "\n")
+	if strings.Contains(lines[2], "●") {
+		t.Errorf("unmarked row should have no marker: %q", lines[2])
+	}
+	if !strings.Contains(lines[3], "●") {
+		t.Errorf("expected marker on marked row: %q", lines[3])
+	}
+}
+
+func TestRenderExpandedContainer(t *testing.T) {
+	pt := newPortTable(config.Default())
+	p := ports.PortInfo{
+		Port: 8080, Address: "*", Command: "docker-proxy", Container: "app-web-1",
+		ContainerInfo: &ports.Container{
+			ID: "aaa111bbb222ccc333", Image: "nginx:1.25", State: "running",
+			Project: "app", Service: "web",
+		},
+	}
+
+	out := pt.renderExpanded(p)
+	for _, want := range []string{"app-web-1 (aaa111bbb222, running)", "nginx:1.25", "app/web"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in expanded view:\n%s", want, out)
+		}
+	}
+	if lines := strings.Count(out, "\n"); lines != expandedLineCount(p) {
+		t.Errorf("rendered %d lines, expandedLineCount says %d", lines, expandedLineCount(p))
+	}
+}
+
+func TestFormatContainer(t *testing.T) {
+	tests := []struct {
+		p    ports.PortInfo
+		want string
+	}{
+		{ports.PortInfo{Container: "web"}, "web"},
+		{ports.PortInfo{Container: "web", ContainerInfo: &ports.Container{ID: "abc"}}, "web (abc)"},
+		{ports.PortInfo{Container: "web", ContainerInfo: &ports.Container{ID: "abc", State: "paused"}}, "web (abc, paused)"},
+		{ports.PortInfo{Container: "web", ContainerInfo: &ports.Container{ID: "abc", State: "running", Runtime: "docker"}}, "web (abc, running)"},
+		{ports.PortInfo{Container: "web", ContainerInfo: &ports.Container{ID: "abc", State: "running", Runtime: "podman"}}, "web (abc, running, podman)"},
+	}
+	for _, tt := range tests {
+		if got := formatContainer(tt.p); got != tt.want {
+			t.Errorf("formatContainer(%+v) = %q, want %q", tt.p, got, tt.want)
+		}
+	}
+}
+
+func TestRenderExpandedPortForward(t *testing.T) {
+	pt := newPortTable(config.Default())
+	p := ports.PortInfo{
+		Port: 8080, Address: "127.0.0.1", Command: "kubectl port-forward -n web svc/api 8080:80",
+		Container:   "web/service/api",
+		PortForward: &ports.PortForward{Namespace: "web", Kind: "service", Name: "api", RemotePort: 80},
+	}
+
+	out := pt.renderExpanded(p)
+	if !strings.Contains(out, "service/api:80 in web") {
+		t.Errorf("expected forward target in expanded view:\n%s", out)
+	}
+	if strings.Contains(out, "Container") {
+		t.Errorf("expected no container line for a port-forward:\n%s", out)
+	}
+	if lines := strings.Count(out, "\n"); lines != expandedLineCount(p) {
+		t.Errorf("rendered %d lines, expandedLineCount says %d", lines, expandedLineCount(p))
+	}
+}
+
+func TestFormatPortForward(t *testing.T) {
+	tests := []struct {
+		f    ports.PortForward
+		want string
+	}{
+		{ports.PortForward{Namespace: "default", Kind: "pod", Name: "db-0"}, "pod/db-0 in default"},
+		{ports.PortForward{Namespace: "web", Kind: "service", Name: "api", RemotePort: 80}, "service/api:80 in web"},
+		{ports.PortForward{Namespace: "web", Kind: "service", Name: "api", Context: "dev"}, "service/api in web (context dev)"},
+	}
+	for _, tt := range tests {
+		if got := formatPortForward(&tt.f); got != tt.want {
+			t.Errorf("formatPortForward(%+v) = %q, want %q", tt.f, got, tt.want)
+		}
+	}
+}
+
+func TestPortTableExpandedService(t *testing.T) {
+	pt := newPortTable(config.Default())
+	pt.treeMode = false
+	pt.setHeight(10)
+	pt.setRows([]ports.PortInfo{{Port: 5432, PID: 100, Process: "postgres", Command: "postgres -D /data", Label: "postgres"}})
+	pt.toggleExpand()
+
+	view := pt.view()
+	if !strings.Contains(view, "Service") {
+		t.Errorf("expanded view should show the service:\n%s", view)
+	}
+	if lines := strings.Count(pt.renderExpanded(pt.displayed[0]), "\n"); lines != expandedLineCount(pt.displayed[0]) {
+		t.Errorf("expanded view has %d lines, expandedLineCount says %d", lines, expandedLineCount(pt.displayed[0]))
+	}
+}
@@ -0,0 +1,229 @@
+package tui
+
+import (
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/legostin/reap/internal/config"
+)
+
+// theme is the palette the TUI styles are built from.
+type theme struct {
+	header      lipgloss.TerminalColor
+	headerBg    lipgloss.TerminalColor
+	tableHeader lipgloss.TerminalColor
+	selected    lipgloss.TerminalColor
+	selectedBg  lipgloss.TerminalColor
+	text        lipgloss.TerminalColor
+	muted       lipgloss.TerminalColor
+	status      lipgloss.TerminalColor
+	accent      lipgloss.TerminalColor
+	mark        lipgloss.TerminalColor
+	parent      lipgloss.TerminalColor
+	sparkline   lipgloss.TerminalColor
+	dialog      lipgloss.TerminalColor
+	picker      lipgloss.TerminalColor
+	error       lipgloss.TerminalColor
+	success     lipgloss.TerminalColor
+	ports       map[string]lipgloss.TerminalColor // by port color name
+
+	mono bool // no colors at all, not even hex ones from port rules
+}
+
+var darkTheme = theme{
+	header:      lipgloss.Color("15"),
+	headerBg:    lipgloss.Color("62"),
+	tableHeader: lipgloss.Color("252"),
+	selected:    lipgloss.Color("15"),
+	selectedBg:  lipgloss.Color("57"),
+	text:        lipgloss.Color("252"),
+	muted:       lipgloss.Color("243"),
+	status:      lipgloss.Color("241"),
+	accent:      lipgloss.Color("205"),
+	mark:        lipgloss.Color("11"),
+	parent:      lipgloss.Color("214"),
+	sparkline:   lipgloss.Color("39"),
+	dialog:      lipgloss.Color("196"),
+	picker:      lipgloss.Color("62"),
+	error:       lipgloss.Color("196"),
+	success:     lipgloss.Color("82"),
+	ports: map[string]lipgloss.TerminalColor{
+		"green":   lipgloss.Color("82"),
+		"yellow":  lipgloss.Color("220"),
+		"cyan":    lipgloss.Color("87"),
+		"magenta": lipgloss.Color("213"),
+		"red":     lipgloss.Color("196"),
+		"blue":    lipgloss.Color("75"),
+		"white":   lipgloss.Color("15"),
+		"dim":     lipgloss.Color("241"),
+	},
+}
+
+var lightTheme = theme{
+	header:      lipgloss.Color("#ffffff"),
+	headerBg:    lipgloss.Color("#4b4bb5"),
+	tableHeader: lipgloss.Color("#262626"),
+	selected:    lipgloss.Color("#ffffff"),
+	selectedBg:  lipgloss.Color("#5f5fd7"),
+	text:        lipgloss.Color("#262626"),
+	muted:       lipgloss.Color("#6c6c6c"),
+	status:      lipgloss.Color("#6c6c6c"),
+	accent:      lipgloss.Color("#ad1457"),
+	mark:        lipgloss.Color("#b26a00"),
+	parent:      lipgloss.Color("#c25e00"),
+	sparkline:   lipgloss.Color("#0277bd"),
+	dialog:      lipgloss.Color("#c62828"),
+	picker:      lipgloss.Color("#4b4bb5"),
+	error:       lipgloss.Color("#c62828"),
+	success:     lipgloss.Color("#2e7d32"),
+	ports: map[string]lipgloss.TerminalColor{
+		"green":   lipgloss.Color("#2e7d32"),
+		"yellow":  lipgloss.Color("#9e7700"),
+		"cyan":    lipgloss.Color("#00838f"),
+		"magenta": lipgloss.Color("#8e24aa"),
+		"red":     lipgloss.Color("#c62828"),
+		"blue":    lipgloss.Color("#1565c0"),
+		"white":   lipgloss.Color("#212121"),
+		"dim":     lipgloss.Color("#8a8a8a"),
+	},
+}
+
+// highContrastTheme uses the 16 basic colors, which terminals keep readable.
+var highContrastTheme = theme{
+	header:      lipgloss.Color("0"),
+	headerBg:    lipgloss.Color("15"),
+	tableHeader: lipgloss.Color("15"),
+	selected:    lipgloss.Color("0"),
+	selectedBg:  lipgloss.Color("11"),
+	text:        lipgloss.Color("15"),
+	muted:       lipgloss.Color("7"),
+	status:      lipgloss.Color("7"),
+	accent:      lipgloss.Color("14"),
+	mark:        lipgloss.Color("11"),
+	parent:      lipgloss.Color("11"),
+	sparkline:   lipgloss.Color("14"),
+	dialog:      lipgloss.Color("9"),
+	picker:      lipgloss.Color("15"),
+	error:       lipgloss.Color("9"),
+	success:     lipgloss.Color("10"),
+	ports: map[string]lipgloss.TerminalColor{
+		"green":   lipgloss.Color("10"),
+		"yellow":  lipgloss.Color("11"),
+		"cyan":    lipgloss.Color("14"),
+		"magenta": lipgloss.Color("13"),
+		"red":     lipgloss.Color("9"),
+		"blue":    lipgloss.Color("12"),
+		"white":   lipgloss.Color("15"),
+		"dim":     lipgloss.Color("7"),
+	},
+}
+
+// monoTheme is used when NO_COLOR is set. Rows stay apart by the cursor
+// marker and the sorted column by its arrow.
+var monoTheme = theme{mono: true}
+
+var builtinThemes = map[string]theme{
+	"dark":          darkTheme,
+	"light":         lightTheme,
+	"high-contrast": highContrastTheme,
+}
+
+// darkBackground reports whether the terminal background is dark. It asks
+// the terminal, so it must run before the program takes over the input.
+var darkBackground = lipgloss.HasDarkBackground
+
+// noColor reports whether NO_COLOR asks for output without colors.
+func noColor() bool {
+	return os.Getenv("NO_COLOR") != ""
+}
+
+// selectTheme picks the theme named in the config: a user theme, then a
+// built-in one. "auto", an empty or an unknown name picks dark or light by
+// the terminal background. NO_COLOR overrides every theme.
+func selectTheme(cfg config.Config) theme {
+	if noColor() {
+		return monoTheme
+	}
+	name := strings.ToLower(strings.TrimSpace(cfg.Theme))
+	for userName, user := range cfg.Themes {
+		if !strings.EqualFold(userName, name) {
+			continue
+		}
+		base := strings.ToLower(strings.TrimSpace(user.Base))
+		if base == "" {
+			base = name
+		}
+		return builtinTheme(base).with(user)
+	}
+	return builtinTheme(name)
+}
+
+func builtinTheme(name string) theme {
+	if t, ok := builtinThemes[name]; ok {
+		return t
+	}
+	if darkBackground() {
+		return darkTheme
+	}
+	return lightTheme
+}
+
+// with returns t with the valid colors of user applied.
+func (t theme) with(user config.Theme) theme {
+	set := func(dst *lipgloss.TerminalColor, value string) {
+		if c, ok := parseColor(value); ok {
+			*dst = c
+		}
+	}
+	set(&t.header, user.Header)
+	set(&t.headerBg, user.HeaderBg)
+	set(&t.tableHeader, user.TableHeader)
+	set(&t.selected, user.Selected)
+	set(&t.selectedBg, user.SelectedBg)
+	set(&t.text, user.Text)
+	set(&t.muted, user.Muted)
+	set(&t.status, user.Status)
+	set(&t.accent, user.Accent)
+	set(&t.mark, user.Mark)
+	set(&t.parent, user.Parent)
+	set(&t.sparkline, user.Sparkline)
+	set(&t.dialog, user.Dialog)
+	set(&t.picker, user.Picker)
+	set(&t.error, user.Error)
+	set(&t.success, user.Success)
+
+	ports := make(map[string]lipgloss.TerminalColor, len(t.ports)+len(user.Ports))
+	for name, c := range t.ports {
+		ports[name] = c
+	}
+	for name, value := range user.Ports {
+		if c, ok := parseColor(value); ok {
+			ports[strings.ToLower(name)] = c
+		}
+	}
+	t.ports = ports
+	return t
+}
+
+var hexColor = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// parseColor accepts a hex color such as "#5f5fd7" or an ANSI-256 number.
+func parseColor(s string) (lipgloss.TerminalColor, bool) {
+	s = strings.TrimSpace(s)
+	if hexColor.MatchString(s) {
+		return lipgloss.Color(s), true
+	}
+	n := 0
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return nil, false
+		}
+		n = n*10 + int(r-'0')
+		if n > 255 {
+			return nil, false
+		}
+	}
+	return lipgloss.Color(s), s != ""
+}
@@ -0,0 +1,162 @@
+package tui
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/legostin/reap/internal/config"
+)
+
+// withBackground fakes the terminal background for the test.
+func withBackground(t *testing.T, dark bool) {
+	t.Helper()
+	old := darkBackground
+	darkBackground = func() bool { return dark }
+	t.Cleanup(func() { darkBackground = old })
+}
+
+func TestSelectThemeBuiltin(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	withBackground(t, true)
+
+	tests := []struct {
+		name string
+		want theme
+	}{
+		{"dark", darkTheme},
+		{"light", lightTheme},
+		{"High-Contrast", highContrastTheme},
+		{" light ", lightTheme},
+	}
+	for _, tt := range tests {
+		cfg := config.Default()
+		cfg.Theme = tt.name
+		if got := selectTheme(cfg); got.header != tt.want.header || got.selectedBg != tt.want.selectedBg {
+			t.Errorf("selectTheme(%q): got header %v on %v", tt.name, got.header, got.selectedBg)
+		}
+	}
+}
+
+func TestSelectThemeAuto(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	for _, name := range []string{"", "auto", "no-such-theme"} {
+		cfg := config.Default()
+		cfg.Theme = name
+
+		withBackground(t, true)
+		if got := selectTheme(cfg); got.text != darkTheme.text {
+			t.Errorf("theme %q on a dark terminal: expected the dark theme", name)
+		}
+		withBackground(t, false)
+		if got := selectTheme(cfg); got.text != lightTheme.text {
+			t.Errorf("theme %q on a light terminal: expected the light theme", name)
+		}
+	}
+}
+
+func TestSelectThemeNoColor(t *testing.T) {
+	withBackground(t, true)
+	cfg := config.Default()
+	cfg.Theme = "high-contrast"
+
+	t.Setenv("NO_COLOR", "1")
+	if got := selectTheme(cfg); !got.mono {
+		t.Error("NO_COLOR should select the monochrome theme")
+	}
+	t.Setenv("NO_COLOR", "")
+	if got := selectTheme(cfg); got.mono {
+		t.Error("an empty NO_COLOR should be ignored")
+	}
+}
+
+func TestSelectThemeUser(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	withBackground(t, true)
+
+	cfg := config.Default()
+	cfg.Theme = "solarized"
+	cfg.Themes = map[string]config.Theme{
+		"Solarized": {
+			Base:       "light",
+			Header:     "#fdf6e3",
+			SelectedBg: "33",
+			Dialog:     "crimson", // invalid, keeps the base color
+			Ports:      map[string]string{"Green": "#859900", "orange": "#cb4b16", "bad": "#12"},
+		},
+	}
+	got := selectTheme(cfg)
+	if got.header != lipgloss.Color("#fdf6e3") || got.selectedBg != lipgloss.Color("33") {
+		t.Errorf("user colors not applied: header %v, selected %v", got.header, got.selectedBg)
+	}
+	if got.dialog != lightTheme.dialog || got.text != lightTheme.text {
+		t.Error("unset and invalid colors should come from the base theme")
+	}
+	if got.ports["green"] != lipgloss.Color("#859900") || got.ports["orange"] != lipgloss.Color("#cb4b16") {
+		t.Errorf("user port colors not applied: %v", got.ports)
+	}
+	if _, ok := got.ports["bad"]; ok {
+		t.Error("an invalid port color should be skipped")
+	}
+	if got.ports["red"] != lightTheme.ports["red"] {
+		t.Error("other port colors should come from the base theme")
+	}
+	if lightTheme.ports["green"] != lipgloss.Color("#2e7d32") {
+		t.Error("a user theme must not change the built-in one")
+	}
+
+	// a user theme named like a built-in one starts from it
+	cfg.Theme = "dark"
+	cfg.Themes = map[string]config.Theme{"dark": {Header: "#000000"}}
+	got = selectTheme(cfg)
+	if got.header != lipgloss.Color("#000000") || got.headerBg != darkTheme.headerBg {
+		t.Errorf("expected a customised dark theme, got header %v on %v", got.header, got.headerBg)
+	}
+}
+
+func TestParseColor(t *testing.T) {
+	tests := []struct {
+		in string
+		ok bool
+	}{
+		{"#5f5fd7", true},
+		{"#FFF", true},
+		{"62", true},
+		{"0", true},
+		{"255", true},
+		{"256", false},
+		{"#12345", false},
+		{"blue", false},
+		{"", false},
+		{"-1", false},
+	}
+	for _, tt := range tests {
+		if _, ok := parseColor(tt.in); ok != tt.ok {
+			t.Errorf("parseColor(%q) ok = %v, want %v", tt.in, ok, tt.ok)
+		}
+	}
+}
+
+func TestApplyThemePortStyle(t *testing.T) {
+	t.Cleanup(func() { applyTheme(darkTheme) })
+
+	applyTheme(darkTheme.with(config.Theme{Ports: map[string]string{"orange": "#cb4b16"}}))
+	if got := portStyle("Orange").GetForeground(); got != lipgloss.Color("#cb4b16") {
+		t.Errorf("theme port color: got %v", got)
+	}
+	if got := portStyle("#ff8800").GetForeground(); got != lipgloss.Color("#ff8800") {
+		t.Errorf("a hex color from port rules should be used as is, got %v", got)
+	}
+	if got := headerStyle.GetBackground(); got != darkTheme.headerBg {
+		t.Errorf("header background: got %v", got)
+	}
+
+	applyTheme(monoTheme)
+	for _, name := range []string{"green", "#ff8800", "dim"} {
+		if got := portStyle(name).GetForeground(); got != (lipgloss.NoColor{}) {
+			t.Errorf("NO_COLOR: portStyle(%q) has color %v", name, got)
+		}
+	}
+	if got := selectedRowStyle.GetBackground(); got != (lipgloss.NoColor{}) {
+		t.Errorf("NO_COLOR: selected row has background %v", got)
+	}
+}